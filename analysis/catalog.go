@@ -0,0 +1,90 @@
+// Package analysis reports semantic problems in parsed statements, using a
+// Catalog to describe the tables that exist outside the statement.
+package analysis
+
+import (
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// Catalog describes the tables available to a statement.
+type Catalog interface {
+	// Table returns the table with the given dotted name, such as "db.orders",
+	// or nil if it does not exist.
+	Table(name string) *Table
+}
+
+// Table describes a table in a catalog.
+type Table struct {
+	Name    string    `json:"name"`
+	Columns []*Column `json:"columns"`
+
+	// PartitionColumns are the names of the partition columns, outermost
+	// partition first.
+	PartitionColumns []string `json:"partition_columns"`
+}
+
+// Column returns the column with the given name, or nil.
+// Names are compared case-insensitively.
+func (t *Table) Column(name string) *Column {
+	for _, col := range t.Columns {
+		if strings.EqualFold(col.Name, name) {
+			return col
+		}
+	}
+	return nil
+}
+
+// Column describes a column of a table.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// MapCatalog is a Catalog holding tables by their lower-cased name.
+type MapCatalog map[string]*Table
+
+// NewMapCatalog returns a catalog holding tables.
+func NewMapCatalog(tables ...*Table) MapCatalog {
+	c := make(MapCatalog)
+	for _, t := range tables {
+		c.Add(t)
+	}
+	return c
+}
+
+// Add adds t to the catalog, replacing any table with the same name.
+func (c MapCatalog) Add(t *Table) {
+	c[strings.ToLower(t.Name)] = t
+}
+
+// Table returns the table with the given name. Names are compared
+// case-insensitively.
+func (c MapCatalog) Table(name string) *Table {
+	return c[strings.ToLower(name)]
+}
+
+// tableName returns the dotted name of ident without quoting.
+func tableName(ident *query.MultiPartIdent) string {
+	if ident == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, part := range []*query.Ident{ident.First, ident.Second, ident.Third, ident.Name} {
+		if part != nil {
+			parts = append(parts, part.Name)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// lookup returns the table named by ident, or nil if catalog is nil or does
+// not hold the table.
+func lookup(catalog Catalog, ident *query.MultiPartIdent) *Table {
+	if catalog == nil || ident == nil {
+		return nil
+	}
+	return catalog.Table(tableName(ident))
+}
@@ -0,0 +1,235 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// CTEIssueKind is the kind of problem found with a common table expression.
+type CTEIssueKind int
+
+const (
+	// UnusedCTE is a CTE never referenced by the query or by later CTEs.
+	UnusedCTE CTEIssueKind = iota + 1
+
+	// ShadowedTable is a CTE whose name hides a table in the catalog.
+	ShadowedTable
+
+	// CircularCTE is a CTE of WITH RECURSIVE that references itself
+	// through other CTEs.
+	CircularCTE
+
+	// CTEColumnMismatch is a CTE whose column list is not the same length
+	// as the result columns of its query.
+	CTEColumnMismatch
+)
+
+// String returns the name of the kind.
+func (k CTEIssueKind) String() string {
+	switch k {
+	case UnusedCTE:
+		return "unused"
+	case ShadowedTable:
+		return "shadowed-table"
+	case CircularCTE:
+		return "circular"
+	case CTEColumnMismatch:
+		return "column-mismatch"
+	default:
+		return fmt.Sprintf("CTEIssueKind(%d)", int(k))
+	}
+}
+
+// CTEIssue is a problem found with a common table expression.
+type CTEIssue struct {
+	Kind    CTEIssueKind `json:"kind"`
+	Pos     query.Pos    `json:"pos"`
+	Name    string       `json:"name"`
+	Message string       `json:"message"`
+}
+
+// String returns the issue as "line:column: message".
+func (i CTEIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Pos, i.Message)
+}
+
+// CheckCTEs returns the problems found with every WITH clause in stmt,
+// ordered by position. Shadowed tables are only reported if catalog is
+// not nil.
+func CheckCTEs(stmt query.Statement, catalog Catalog) []CTEIssue {
+	var issues []CTEIssue
+	query.Inspect(stmt, func(n query.Node) bool {
+		var with *query.WithClause
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			with = n.WithClause
+		case *query.InsertStatement:
+			with = n.WithClause
+		case *query.DeleteStatement:
+			with = n.WithClause
+		}
+		if with != nil {
+			issues = append(issues, checkWithClause(n, with, catalog)...)
+		}
+		return true
+	})
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		return issues[i].Pos.Offset < issues[j].Pos.Offset
+	})
+	return issues
+}
+
+// checkWithClause checks the CTEs of with, which belongs to owner.
+func checkWithClause(owner query.Node, with *query.WithClause, catalog Catalog) []CTEIssue {
+	recursive := with.Recursive.IsValid()
+	ctes := with.CTEs
+
+	// Tables referenced by the query itself and by each CTE.
+	used := tableRefs(owner, with)
+	refs := make([]map[string]bool, len(ctes))
+	for i, cte := range ctes {
+		refs[i] = tableRefs(cte, nil)
+	}
+
+	var issues []CTEIssue
+	for i, cte := range ctes {
+		if cte.TableName == nil {
+			continue
+		}
+		name := cte.TableName.Name
+		key := strings.ToLower(name)
+
+		referenced := used[key]
+		for j := range ctes {
+			if j > i || (recursive && j != i) {
+				referenced = referenced || refs[j][key]
+			}
+		}
+		if !referenced {
+			issues = append(issues, CTEIssue{
+				Kind:    UnusedCTE,
+				Pos:     cte.TableName.NamePos,
+				Name:    name,
+				Message: fmt.Sprintf("CTE %s is never used", name),
+			})
+		}
+
+		if catalog != nil && catalog.Table(name) != nil {
+			issues = append(issues, CTEIssue{
+				Kind:    ShadowedTable,
+				Pos:     cte.TableName.NamePos,
+				Name:    name,
+				Message: fmt.Sprintf("CTE %s shadows a table of the same name", name),
+			})
+		}
+
+		if n, ok := resultColumnCount(cte.Select); ok && len(cte.Columns) != 0 && len(cte.Columns) != n {
+			issues = append(issues, CTEIssue{
+				Kind:    CTEColumnMismatch,
+				Pos:     cte.ColumnsLparen,
+				Name:    name,
+				Message: fmt.Sprintf("CTE %s lists %d columns but its query returns %d", name, len(cte.Columns), n),
+			})
+		}
+	}
+
+	if recursive {
+		issues = append(issues, checkCycles(ctes, refs)...)
+	}
+	return issues
+}
+
+// checkCycles reports each group of CTEs that reference one another.
+// A CTE that only references itself is ordinary recursion.
+func checkCycles(ctes []*query.CTE, refs []map[string]bool) []CTEIssue {
+	edges := make([][]int, len(ctes))
+	for i := range ctes {
+		for j, cte := range ctes {
+			if i != j && cte.TableName != nil && refs[i][strings.ToLower(cte.TableName.Name)] {
+				edges[i] = append(edges[i], j)
+			}
+		}
+	}
+
+	reach := make([][]bool, len(ctes))
+	for i := range ctes {
+		reach[i] = make([]bool, len(ctes))
+		var visit func(int)
+		visit = func(j int) {
+			for _, k := range edges[j] {
+				if !reach[i][k] {
+					reach[i][k] = true
+					visit(k)
+				}
+			}
+		}
+		visit(i)
+	}
+
+	var issues []CTEIssue
+	reported := make([]bool, len(ctes))
+	for i, cte := range ctes {
+		if reported[i] || !reach[i][i] {
+			continue
+		}
+
+		var names []string
+		for j := range ctes {
+			if j == i || (reach[i][j] && reach[j][i]) {
+				reported[j] = true
+				names = append(names, ctes[j].TableName.Name)
+			}
+		}
+		issues = append(issues, CTEIssue{
+			Kind:    CircularCTE,
+			Pos:     cte.TableName.NamePos,
+			Name:    cte.TableName.Name,
+			Message: fmt.Sprintf("circular reference between CTEs %s", strings.Join(names, ", ")),
+		})
+	}
+	return issues
+}
+
+// tableRefs returns the lower-cased unqualified table names referenced
+// within n, excluding the subtree skip.
+func tableRefs(n query.Node, skip query.Node) map[string]bool {
+	refs := make(map[string]bool)
+	query.Inspect(n, func(n query.Node) bool {
+		if skip != nil && n == skip {
+			return false
+		}
+		if src, ok := n.(*query.QualifiedTableName); ok && src.Name != nil && src.Name.First == nil && src.Name.Name != nil {
+			refs[strings.ToLower(src.Name.Name.Name)] = true
+		}
+		return true
+	})
+	return refs
+}
+
+// resultColumnCount returns the number of columns returned by sel. Returns
+// false if the count is unknown because sel selects a star.
+func resultColumnCount(sel *query.SelectStatement) (int, bool) {
+	if sel == nil {
+		return 0, false
+	}
+
+	// The operands of a set operation return the same number of columns.
+	sel = sel.Operands()[0]
+	if len(sel.ValueLists) != 0 {
+		return len(sel.ValueLists[0].Exprs), true
+	}
+
+	for _, col := range sel.Columns {
+		if col.Star.IsValid() {
+			return 0, false
+		}
+		if ref, ok := col.Expr.(*query.QualifiedRef); ok && ref.Star.IsValid() {
+			return 0, false
+		}
+	}
+	return len(sel.Columns), true
+}
@@ -0,0 +1,75 @@
+package analysis_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+	"github.com/sbchaos/query/analysis"
+)
+
+func TestCheckCTEs(t *testing.T) {
+	t.Run("Unused", func(t *testing.T) {
+		AssertCTEIssues(t, nil, `WITH a AS (SELECT 1), b AS (SELECT * FROM a), c AS (SELECT 2) SELECT * FROM b`,
+			`1:47: CTE c is never used`)
+		AssertCTEIssues(t, nil, `WITH a AS (SELECT * FROM b), b AS (SELECT 1) SELECT * FROM a`,
+			`1:30: CTE b is never used`)
+		AssertCTEIssues(t, nil, `INSERT INTO t WITH a AS (SELECT 1) SELECT * FROM x WHERE EXISTS (SELECT 1 FROM A)`)
+		AssertCTEIssues(t, nil, `SELECT * FROM (WITH a AS (SELECT 1) SELECT 2) s`,
+			`1:21: CTE a is never used`)
+	})
+
+	t.Run("Shadowed", func(t *testing.T) {
+		catalog := analysis.NewMapCatalog(&analysis.Table{Name: "Orders"}, &analysis.Table{Name: "db.users"})
+		AssertCTEIssues(t, catalog, `WITH orders AS (SELECT * FROM db.users) SELECT * FROM orders`,
+			`1:6: CTE orders shadows a table of the same name`)
+		AssertCTEIssues(t, catalog, `WITH users AS (SELECT * FROM db.users) SELECT * FROM users`)
+	})
+
+	t.Run("Circular", func(t *testing.T) {
+		AssertCTEIssues(t, nil, `WITH RECURSIVE a AS (SELECT * FROM b), b AS (SELECT * FROM a), c AS (SELECT * FROM c) SELECT * FROM a, c`,
+			`1:16: circular reference between CTEs a, b`)
+		AssertCTEIssues(t, nil, `WITH a AS (SELECT * FROM a) SELECT * FROM a`)
+	})
+
+	t.Run("ColumnMismatch", func(t *testing.T) {
+		AssertCTEIssues(t, nil, `WITH a (x, y) AS (SELECT 1), b (x) AS (VALUES (1, 2)), c (x, y) AS (SELECT *, 1 FROM t) SELECT * FROM a, b, c`,
+			`1:8: CTE a lists 2 columns but its query returns 1`,
+			`1:32: CTE b lists 1 columns but its query returns 2`)
+	})
+
+	t.Run("Kind", func(t *testing.T) {
+		issues := analysis.CheckCTEs(ParseStatement(t, `WITH a AS (SELECT 1) SELECT 2`), nil)
+		assert.Equal(t, []analysis.CTEIssue{{
+			Kind:    analysis.UnusedCTE,
+			Pos:     query.Pos{Offset: 5, Line: 1, Column: 6},
+			Name:    "a",
+			Message: "CTE a is never used",
+		}}, issues)
+		assert.Equal(t, "unused", issues[0].Kind.String())
+	})
+}
+
+// AssertCTEIssues asserts that checking the CTEs of s reports want.
+func AssertCTEIssues(tb testing.TB, catalog analysis.Catalog, s string, want ...string) {
+	tb.Helper()
+
+	var got []string
+	for _, issue := range analysis.CheckCTEs(ParseStatement(tb, s), catalog) {
+		got = append(got, issue.String())
+	}
+	assert.Equal(tb, want, got)
+}
+
+// ParseStatement parses s into a single statement and fails the test on error.
+func ParseStatement(tb testing.TB, s string) query.Statement {
+	tb.Helper()
+
+	stmt, err := query.NewParser(strings.NewReader(s)).ParseStatement()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return stmt
+}
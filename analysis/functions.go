@@ -0,0 +1,144 @@
+package analysis
+
+import "strings"
+
+// Signature returns the result type of a call to a function, given the
+// types of its arguments. It returns Unknown if the result cannot be
+// inferred.
+type Signature func(args []Type) Type
+
+// Functions holds function signatures by upper-cased name.
+type Functions map[string]Signature
+
+// Add registers sig under name, replacing any signature of the same name.
+func (f Functions) Add(name string, sig Signature) {
+	f[strings.ToUpper(name)] = sig
+}
+
+// Lookup returns the signature of the function with the given name, or nil.
+// Names are compared case-insensitively.
+func (f Functions) Lookup(name string) Signature {
+	return f[strings.ToUpper(name)]
+}
+
+// Returns returns a signature whose result is always t.
+func Returns(t Type) Signature {
+	return func([]Type) Type { return t }
+}
+
+// ArgType returns a signature whose result is the type of argument i.
+func ArgType(i int) Signature {
+	return func(args []Type) Type {
+		if i >= len(args) {
+			return Unknown
+		}
+		return args[i]
+	}
+}
+
+// CommonArgType returns a signature whose result is the common type of the
+// arguments from index i on, e.g. 0 for COALESCE and 1 for IF.
+func CommonArgType(i int) Signature {
+	return func(args []Type) Type {
+		t := Null
+		for _, arg := range args[min(i, len(args)):] {
+			var ok bool
+			if t, ok = CommonType(t, arg); !ok {
+				return Unknown
+			}
+		}
+		if t.Kind == NullKind {
+			return Unknown
+		}
+		return t
+	}
+}
+
+// sumType returns the type of SUM: integers sum to integers, decimals to
+// decimals and anything else to floats.
+func sumType(args []Type) Type {
+	if len(args) == 0 {
+		return Unknown
+	}
+	switch args[0].Kind {
+	case IntegerKind, DecimalKind:
+		return Type{Kind: args[0].Kind}
+	case UnknownKind, OtherKind:
+		return Unknown
+	default:
+		return Float
+	}
+}
+
+// avgType returns the type of AVG: decimals average to decimals and
+// anything else to floats.
+func avgType(args []Type) Type {
+	if len(args) != 0 && args[0].Kind == DecimalKind {
+		return Decimal
+	}
+	return Float
+}
+
+// arrayType returns the type of aggregates that collect their argument into
+// an array.
+func arrayType(args []Type) Type {
+	if len(args) == 0 || args[0].String() == "" {
+		return Unknown
+	}
+	return ParseType("ARRAY<" + args[0].String() + ">")
+}
+
+// DefaultFunctions returns the signatures of common aggregate, window and
+// scalar functions.
+func DefaultFunctions() Functions {
+	f := make(Functions)
+	add := func(sig Signature, names ...string) {
+		for _, name := range names {
+			f.Add(name, sig)
+		}
+	}
+
+	// Aggregates.
+	add(Returns(Integer), "COUNT", "COUNT_IF", "APPROX_COUNT_DISTINCT", "COUNTIF")
+	add(sumType, "SUM")
+	add(avgType, "AVG")
+	add(Returns(Float), "STDDEV", "STDDEV_POP", "STDDEV_SAMP", "VARIANCE", "VAR_POP", "VAR_SAMP", "CORR", "COVAR_POP", "COVAR_SAMP", "PERCENTILE", "PERCENTILE_APPROX")
+	add(ArgType(0), "MIN", "MAX", "ANY_VALUE", "ARBITRARY", "MEDIAN")
+	add(Returns(String), "STRING_AGG", "GROUP_CONCAT", "LISTAGG", "WM_CONCAT")
+	add(Returns(Boolean), "BOOL_AND", "BOOL_OR", "LOGICAL_AND", "LOGICAL_OR")
+	add(arrayType, "ARRAY_AGG", "COLLECT_LIST", "COLLECT_SET")
+	add(Returns(Integer), "GROUPING", "GROUPING_ID")
+
+	// Window functions.
+	add(Returns(Integer), "ROW_NUMBER", "RANK", "DENSE_RANK", "NTILE")
+	add(Returns(Float), "PERCENT_RANK", "CUME_DIST")
+	add(ArgType(0), "LAG", "LEAD", "FIRST_VALUE", "LAST_VALUE", "NTH_VALUE")
+
+	// Conditionals.
+	add(CommonArgType(0), "COALESCE", "NVL", "IFNULL", "GREATEST", "LEAST")
+	add(CommonArgType(1), "IF", "IFF", "NVL2")
+	add(ArgType(0), "NULLIF")
+
+	// Numbers.
+	add(ArgType(0), "ABS", "ROUND", "TRUNC", "TRUNCATE", "FLOOR", "CEIL", "CEILING", "MOD", "SIGN")
+	add(Returns(Float), "SQRT", "EXP", "LN", "LOG", "LOG10", "LOG2", "POW", "POWER", "RAND", "RANDOM")
+
+	// Strings.
+	add(Returns(String), "CONCAT", "CONCAT_WS", "UPPER", "LOWER", "TRIM", "LTRIM", "RTRIM",
+		"SUBSTR", "SUBSTRING", "REPLACE", "REGEXP_REPLACE", "REGEXP_EXTRACT", "LPAD", "RPAD",
+		"REVERSE", "INITCAP", "FORMAT", "FORMAT_DATE", "FORMAT_TIMESTAMP", "DATE_FORMAT",
+		"FROM_UNIXTIME", "TO_CHAR", "MD5", "SHA1", "SHA2", "TO_JSON_STRING", "GET_JSON_OBJECT",
+		"JSON_EXTRACT_SCALAR", "JSON_VALUE", "SPLIT_PART", "UUID", "GENERATE_UUID")
+	add(Returns(Integer), "LENGTH", "CHAR_LENGTH", "CHARACTER_LENGTH", "INSTR", "STRPOS", "LOCATE")
+	add(Returns(Boolean), "REGEXP_CONTAINS", "STARTS_WITH", "ENDS_WITH")
+
+	// Dates and times.
+	add(Returns(Date), "CURRENT_DATE", "DATE", "TO_DATE", "DATE_ADD", "DATE_SUB", "LAST_DAY", "PARSE_DATE")
+	add(Returns(Timestamp), "CURRENT_TIMESTAMP", "NOW", "GETDATE", "SYSDATE", "TIMESTAMP",
+		"TO_TIMESTAMP", "PARSE_TIMESTAMP", "CURRENT_DATETIME", "DATETIME")
+	add(ArgType(0), "DATE_TRUNC", "TIMESTAMP_TRUNC", "DATETIME_TRUNC")
+	add(Returns(Integer), "UNIX_TIMESTAMP", "DATEDIFF", "DATE_DIFF", "TIMESTAMP_DIFF",
+		"YEAR", "QUARTER", "MONTH", "WEEK", "WEEKOFYEAR", "DAY", "DAYOFMONTH", "DAYOFWEEK",
+		"DAYOFYEAR", "HOUR", "MINUTE", "SECOND", "EXTRACT")
+	return f
+}
@@ -0,0 +1,271 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// Metrics describes the complexity of a statement.
+type Metrics struct {
+	// Joins counts the joins by type: "inner", "left", "right", "full",
+	// "cross", "semi", "anti" and "comma". Natural joins are counted by their
+	// underlying type.
+	Joins map[string]int `json:"joins"`
+
+	// Subqueries counts the queries nested within the statement, excluding
+	// CTEs and compound SELECTs.
+	Subqueries int `json:"subqueries"`
+
+	// SubqueryDepth is the deepest nesting of queries within the statement,
+	// including CTEs. A statement without subqueries has a depth of 0.
+	SubqueryDepth int `json:"subquery_depth"`
+
+	// CTEs counts the common table expressions.
+	CTEs int `json:"ctes"`
+
+	// CTEDepth is the length of the longest chain of CTEs that reference
+	// one another, e.g. 3 if c reads b, which reads a.
+	CTEDepth int `json:"cte_depth"`
+
+	// WindowFunctions counts function calls with an OVER clause.
+	WindowFunctions int `json:"window_functions"`
+
+	// Tables counts the distinct tables read or written.
+	Tables int `json:"tables"`
+
+	// ResultColumns counts the result columns of the statement's query.
+	// A star is counted as a single column.
+	ResultColumns int `json:"result_columns"`
+
+	// CaseExprs counts the CASE expressions, CaseBranches their WHEN
+	// branches and MaxCaseBranches the WHEN branches of the largest one.
+	CaseExprs       int `json:"case_exprs"`
+	CaseBranches    int `json:"case_branches"`
+	MaxCaseBranches int `json:"max_case_branches"`
+
+	// Distinct counts SELECT DISTINCT and aggregates called with DISTINCT.
+	Distinct int `json:"distinct"`
+
+	// Unions counts UNION and UNION DISTINCT, UnionAlls counts UNION ALL.
+	Unions    int `json:"unions"`
+	UnionAlls int `json:"union_alls"`
+}
+
+// JoinCount returns the total number of joins.
+func (m *Metrics) JoinCount() int {
+	var n int
+	for _, count := range m.Joins {
+		n += count
+	}
+	return n
+}
+
+// ComputeMetrics returns the complexity metrics of stmt.
+func ComputeMetrics(stmt query.Statement) *Metrics {
+	mc := &metricsCollector{
+		m:         &Metrics{Joins: make(map[string]int)},
+		sameLevel: make(map[*query.SelectStatement]bool),
+		cteBodies: make(map[*query.SelectStatement]bool),
+		ctes:      make(map[string]bool),
+		tables:    make(map[string]bool),
+	}
+
+	// The query of an INSERT or CREATE TABLE is not a subquery.
+	var root *query.SelectStatement
+	switch stmt := stmt.(type) {
+	case *query.SelectStatement:
+		root = stmt
+	case *query.InsertStatement:
+		root = stmt.Select
+		mc.addTable(stmt.Table)
+	case *query.CreateTableStatement:
+		root = stmt.Select
+		mc.addTable(stmt.Name)
+	case *query.DropTableStatement:
+		mc.addTable(stmt.Name)
+	case *query.TruncateStatement:
+		mc.addTable(stmt.Name)
+	}
+	if root != nil {
+		mc.sameLevel[root] = true
+		first := root.Operands()[0]
+		mc.m.ResultColumns = len(first.Columns)
+		if len(first.ValueLists) != 0 {
+			mc.m.ResultColumns = len(first.ValueLists[0].Exprs)
+		}
+	}
+
+	query.Inspect(stmt, func(n query.Node) bool {
+		if cte, ok := n.(*query.CTE); ok && cte.TableName != nil {
+			mc.ctes[strings.ToLower(cte.TableName.Name)] = true
+		}
+		return true
+	})
+
+	mc.visit(stmt, 0)
+	mc.m.Tables = len(mc.tables)
+	return mc.m
+}
+
+type metricsCollector struct {
+	m *Metrics
+
+	// sameLevel holds queries at the depth of their parent, such as the
+	// operands of a set operation.
+	sameLevel map[*query.SelectStatement]bool
+	cteBodies map[*query.SelectStatement]bool
+
+	ctes   map[string]bool
+	tables map[string]bool
+}
+
+// visit collects the metrics of n, which is nested depth queries deep.
+func (mc *metricsCollector) visit(root query.Node, depth int) {
+	m := mc.m
+	if depth > m.SubqueryDepth {
+		m.SubqueryDepth = depth
+	}
+
+	query.Inspect(root, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			if n != root {
+				switch {
+				case mc.sameLevel[n]:
+					mc.visit(n, depth)
+				case mc.cteBodies[n]:
+					mc.visit(n, depth+1)
+				default:
+					m.Subqueries++
+					mc.visit(n, depth+1)
+				}
+				return false
+			}
+
+			if n.Distinct.IsValid() {
+				m.Distinct++
+			}
+
+		case *query.SetOperation:
+			if n.Union.IsValid() && n.All.IsValid() {
+				m.UnionAlls++
+			} else if n.Union.IsValid() {
+				m.Unions++
+			}
+			mc.sameLevel[n.X] = true
+			mc.sameLevel[n.Y] = true
+
+		case *query.WithClause:
+			m.CTEs += len(n.CTEs)
+			for _, cte := range n.CTEs {
+				if cte.Select != nil {
+					mc.cteBodies[cte.Select] = true
+				}
+			}
+			if d := cteDepth(n); d > m.CTEDepth {
+				m.CTEDepth = d
+			}
+
+		case *query.JoinClause:
+			if n.Operator != nil {
+				m.Joins[joinType(n.Operator)]++
+			}
+
+		case *query.Call:
+			if n.Over != nil {
+				m.WindowFunctions++
+			}
+			if n.Distinct.IsValid() {
+				m.Distinct++
+			}
+
+		case *query.CaseExpr:
+			m.CaseExprs++
+			m.CaseBranches += len(n.Blocks)
+			if len(n.Blocks) > m.MaxCaseBranches {
+				m.MaxCaseBranches = len(n.Blocks)
+			}
+
+		case *query.QualifiedTableName:
+			if name := n.Name; name != nil && (name.First != nil || !mc.ctes[strings.ToLower(name.Name.Name)]) {
+				mc.addTable(name)
+			}
+		}
+		return true
+	})
+}
+
+func (mc *metricsCollector) addTable(name *query.MultiPartIdent) {
+	if name != nil {
+		mc.tables[strings.ToLower(tableName(name))] = true
+	}
+}
+
+// joinType returns the name the join is counted under in Metrics.Joins.
+func joinType(op *query.JoinOperator) string {
+	switch {
+	case op.Comma.IsValid():
+		return "comma"
+	case op.Cross.IsValid():
+		return "cross"
+	case op.Semi.IsValid():
+		return "semi"
+	case op.Anti.IsValid():
+		return "anti"
+	case op.Left.IsValid():
+		return "left"
+	case op.Right.IsValid():
+		return "right"
+	case op.Full.IsValid():
+		return "full"
+	default:
+		return "inner"
+	}
+}
+
+// cteDepth returns the length of the longest chain of CTEs in with that
+// reference one another.
+func cteDepth(with *query.WithClause) int {
+	index := make(map[string]int)
+	for i, cte := range with.CTEs {
+		if cte.TableName != nil {
+			index[strings.ToLower(cte.TableName.Name)] = i
+		}
+	}
+
+	deps := make([][]int, len(with.CTEs))
+	for i, cte := range with.CTEs {
+		for name := range tableRefs(cte, nil) {
+			if j, ok := index[name]; ok && j != i {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+
+	// Memoized longest path; in-progress entries break cycles.
+	depths := make([]int, len(with.CTEs))
+	var depth func(int) int
+	depth = func(i int) int {
+		if depths[i] != 0 {
+			return depths[i]
+		}
+		depths[i] = -1
+		d := 1
+		for _, j := range deps[i] {
+			if dj := depth(j); dj > 0 && dj+1 > d {
+				d = dj + 1
+			}
+		}
+		depths[i] = d
+		return d
+	}
+
+	var max int
+	for i := range with.CTEs {
+		if d := depth(i); d > max {
+			max = d
+		}
+	}
+	return max
+}
@@ -0,0 +1,88 @@
+package analysis_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query/analysis"
+)
+
+func TestComputeMetrics(t *testing.T) {
+	t.Run("Joins", func(t *testing.T) {
+		m := analysis.ComputeMetrics(ParseStatement(t, `SELECT * FROM a JOIN b ON a.id = b.id LEFT JOIN c USING (id), d CROSS JOIN e FULL OUTER JOIN f ON true NATURAL LEFT JOIN g`))
+		assert.Equal(t, map[string]int{"inner": 1, "left": 2, "comma": 1, "cross": 1, "full": 1}, m.Joins)
+		assert.Equal(t, 6, m.JoinCount())
+		assert.Equal(t, 7, m.Tables)
+
+		m = analysis.ComputeMetrics(ParseStatement(t, `SELECT * FROM a RIGHT JOIN b ON a.id = b.id LEFT SEMI JOIN c ON a.id = c.id ANTI JOIN d ON a.id = d.id`))
+		assert.Equal(t, map[string]int{"right": 1, "semi": 1, "anti": 1}, m.Joins)
+	})
+
+	t.Run("Subqueries", func(t *testing.T) {
+		m := analysis.ComputeMetrics(ParseStatement(t, `SELECT (SELECT max(x) FROM t) FROM (SELECT * FROM (SELECT id FROM t) s) s WHERE EXISTS (SELECT 1 FROM u)`))
+		assert.Equal(t, 4, m.Subqueries)
+		assert.Equal(t, 2, m.SubqueryDepth)
+		assert.Equal(t, 2, m.Tables)
+		assert.Equal(t, 1, m.ResultColumns)
+
+		m = analysis.ComputeMetrics(ParseStatement(t, `INSERT INTO t SELECT a FROM x UNION ALL SELECT a FROM y UNION SELECT a FROM z`))
+		assert.Equal(t, 0, m.Subqueries)
+		assert.Equal(t, 0, m.SubqueryDepth)
+		assert.Equal(t, 1, m.UnionAlls)
+		assert.Equal(t, 1, m.Unions)
+		assert.Equal(t, 4, m.Tables)
+
+		m = analysis.ComputeMetrics(ParseStatement(t, `(SELECT a, b FROM x ORDER BY a LIMIT 1) EXCEPT SELECT a, b FROM y`))
+		assert.Equal(t, 0, m.Subqueries)
+		assert.Equal(t, 0, m.Unions)
+		assert.Equal(t, 2, m.ResultColumns)
+	})
+
+	t.Run("CTEs", func(t *testing.T) {
+		m := analysis.ComputeMetrics(ParseStatement(t, `WITH a AS (SELECT * FROM t), b AS (SELECT * FROM a), c AS (SELECT * FROM b JOIN a ON true), d AS (SELECT 1) SELECT * FROM c, d`))
+		assert.Equal(t, 4, m.CTEs)
+		assert.Equal(t, 3, m.CTEDepth)
+		assert.Equal(t, 1, m.SubqueryDepth)
+		assert.Equal(t, 0, m.Subqueries)
+		assert.Equal(t, 1, m.Tables)
+	})
+
+	t.Run("Expressions", func(t *testing.T) {
+		m := analysis.ComputeMetrics(ParseStatement(t, `SELECT DISTINCT
+			row_number() OVER (PARTITION BY a ORDER BY b),
+			sum(x) OVER w,
+			count(DISTINCT y),
+			CASE WHEN a = 1 THEN 'x' WHEN a = 2 THEN 'y' ELSE 'z' END,
+			CASE b WHEN 1 THEN 'x' END
+		FROM t WINDOW w AS (PARTITION BY a)`))
+		assert.Equal(t, 2, m.WindowFunctions)
+		assert.Equal(t, 2, m.Distinct)
+		assert.Equal(t, 2, m.CaseExprs)
+		assert.Equal(t, 3, m.CaseBranches)
+		assert.Equal(t, 2, m.MaxCaseBranches)
+		assert.Equal(t, 5, m.ResultColumns)
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		buf, err := json.Marshal(analysis.ComputeMetrics(ParseStatement(t, `SELECT a FROM t LEFT JOIN u ON true`)))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"joins": {"left": 1},
+			"subqueries": 0,
+			"subquery_depth": 0,
+			"ctes": 0,
+			"cte_depth": 0,
+			"window_functions": 0,
+			"tables": 2,
+			"result_columns": 1,
+			"case_exprs": 0,
+			"case_branches": 0,
+			"max_case_branches": 0,
+			"distinct": 0,
+			"unions": 0,
+			"union_alls": 0
+		}`, string(buf))
+	})
+}
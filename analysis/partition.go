@@ -0,0 +1,557 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// Scan is a read of a table by a statement.
+type Scan struct {
+	Pos   query.Pos `json:"pos"`
+	Name  string    `json:"name"`
+	Alias string    `json:"alias"`
+
+	// Table is the catalog entry of the table, or nil if it is unknown.
+	Table *Table `json:"-"`
+
+	// Ranges holds the partitions selected for each partition column that
+	// is constrained by a prunable predicate, in partition order.
+	Ranges []*PartitionRange `json:"ranges"`
+
+	// Unprunable holds predicates on partition columns that cannot be used
+	// to prune partitions, such as pt != '2024-01-01' or substr(pt, 1, 6).
+	Unprunable []query.Expr `json:"unprunable"`
+
+	// FullScan is true if the table is partitioned but none of its
+	// partition columns is constrained, so every partition is read.
+	FullScan bool `json:"full_scan"`
+}
+
+// String returns a summary of the partitions read by the scan.
+func (s *Scan) String() string {
+	switch {
+	case s.Table == nil || len(s.Table.PartitionColumns) == 0:
+		return fmt.Sprintf("%s: %s is not partitioned", s.Pos, s.Name)
+	case s.FullScan:
+		return fmt.Sprintf("%s: full scan of %s, no prunable filter on %s", s.Pos, s.Name, strings.Join(s.Table.PartitionColumns, ", "))
+	}
+
+	ranges := make([]string, len(s.Ranges))
+	for i, r := range s.Ranges {
+		if r.Empty {
+			return fmt.Sprintf("%s: %s reads no partitions, filter on %s is never true", s.Pos, s.Name, r.Column)
+		}
+		ranges[i] = r.String()
+	}
+	return fmt.Sprintf("%s: %s reads partitions %s", s.Pos, s.Name, strings.Join(ranges, " AND "))
+}
+
+// PartitionRange describes the values of a partition column selected by
+// the predicates of a scan. Values are set by =, IN and ORs of them, bounds
+// by comparisons and BETWEEN. Each predicate narrows the range further.
+type PartitionRange struct {
+	Column string       `json:"column"`
+	Values []query.Expr `json:"values"`
+
+	Low           query.Expr `json:"low"`
+	LowInclusive  bool       `json:"low_inclusive"`
+	High          query.Expr `json:"high"`
+	HighInclusive bool       `json:"high_inclusive"`
+
+	// Empty is true if the predicates contradict each other, as in
+	// pt = '1' AND pt = '2', so no partition is selected.
+	Empty bool `json:"empty"`
+}
+
+// String returns the range as a predicate, e.g. "pt >= '2024-01-01' AND pt < '2024-02-01'".
+func (r *PartitionRange) String() string {
+	if r.Empty {
+		return "FALSE"
+	}
+
+	var parts []string
+	switch len(r.Values) {
+	case 0:
+	case 1:
+		parts = append(parts, fmt.Sprintf("%s = %s", r.Column, r.Values[0]))
+	default:
+		var buf bytes.Buffer
+		for i, v := range r.Values {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(v.String())
+		}
+		parts = append(parts, fmt.Sprintf("%s IN (%s)", r.Column, buf.String()))
+	}
+
+	if r.Low != nil {
+		op := ">"
+		if r.LowInclusive {
+			op = ">="
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %s", r.Column, op, r.Low))
+	}
+	if r.High != nil {
+		op := "<"
+		if r.HighInclusive {
+			op = "<="
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %s", r.Column, op, r.High))
+	}
+	return strings.Join(parts, " AND ")
+}
+
+// PartitionScans returns every table scanned by stmt, ordered by position,
+// along with the partitions each scan selects. References to CTEs are not
+// scans.
+//
+// A scan is constrained by the conjuncts of the WHERE clause of its query
+// and by the ON constraints of the joins it takes part in, except where an
+// outer join keeps its rows regardless of the constraint.
+func PartitionScans(stmt query.Statement, catalog Catalog) []*Scan {
+	ctes := make(map[string]bool)
+	query.Inspect(stmt, func(n query.Node) bool {
+		if cte, ok := n.(*query.CTE); ok && cte.TableName != nil {
+			ctes[strings.ToLower(cte.TableName.Name)] = true
+		}
+		return true
+	})
+
+	ps := &partitionScanner{catalog: catalog, ctes: ctes}
+	query.Inspect(stmt, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			if n.Source != nil {
+				ps.scanSource(n.Source, conjuncts(n.WhereExpr))
+			}
+		case *query.DeleteStatement:
+			if n.Table != nil {
+				ps.scanSource(n.Table, conjuncts(n.WhereExpr))
+			}
+		}
+		return true
+	})
+
+	sort.SliceStable(ps.scans, func(i, j int) bool {
+		return ps.scans[i].Pos.Offset < ps.scans[j].Pos.Offset
+	})
+	return ps.scans
+}
+
+type partitionScanner struct {
+	catalog Catalog
+	ctes    map[string]bool
+	scans   []*Scan
+}
+
+// scanSource records the scans of src, which are constrained by preds.
+// Subqueries are scanned separately as they have their own WHERE clause.
+func (ps *partitionScanner) scanSource(src query.Source, preds []query.Expr) {
+	switch src := src.(type) {
+	case *query.QualifiedTableName:
+		if src.Name == nil || (src.Name.First == nil && ps.ctes[strings.ToLower(src.Name.Name.Name)]) {
+			return
+		}
+		ps.scans = append(ps.scans, ps.scanTable(src, preds))
+
+	case *query.ParenSource:
+		ps.scanSource(src.X, preds)
+
+	case *query.PivotSource:
+		// Columns that are not pivoted keep their values, so predicates
+		// on partition columns still constrain the scan.
+		ps.scanSource(src.X, preds)
+
+	case *query.UnpivotSource:
+		ps.scanSource(src.X, preds)
+
+	case *query.SampleSource:
+		ps.scanSource(src.X, preds)
+
+	case *query.LateralView:
+		ps.scanSource(src.X, preds)
+
+	case *query.JoinClause:
+		var on []query.Expr
+		if c, ok := src.Constraint.(*query.OnConstraint); ok {
+			on = conjuncts(c.X)
+		}
+
+		// The rows of the preserved side of an outer or anti join are kept
+		// whether or not they match the ON constraint.
+		left, right := preds, preds
+		on = on[:len(on):len(on)]
+		switch op := src.Operator; {
+		case op == nil:
+		case op.Full.IsValid():
+		case op.Anti.IsValid(), op.Left.IsValid() && !op.Semi.IsValid():
+			right = append(on, right...)
+		case op.Right.IsValid():
+			left = append(on, left...)
+		default:
+			left = append(on, left...)
+			right = append(on, right...)
+		}
+		ps.scanSource(src.X, left)
+		ps.scanSource(src.Y, right)
+	}
+}
+
+func (ps *partitionScanner) scanTable(src *query.QualifiedTableName, preds []query.Expr) *Scan {
+	scan := &Scan{
+		Pos:   query.NodePos(src),
+		Name:  tableName(src.Name),
+		Alias: query.IdentName(src.Alias),
+		Table: lookup(ps.catalog, src.Name),
+	}
+	if scan.Table == nil || len(scan.Table.PartitionColumns) == 0 {
+		return scan
+	}
+
+	for _, col := range scan.Table.PartitionColumns {
+		r := &PartitionRange{Column: col}
+		for _, pred := range preds {
+			if !r.apply(pred, func(expr query.Expr) bool { return scan.isColumn(expr, col) }) && scan.mentions(pred, col) {
+				scan.Unprunable = append(scan.Unprunable, pred)
+			}
+		}
+		if len(r.Values) != 0 || r.Low != nil || r.High != nil || r.Empty {
+			scan.Ranges = append(scan.Ranges, r)
+		}
+	}
+	scan.FullScan = len(scan.Ranges) == 0
+	return scan
+}
+
+// isColumn returns true if expr is a reference to the column col of the
+// scanned table, either unqualified or qualified by its alias or name.
+func (s *Scan) isColumn(expr query.Expr, col string) bool {
+	for {
+		paren, ok := expr.(*query.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+
+	ident, ok := expr.(*query.MultiPartIdent)
+	if !ok || ident.Name == nil || !strings.EqualFold(ident.Name.Name, col) || !isColumnRef(ident) {
+		return false
+	} else if ident.First == nil {
+		return true
+	}
+
+	qualifier := strings.TrimSuffix(tableName(ident), "."+ident.Name.Name)
+	if s.Alias != "" {
+		return strings.EqualFold(qualifier, s.Alias)
+	}
+	name := s.Name
+	if i := strings.LastIndex(name, "."); i >= 0 && !strings.Contains(qualifier, ".") {
+		name = name[i+1:]
+	}
+	return strings.EqualFold(qualifier, name)
+}
+
+// mentions returns true if expr references the column col of the scan.
+func (s *Scan) mentions(expr query.Expr, col string) (found bool) {
+	query.Inspect(expr, func(n query.Node) bool {
+		if ident, ok := n.(*query.MultiPartIdent); ok && s.isColumn(ident, col) {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// apply narrows r by pred and returns true if pred is a prunable predicate
+// on the column matched by isCol.
+func (r *PartitionRange) apply(pred query.Expr, isCol func(query.Expr) bool) bool {
+	expr, ok := pred.(*query.BinaryExpr)
+	if !ok {
+		return false
+	}
+
+	switch expr.Op {
+	case query.EQ, query.LT, query.LE, query.GT, query.GE:
+		op, value := expr.Op, expr.Y
+		if !isCol(expr.X) {
+			op, value = flip(op), expr.X
+			if !isCol(expr.Y) {
+				return false
+			}
+		}
+		if !isConstant(value) {
+			return false
+		}
+
+		switch op {
+		case query.EQ:
+			r.narrowValues([]query.Expr{value})
+		case query.GT, query.GE:
+			r.narrowLow(value, op == query.GE)
+		case query.LT, query.LE:
+			r.narrowHigh(value, op == query.LE)
+		}
+		return true
+
+	case query.IN:
+		list, ok := expr.Y.(*query.ExprList)
+		if !ok || !isCol(expr.X) {
+			return false
+		}
+		for _, value := range list.Exprs {
+			if !isConstant(value) {
+				return false
+			}
+		}
+		r.narrowValues(list.Exprs)
+		return true
+
+	case query.BETWEEN:
+		rng, ok := expr.Y.(*query.Range)
+		if !ok || !isCol(expr.X) || !isConstant(rng.X) || !isConstant(rng.Y) {
+			return false
+		}
+		r.narrowLow(rng.X, true)
+		r.narrowHigh(rng.Y, true)
+		return true
+
+	case query.OR:
+		values, ok := disjunctValues(expr, isCol)
+		if !ok {
+			return false
+		}
+		r.narrowValues(values)
+		return true
+
+	default:
+		return false
+	}
+}
+
+// disjunctValues returns the values selected by an OR of equalities and IN
+// lists on the column matched by isCol, as in pt = 'a' OR pt = 'b'.
+func disjunctValues(expr query.Expr, isCol func(query.Expr) bool) ([]query.Expr, bool) {
+	for {
+		paren, ok := expr.(*query.ParenExpr)
+		if !ok {
+			break
+		}
+		expr = paren.X
+	}
+
+	if bin, ok := expr.(*query.BinaryExpr); ok && bin.Op == query.OR {
+		x, ok := disjunctValues(bin.X, isCol)
+		if !ok {
+			return nil, false
+		}
+		y, ok := disjunctValues(bin.Y, isCol)
+		if !ok {
+			return nil, false
+		}
+		for _, v := range y {
+			if !containsValue(x, v) {
+				x = append(x, v)
+			}
+		}
+		return x, true
+	}
+
+	var r PartitionRange
+	if !r.apply(expr, isCol) || r.Low != nil || r.High != nil {
+		return nil, false
+	}
+	return r.Values, true
+}
+
+// narrowValues restricts r to the values also in values.
+func (r *PartitionRange) narrowValues(values []query.Expr) {
+	if r.Empty {
+		return
+	} else if len(r.Values) == 0 {
+		r.Values = values
+		r.prune()
+		return
+	}
+
+	var kept []query.Expr
+	for _, v := range r.Values {
+		if containsValue(values, v) {
+			kept = append(kept, v)
+		}
+	}
+	r.Values = kept
+	r.Empty = len(kept) == 0
+}
+
+// narrowLow raises the lower bound of r to value, unless it is already
+// higher.
+func (r *PartitionRange) narrowLow(value query.Expr, inclusive bool) {
+	if r.Low == nil {
+		r.Low, r.LowInclusive = value, inclusive
+	} else if c, ok := compareConstants(value, r.Low); ok && (c > 0 || (c == 0 && !inclusive)) {
+		r.Low, r.LowInclusive = value, inclusive
+	}
+	r.prune()
+}
+
+// narrowHigh lowers the upper bound of r to value, unless it is already
+// lower.
+func (r *PartitionRange) narrowHigh(value query.Expr, inclusive bool) {
+	if r.High == nil {
+		r.High, r.HighInclusive = value, inclusive
+	} else if c, ok := compareConstants(value, r.High); ok && (c < 0 || (c == 0 && !inclusive)) {
+		r.High, r.HighInclusive = value, inclusive
+	}
+	r.prune()
+}
+
+// prune drops the values of r outside its bounds and sets Empty if no
+// value is left or the bounds cross.
+func (r *PartitionRange) prune() {
+	if r.Empty {
+		return
+	}
+
+	if len(r.Values) != 0 {
+		var kept []query.Expr
+		for _, v := range r.Values {
+			if r.inBounds(v) {
+				kept = append(kept, v)
+			}
+		}
+		r.Values = kept
+		r.Empty = len(kept) == 0
+	}
+
+	if r.Low != nil && r.High != nil {
+		if c, ok := compareConstants(r.Low, r.High); ok && (c > 0 || (c == 0 && !(r.LowInclusive && r.HighInclusive))) {
+			r.Empty = true
+		}
+	}
+}
+
+// inBounds returns false if value is known to be outside the bounds of r.
+func (r *PartitionRange) inBounds(value query.Expr) bool {
+	if r.Low != nil {
+		if c, ok := compareConstants(value, r.Low); ok && (c < 0 || (c == 0 && !r.LowInclusive)) {
+			return false
+		}
+	}
+	if r.High != nil {
+		if c, ok := compareConstants(value, r.High); ok && (c > 0 || (c == 0 && !r.HighInclusive)) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsValue returns true if value may be one of values. Values that
+// cannot be compared may be equal.
+func containsValue(values []query.Expr, value query.Expr) bool {
+	for _, v := range values {
+		if c, ok := compareConstants(value, v); !ok || c == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// compareConstants returns -1, 0 or +1 as the value of a is less than,
+// equal to or greater than that of b. It returns false if either value is
+// only known when the query runs, as for templates and bind parameters.
+func compareConstants(a, b query.Expr) (int, bool) {
+	if isTemplate(a) || isTemplate(b) {
+		return 0, false
+	}
+
+	less, err := query.Eval(&query.BinaryExpr{X: a, Op: query.LT, Y: b}, nil)
+	if err != nil || less == nil {
+		return 0, false
+	}
+	greater, err := query.Eval(&query.BinaryExpr{X: a, Op: query.GT, Y: b}, nil)
+	if err != nil || greater == nil {
+		return 0, false
+	}
+
+	switch {
+	case less == true:
+		return -1, true
+	case greater == true:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+// isTemplate returns true if expr is a string holding a template, such as
+// '{{ .DSTART }}', that is replaced before the query runs.
+func isTemplate(expr query.Expr) bool {
+	lit, ok := expr.(*query.StringLit)
+	return ok && strings.Contains(lit.Value, "{{")
+}
+
+// flip returns the comparison with its operands swapped.
+func flip(op query.Token) query.Token {
+	switch op {
+	case query.LT:
+		return query.GT
+	case query.LE:
+		return query.GE
+	case query.GT:
+		return query.LT
+	case query.GE:
+		return query.LE
+	default:
+		return op
+	}
+}
+
+// conjuncts splits expr on AND.
+func conjuncts(expr query.Expr) []query.Expr {
+	switch e := expr.(type) {
+	case nil:
+		return nil
+	case *query.ParenExpr:
+		return conjuncts(e.X)
+	case *query.BinaryExpr:
+		if e.Op == query.AND {
+			return append(conjuncts(e.X), conjuncts(e.Y)...)
+		}
+	}
+	return []query.Expr{expr}
+}
+
+// isConstant returns true if expr does not depend on any column or subquery,
+// so its value is known before the tables are read. Templates and bind
+// parameters are constant.
+func isConstant(expr query.Expr) (ok bool) {
+	ok = true
+	query.Inspect(expr, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			ok = false
+		case *query.Call:
+			for _, arg := range n.Args {
+				ok = ok && isConstant(arg.X)
+			}
+			return false
+		case *query.MultiPartIdent:
+			ok = ok && !isColumnRef(n)
+			return false
+		}
+		return ok
+	})
+	return ok
+}
+
+// isColumnRef returns true if ident names a column rather than a keyword,
+// parameter or typed literal.
+func isColumnRef(ident *query.MultiPartIdent) bool {
+	return ident.Name != nil && (ident.Name.Tok == query.IDENT || ident.Name.Tok == query.QIDENT)
+}
@@ -0,0 +1,121 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query/analysis"
+)
+
+func TestPartitionScans(t *testing.T) {
+	catalog := analysis.NewMapCatalog(
+		&analysis.Table{Name: "db.events", PartitionColumns: []string{"pt"}},
+		&analysis.Table{Name: "db.users", PartitionColumns: []string{"dt", "region"}},
+		&analysis.Table{Name: "dim"},
+	)
+
+	t.Run("Where", func(t *testing.T) {
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt = '2024-01-01' AND a > 1`,
+			`1:15: db.events reads partitions pt = '2024-01-01'`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events e WHERE (e.pt >= '{{ .DSTART }}' AND '{{ .DEND }}' > e.pt)`,
+			`1:15: db.events reads partitions pt >= '{{ .DSTART }}' AND pt < '{{ .DEND }}'`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.users WHERE region IN ('id', 'sg') AND dt BETWEEN @a AND @b`,
+			`1:15: db.users reads partitions dt >= @a AND dt <= @b AND region IN ('id', 'sg')`)
+		AssertPartitionScans(t, catalog, `DELETE FROM db.events WHERE events.pt < DATE '2024-01-01'`,
+			`1:13: db.events reads partitions pt < DATE '2024-01-01'`)
+	})
+
+	t.Run("Narrow", func(t *testing.T) {
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt >= '2024-02-01' AND pt >= '2024-01-01' AND pt < '2024-03-01' AND pt <= '2024-03-01'`,
+			`1:15: db.events reads partitions pt >= '2024-02-01' AND pt < '2024-03-01'`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt IN ('a', 'b', 'c') AND pt IN ('c', 'b', 'd') AND pt > 'b'`,
+			`1:15: db.events reads partitions pt = 'c' AND pt > 'b'`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt BETWEEN 1 AND 10 AND pt BETWEEN 5 AND 20`,
+			`1:15: db.events reads partitions pt >= 5 AND pt <= 10`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt >= '{{ .DSTART }}' AND pt >= '2024-01-01' AND pt = @dt AND pt = '2024-01-02'`,
+			`1:15: db.events reads partitions pt = @dt AND pt >= '{{ .DSTART }}'`)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt = '2024-01-01' AND pt = '2024-01-02'`,
+			`1:15: db.events reads no partitions, filter on pt is never true`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt > '2024-02-01' AND pt <= DATE '2024-01-01'`,
+			`1:15: db.events reads no partitions, filter on pt is never true`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt IN ('a', 'b') AND pt > 'b'`,
+			`1:15: db.events reads no partitions, filter on pt is never true`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt < 'a' AND pt > 'a'`,
+			`1:15: db.events reads no partitions, filter on pt is never true`)
+
+		scans := analysis.PartitionScans(ParseStatement(t, `SELECT * FROM db.events WHERE pt = '1' AND pt = '2'`), catalog)
+		assert.False(t, scans[0].FullScan)
+		assert.True(t, scans[0].Ranges[0].Empty)
+		assert.Equal(t, "FALSE", scans[0].Ranges[0].String())
+	})
+
+	t.Run("Or", func(t *testing.T) {
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt = 'a' OR pt = 'b'`,
+			`1:15: db.events reads partitions pt IN ('a', 'b')`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events e WHERE (e.pt = 'a' OR (pt IN ('b', 'c') OR 'a' = pt)) AND pt != 'x'`,
+			`1:15: db.events reads partitions pt IN ('a', 'b', 'c')`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE (pt = 'a' OR pt = 'b') AND pt IN ('b', 'c')`,
+			`1:15: db.events reads partitions pt = 'b'`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt = 'a' OR pt > 'b'`,
+			`1:15: full scan of db.events, no prunable filter on pt`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt = 'a' OR a = 'b'`,
+			`1:15: full scan of db.events, no prunable filter on pt`)
+	})
+
+	t.Run("FullScan", func(t *testing.T) {
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events, dim, unknown WHERE pt != '1' OR pt = '2'`,
+			`1:15: full scan of db.events, no prunable filter on pt`,
+			`1:26: dim is not partitioned`,
+			`1:31: unknown is not partitioned`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE substr(pt, 1, 6) = '202401' AND pt = other_col`,
+			`1:15: full scan of db.events, no prunable filter on pt`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events WHERE pt = (SELECT max(pt) FROM db.events WHERE pt > '1')`,
+			`1:15: full scan of db.events, no prunable filter on pt`,
+			`1:57: db.events reads partitions pt > '1'`)
+
+		scans := analysis.PartitionScans(ParseStatement(t, `SELECT * FROM db.events WHERE pt != '1'`), catalog)
+		assert.True(t, scans[0].FullScan)
+		assert.Equal(t, "pt != '1'", scans[0].Unprunable[0].String())
+	})
+
+	t.Run("Join", func(t *testing.T) {
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events e JOIN db.users u ON e.id = u.id AND u.dt = e.pt WHERE e.pt = '1'`,
+			`1:15: db.events reads partitions pt = '1'`,
+			`1:32: full scan of db.users, no prunable filter on dt, region`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events e LEFT JOIN db.users u ON e.id = u.id AND u.dt = '1' AND e.pt = '1'`,
+			`1:15: full scan of db.events, no prunable filter on pt`,
+			`1:37: db.users reads partitions dt = '1'`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events e FULL JOIN db.users u ON u.dt = '1' AND e.pt = '1'`,
+			`1:15: full scan of db.events, no prunable filter on pt`,
+			`1:37: full scan of db.users, no prunable filter on dt, region`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events e RIGHT JOIN db.users u ON e.id = u.id AND u.dt = '1' AND e.pt = '1'`,
+			`1:15: db.events reads partitions pt = '1'`,
+			`1:38: full scan of db.users, no prunable filter on dt, region`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events e LEFT SEMI JOIN db.users u ON e.id = u.id AND u.dt = '1' AND e.pt = '1'`,
+			`1:15: db.events reads partitions pt = '1'`,
+			`1:42: db.users reads partitions dt = '1'`)
+		AssertPartitionScans(t, catalog, `SELECT * FROM db.events e LEFT ANTI JOIN db.users u ON e.id = u.id AND u.dt = '1' AND e.pt = '1'`,
+			`1:15: full scan of db.events, no prunable filter on pt`,
+			`1:42: db.users reads partitions dt = '1'`)
+	})
+
+	t.Run("CTE", func(t *testing.T) {
+		AssertPartitionScans(t, catalog, `WITH events AS (SELECT * FROM db.events WHERE pt = '1') SELECT * FROM events`,
+			`1:31: db.events reads partitions pt = '1'`)
+	})
+}
+
+// AssertPartitionScans asserts that the scans of s are summarised as want.
+func AssertPartitionScans(tb testing.TB, catalog analysis.Catalog, s string, want ...string) {
+	tb.Helper()
+
+	var got []string
+	for _, scan := range analysis.PartitionScans(ParseStatement(tb, s), catalog) {
+		got = append(got, scan.String())
+	}
+	assert.Equal(tb, want, got)
+}
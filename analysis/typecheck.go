@@ -0,0 +1,1147 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// TypeIssue is a type mismatch found in a statement.
+type TypeIssue struct {
+	Pos     query.Pos `json:"pos"`
+	Message string    `json:"message"`
+}
+
+// String returns the issue as "line:column: message".
+func (i TypeIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Pos, i.Message)
+}
+
+// TypeInfo holds the types inferred for a statement by CheckTypes.
+type TypeInfo struct {
+	// Types holds the type of every expression in the statement.
+	Types map[query.Expr]Type
+
+	// Schemas holds the result columns of every query whose columns are
+	// known, including the queries it is compounded with. The columns of a
+	// query are unknown if it selects a star from a table missing from the
+	// catalog.
+	Schemas map[*query.SelectStatement][]*Column
+
+	// Columns holds the result columns of the query of a SELECT, INSERT or
+	// CREATE TABLE AS statement, or nil if they are unknown.
+	Columns []*Column
+
+	// Issues holds the type mismatches found, ordered by position.
+	Issues []TypeIssue
+}
+
+// TypeOf returns the type of expr, or Unknown if expr was not checked.
+func (info *TypeInfo) TypeOf(expr query.Expr) Type {
+	return info.Types[expr]
+}
+
+// Schema returns the result columns of sel, or nil if they are unknown.
+func (info *TypeInfo) Schema(sel *query.SelectStatement) []*Column {
+	return info.Schemas[sel]
+}
+
+// CheckTypes infers the type of every expression in stmt and reports type
+// mismatches in comparisons, CASE branches, the operands of UNION and
+// INTERSECT, and the columns of INSERT. Columns of tables are typed by
+// catalog and calls by funcs; if funcs is nil, DefaultFunctions is used.
+//
+// Expressions whose type cannot be inferred, such as columns of unknown
+// tables or calls to unknown functions, are Unknown and never mismatch.
+// Strings compare with dates and timestamps, and numeric types with each
+// other.
+func CheckTypes(stmt query.Statement, catalog Catalog, funcs Functions) *TypeInfo {
+	if funcs == nil {
+		funcs = DefaultFunctions()
+	}
+	tc := &typeChecker{
+		catalog: catalog,
+		funcs:   funcs,
+		info: &TypeInfo{
+			Types:   make(map[query.Expr]Type),
+			Schemas: make(map[*query.SelectStatement][]*Column),
+		},
+		checked: make(map[*query.SelectStatement]bool),
+	}
+	tc.statement(stmt)
+
+	sort.SliceStable(tc.info.Issues, func(i, j int) bool {
+		return tc.info.Issues[i].Pos.Offset < tc.info.Issues[j].Pos.Offset
+	})
+	return tc.info
+}
+
+type typeChecker struct {
+	catalog Catalog
+	funcs   Functions
+	info    *TypeInfo
+	checked map[*query.SelectStatement]bool
+}
+
+func (tc *typeChecker) report(pos query.Pos, format string, args ...interface{}) {
+	tc.info.Issues = append(tc.info.Issues, TypeIssue{Pos: pos, Message: fmt.Sprintf(format, args...)})
+}
+
+func (tc *typeChecker) statement(stmt query.Statement) {
+	switch stmt := stmt.(type) {
+	case *query.SelectStatement:
+		tc.info.Columns, _ = tc.query(stmt, nil, nil)
+
+	case *query.CreateTableStatement:
+		if stmt.Select != nil {
+			tc.info.Columns, _ = tc.query(stmt.Select, nil, nil)
+		}
+
+	case *query.InsertStatement:
+		env := tc.with(stmt.WithClause, nil)
+		var cols []*Column
+		known := true
+		if stmt.Select != nil {
+			cols, known = tc.query(stmt.Select, env, nil)
+			tc.info.Columns = cols
+		}
+		for i, list := range stmt.ValueLists {
+			for _, expr := range list.Exprs {
+				t := tc.expr(expr, nil, env)
+				if i == 0 {
+					cols = append(cols, &Column{Type: t.String()})
+				}
+			}
+		}
+		if known && (stmt.Select != nil || len(stmt.ValueLists) != 0) {
+			tc.checkInsert(stmt, cols)
+		}
+
+	case *query.DeleteStatement:
+		env := tc.with(stmt.WithClause, nil)
+		scope := &typeScope{}
+		if stmt.Table != nil {
+			tc.addSource(scope, stmt.Table, env)
+		}
+		if stmt.WhereExpr != nil {
+			tc.expr(stmt.WhereExpr, scope, env)
+		}
+
+	default:
+		query.Inspect(stmt, func(n query.Node) bool {
+			if sel, ok := n.(*query.SelectStatement); ok {
+				tc.query(sel, nil, nil)
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// checkInsert reports columns of the query of an INSERT that do not match
+// the columns of the table.
+func (tc *typeChecker) checkInsert(stmt *query.InsertStatement, cols []*Column) {
+	table := lookup(tc.catalog, stmt.Table)
+	if table == nil {
+		return
+	}
+
+	targets := table.Columns
+	if len(stmt.Columns) != 0 {
+		targets = make([]*Column, len(stmt.Columns))
+		for i, ident := range stmt.Columns {
+			if targets[i] = table.Column(ident.Name); targets[i] == nil {
+				tc.report(ident.NamePos, "table %s has no column %s", table.Name, ident.Name)
+			}
+		}
+	}
+
+	// Partition columns are not inserted positionally: static ones are given
+	// by the PARTITION clause and dynamic ones follow the other columns.
+	if spec := stmt.Partition; spec != nil {
+		if len(stmt.Columns) == 0 {
+			targets = nil
+			for _, col := range table.Columns {
+				if !partitioned(spec, col.Name) {
+					targets = append(targets, col)
+				}
+			}
+		}
+		for _, col := range spec.Columns {
+			if col.IsDynamic() {
+				targets = append(targets, table.Column(col.Name.Name))
+			}
+		}
+	}
+
+	if len(targets) != len(cols) {
+		tc.report(stmt.Insert, "INSERT into %s expects %d columns but its query returns %d", table.Name, len(targets), len(cols))
+		return
+	}
+	for i, target := range targets {
+		if target == nil {
+			continue
+		}
+		to, from := ParseType(target.Type), ParseType(cols[i].Type)
+		if !Comparable(to, from) {
+			tc.report(tc.insertPos(stmt, i), "cannot insert %s into column %s of type %s", from, target.Name, to)
+		}
+	}
+}
+
+// partitioned returns true if name is a column of spec.
+func partitioned(spec *query.PartitionSpec, name string) bool {
+	for _, col := range spec.Columns {
+		if strings.EqualFold(col.Name.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// insertPos returns the position of the i-th value inserted by stmt.
+func (tc *typeChecker) insertPos(stmt *query.InsertStatement, i int) query.Pos {
+	if len(stmt.ValueLists) != 0 && i < len(stmt.ValueLists[0].Exprs) {
+		return query.NodePos(stmt.ValueLists[0].Exprs[i])
+	}
+	if stmt.Select == nil {
+		return stmt.Insert
+	}
+	if sel := stmt.Select.Operands()[0]; len(sel.ValueLists) != 0 && i < len(sel.ValueLists[0].Exprs) {
+		return query.NodePos(sel.ValueLists[0].Exprs[i])
+	} else if n, ok := resultColumnCount(sel); ok && i < n {
+		return query.NodePos(sel.Columns[i])
+	}
+	return stmt.Insert
+}
+
+// typeEnv holds the CTEs visible to a query.
+type typeEnv struct {
+	parent *typeEnv
+	ctes   map[string]*cteSchema
+}
+
+// cteSchema holds the result columns of a CTE. Columns is nil if unknown.
+type cteSchema struct {
+	columns []*Column
+}
+
+func (env *typeEnv) lookup(ident *query.MultiPartIdent) *cteSchema {
+	if ident == nil || ident.First != nil || ident.Name == nil {
+		return nil
+	}
+	for ; env != nil; env = env.parent {
+		if cte, ok := env.ctes[strings.ToLower(ident.Name.Name)]; ok {
+			return cte
+		}
+	}
+	return nil
+}
+
+// with checks the queries of the CTEs of with and returns the environment
+// of the query they belong to.
+func (tc *typeChecker) with(with *query.WithClause, parent *typeEnv) *typeEnv {
+	if with == nil {
+		return parent
+	}
+
+	env := &typeEnv{parent: parent, ctes: make(map[string]*cteSchema)}
+	schemas := make([]*cteSchema, len(with.CTEs))
+	for i, cte := range with.CTEs {
+		schemas[i] = &cteSchema{}
+		if len(cte.Columns) != 0 {
+			for _, col := range cte.Columns {
+				schemas[i].columns = append(schemas[i].columns, &Column{Name: col.Name})
+			}
+		}
+		if with.Recursive.IsValid() && cte.TableName != nil {
+			env.ctes[strings.ToLower(cte.TableName.Name)] = schemas[i]
+		}
+	}
+
+	for i, cte := range with.CTEs {
+		if cte.Select != nil {
+			cols, known := tc.query(cte.Select, env, nil)
+			switch {
+			case len(cte.Columns) == 0:
+				if known {
+					schemas[i].columns = cols
+				}
+			case known && len(cols) == len(cte.Columns):
+				for j, col := range cols {
+					schemas[i].columns[j].Type = col.Type
+				}
+			}
+		}
+		if cte.TableName != nil {
+			env.ctes[strings.ToLower(cte.TableName.Name)] = schemas[i]
+		}
+	}
+	return env
+}
+
+// query checks sel and the queries it combines, and returns its result
+// columns. Returns false if the columns are unknown. Outer is the scope of
+// the enclosing query, for correlated subqueries.
+func (tc *typeChecker) query(sel *query.SelectStatement, env *typeEnv, outer *typeScope) ([]*Column, bool) {
+	if tc.checked[sel] {
+		cols, ok := tc.info.Schemas[sel]
+		return cols, ok
+	}
+	tc.checked[sel] = true
+
+	var cols []*Column
+	var known bool
+	env = tc.with(sel.WithClause, env)
+	if sel.Compound != nil {
+		cols, known = tc.setOperation(sel.Compound, env, outer)
+	} else {
+		cols, known = tc.selectColumns(sel, env, outer)
+	}
+
+	if known {
+		tc.info.Schemas[sel] = cols
+	}
+	return cols, known
+}
+
+// setOperation checks the operands of op and returns its result columns,
+// which are named by the first operand.
+func (tc *typeChecker) setOperation(op *query.SetOperation, env *typeEnv, outer *typeScope) ([]*Column, bool) {
+	pos, name := op.Op()
+	cols, known := tc.query(op.X, env, outer)
+	other, otherKnown := tc.query(op.Y, env, outer)
+	switch {
+	case !known || !otherKnown:
+		return cols, false
+	case len(cols) != len(other):
+		tc.report(pos, "%s operands have %d and %d columns", name, len(cols), len(other))
+		return cols, false
+	}
+
+	merged := make([]*Column, len(cols))
+	for i, col := range cols {
+		x, y := ParseType(col.Type), ParseType(other[i].Type)
+		t, ok := CommonType(x, y)
+		if !ok {
+			tc.report(pos, "column %d of %s has incompatible types %s and %s", i+1, name, x, y)
+		}
+		merged[i] = &Column{Name: col.Name, Type: t.String()}
+	}
+	return merged, true
+}
+
+// typeScope holds the sources of a query.
+type typeScope struct {
+	parent  *typeScope
+	sources []*typedSource
+
+	// star holds the columns selected by *; starKnown is false if some of
+	// them are unknown.
+	star      []*Column
+	starKnown bool
+
+	// aliases holds the types of the named result columns, which ORDER BY
+	// and other clauses may reference.
+	aliases map[string]Type
+
+	// grouping holds the expressions grouped by the query, which are the
+	// only valid arguments of GROUPING and GROUPING_ID.
+	grouping []query.Expr
+}
+
+// typedSource is a table, CTE, subquery or lateral view read by a query.
+type typedSource struct {
+	qualifier string // alias, or the last part of name
+	name      string // dotted table name; blank if aliased
+	columns   []*Column
+	known     bool
+}
+
+// source returns the source qualified by ident, or nil.
+func (s *typeScope) source(ident *query.MultiPartIdent) *typedSource {
+	name := tableName(ident)
+	for _, src := range s.sources {
+		if (ident.First == nil && strings.EqualFold(src.qualifier, name)) || (src.name != "" && strings.EqualFold(src.name, name)) {
+			return src
+		}
+	}
+	return nil
+}
+
+// selectColumns checks sel, excluding the queries it is compounded with,
+// and returns its result columns.
+func (tc *typeChecker) selectColumns(sel *query.SelectStatement, env *typeEnv, outer *typeScope) ([]*Column, bool) {
+	scope := &typeScope{parent: outer, starKnown: true, grouping: sel.GroupingExprs()}
+	if sel.Source != nil {
+		scope.star, scope.starKnown = tc.addSource(scope, sel.Source, env)
+	}
+
+	var cols []*Column
+	known := true
+	for i, list := range sel.ValueLists {
+		for _, expr := range list.Exprs {
+			t := tc.expr(expr, scope, env)
+			if i == 0 {
+				cols = append(cols, &Column{Type: t.String()})
+			}
+		}
+	}
+
+	scope.aliases = make(map[string]Type)
+	for _, col := range sel.Columns {
+		ref, _ := col.Expr.(*query.QualifiedRef)
+		switch {
+		case col.Star.IsValid():
+			known = known && scope.starKnown
+			cols = append(cols, except(scope.star, col.ExceptCol)...)
+		case ref != nil && ref.Star.IsValid():
+			src := scope.source(ref.Name)
+			if src == nil || !src.known {
+				known = false
+				continue
+			}
+			cols = append(cols, except(src.columns, col.ExceptCol)...)
+		case col.Expr != nil:
+			t := tc.expr(col.Expr, scope, env)
+			name := resultColumnName(col)
+			if name != "" {
+				scope.aliases[strings.ToLower(name)] = t
+			}
+			cols = append(cols, &Column{Name: name, Type: t.String()})
+		}
+	}
+
+	// Check the expressions of the other clauses.
+	query.Inspect(sel, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			return n == sel
+		case *query.WithClause, *query.ResultColumn, *query.QualifiedTableName, *query.QualifiedTableFunctionName,
+			*query.PivotSource, *query.UnpivotSource, *query.UnnestSource, *query.LateralView:
+			return false
+		case *query.ParenSource:
+			_, ok := n.X.(*query.SelectStatement)
+			return !ok
+		case *query.Window:
+			tc.window(n.Definition, scope, env)
+			return false
+		case query.Expr:
+			tc.expr(n, scope, env)
+			return false
+		}
+		return true
+	})
+	return cols, known
+}
+
+// resultColumnName returns the name of col, or a blank string if the
+// engine would pick it.
+func resultColumnName(col *query.ResultColumn) string {
+	if col.Alias != nil {
+		return col.Alias.Name
+	}
+	switch expr := col.Expr.(type) {
+	case *query.Ident:
+		return expr.Name
+	case *query.MultiPartIdent:
+		if isColumnRef(expr) {
+			return expr.Name.Name
+		}
+	}
+	return ""
+}
+
+// except returns cols without the columns named by the EXCEPT list expr.
+func except(cols []*Column, expr query.Expr) []*Column {
+	if expr == nil {
+		return cols
+	}
+	names := make(map[string]bool)
+	query.Inspect(expr, func(n query.Node) bool {
+		if ident, ok := n.(*query.MultiPartIdent); ok && ident.Name != nil {
+			names[strings.ToLower(ident.Name.Name)] = true
+			return false
+		}
+		return true
+	})
+
+	var out []*Column
+	for _, col := range cols {
+		if !names[strings.ToLower(col.Name)] {
+			out = append(out, col)
+		}
+	}
+	return out
+}
+
+// addSource adds the sources read by src to scope and returns the columns
+// selected by * from src. Returns false if some of them are unknown.
+func (tc *typeChecker) addSource(scope *typeScope, src query.Source, env *typeEnv) ([]*Column, bool) {
+	switch src := src.(type) {
+	case *query.QualifiedTableName:
+		ts := &typedSource{qualifier: query.IdentName(src.Alias)}
+		if src.Alias == nil && src.Name != nil && src.Name.Name != nil {
+			ts.qualifier, ts.name = src.Name.Name.Name, tableName(src.Name)
+		}
+		if cte := env.lookup(src.Name); cte != nil {
+			ts.columns, ts.known = cte.columns, cte.columns != nil
+		} else if table := lookup(tc.catalog, src.Name); table != nil {
+			ts.columns, ts.known = table.Columns, true
+		}
+		scope.sources = append(scope.sources, ts)
+
+		return ts.columns, ts.known
+
+	case *query.QualifiedTableFunctionName:
+		for _, arg := range src.Args {
+			tc.expr(arg, scope, env)
+		}
+		scope.sources = append(scope.sources, &typedSource{qualifier: query.IdentName(src.Alias)})
+		return nil, false
+
+	case *query.ParenSource:
+		sel, ok := src.X.(*query.SelectStatement)
+		if !ok {
+			return tc.addSource(scope, src.X, env)
+		}
+		cols, known := tc.query(sel, env, scope.parent)
+		scope.sources = append(scope.sources, &typedSource{qualifier: query.IdentName(src.Alias), columns: cols, known: known})
+		return cols, known
+
+	case *query.PivotSource:
+		// The aggregates and pivot column read the columns of X, which are
+		// replaced by the columns they generate.
+		inner := &typeScope{parent: scope.parent, starKnown: true}
+		star, known := tc.addSource(inner, src.X, env)
+		tc.expr(src.Column, inner, env)
+		types := make([]Type, len(src.Aggregates))
+		for i, agg := range src.Aggregates {
+			types[i] = tc.expr(agg.X, inner, env)
+		}
+
+		used := []query.Expr{src.Column}
+		for _, agg := range src.Aggregates {
+			used = append(used, agg.X)
+		}
+		cols := unreferenced(star, used)
+		for _, value := range src.Values {
+			tc.expr(value.X, inner, env)
+			for i, agg := range src.Aggregates {
+				cols = append(cols, &Column{Name: src.ColumnName(value, agg), Type: types[i].String()})
+			}
+		}
+		scope.sources = append(scope.sources, pivotedSource(inner, src.Alias, cols, known))
+		return cols, known
+
+	case *query.UnpivotSource:
+		// The listed columns of X are replaced by the name and value
+		// columns, which take the types of the first listed columns.
+		inner := &typeScope{parent: scope.parent, starKnown: true}
+		star, known := tc.addSource(inner, src.X, env)
+		var used []query.Expr
+		var types []Type
+		for i, col := range src.Columns {
+			for _, expr := range listExprs(col.X) {
+				if t := tc.expr(expr, inner, env); i == 0 {
+					types = append(types, t)
+				}
+			}
+			used = append(used, col.X)
+		}
+
+		cols := unreferenced(star, used)
+		cols = append(cols, &Column{Name: query.IdentName(src.Name), Type: String.String()})
+		for i, expr := range listExprs(src.Value) {
+			col := &Column{Name: exprName(expr)}
+			if i < len(types) {
+				col.Type = types[i].String()
+			}
+			cols = append(cols, col)
+		}
+		scope.sources = append(scope.sources, pivotedSource(inner, src.Alias, cols, known))
+		return cols, known
+
+	case *query.UnnestSource:
+		// The array may reference the sources joined before it.
+		t := tc.expr(src.X, scope, env)
+		ts := &typedSource{qualifier: query.IdentName(src.Alias), known: true}
+		ts.columns = append(ts.columns, &Column{Name: query.IdentName(src.Alias), Type: elemType(t).String()})
+		if src.With.IsValid() {
+			name := "offset"
+			if src.OffsetAlias != nil {
+				name = src.OffsetAlias.Name
+			}
+			ts.columns = append(ts.columns, &Column{Name: name, Type: Integer.String()})
+		}
+		scope.sources = append(scope.sources, ts)
+		return ts.columns, true
+
+	case *query.LateralView:
+		star, known := tc.addSource(scope, src.X, env)
+		var types []Type
+		if src.Udtf != nil {
+			tc.expr(src.Udtf, scope, env)
+			types = generatedTypes(src.Udtf, tc.info.Types)
+		}
+		ts := &typedSource{qualifier: query.IdentName(src.TableAlias), known: true}
+		for i, col := range src.ColAlias {
+			t := Unknown
+			if i < len(types) {
+				t = types[i]
+			}
+			ts.columns = append(ts.columns, &Column{Name: col.Name, Type: t.String()})
+		}
+		scope.sources = append(scope.sources, ts)
+		return append(star[:len(star):len(star)], ts.columns...), known
+
+	case *query.SampleSource:
+		n := len(scope.sources)
+		star, known := tc.addSource(scope, src.X, env)
+		if src.Alias != nil && len(scope.sources) == n+1 {
+			scope.sources[n].qualifier, scope.sources[n].name = src.Alias.Name, ""
+		}
+		return star, known
+
+	case *query.JoinClause:
+		// The operator and constraint of a join apply to the first source
+		// of Y and the sources before it.
+		star, known := tc.addSource(scope, src.X, env)
+		y := src.Y
+		for join := src; join != nil; {
+			var next *query.JoinClause
+			if j, ok := y.(*query.JoinClause); ok {
+				next, y = j, j.X
+			}
+			right, rightKnown := tc.addSource(scope, y, env)
+			if join.Operator == nil || !join.Operator.IsFilter() {
+				star, known = joinColumns(star, right, join), known && rightKnown
+			}
+			if join = next; join != nil {
+				y = join.Y
+			}
+		}
+		return star, known
+	}
+	return nil, false
+}
+
+// pivotedSource returns the source generated by a PIVOT or UNPIVOT of the
+// sources in inner. Without an alias, it is qualified like its source.
+func pivotedSource(inner *typeScope, alias *query.Ident, cols []*Column, known bool) *typedSource {
+	ts := &typedSource{qualifier: query.IdentName(alias), columns: cols, known: known}
+	if alias == nil && len(inner.sources) > 0 {
+		ts.qualifier, ts.name = inner.sources[0].qualifier, inner.sources[0].name
+	}
+	return ts
+}
+
+// unreferenced returns cols without the columns referenced by exprs.
+func unreferenced(cols []*Column, exprs []query.Expr) []*Column {
+	names := make(map[string]bool)
+	funcs := make(map[*query.MultiPartIdent]bool)
+	for _, expr := range exprs {
+		query.Inspect(expr, func(n query.Node) bool {
+			switch n := n.(type) {
+			case *query.Call:
+				funcs[n.Name] = true
+			case *query.MultiPartIdent:
+				if !funcs[n] && isColumnRef(n) {
+					names[strings.ToLower(n.Name.Name)] = true
+				}
+				return false
+			}
+			return true
+		})
+	}
+
+	var out []*Column
+	for _, col := range cols {
+		if !names[strings.ToLower(col.Name)] {
+			out = append(out, col)
+		}
+	}
+	return out
+}
+
+// listExprs returns the expressions of a list, or expr itself.
+func listExprs(expr query.Expr) []query.Expr {
+	switch expr := expr.(type) {
+	case *query.ExprList:
+		return expr.Exprs
+	case *query.ParenExpr:
+		return []query.Expr{expr.X}
+	}
+	return []query.Expr{expr}
+}
+
+// exprName returns the name of the column referenced by expr, or its text.
+func exprName(expr query.Expr) string {
+	if ident, ok := expr.(*query.MultiPartIdent); ok && ident.Name != nil {
+		return ident.Name.Name
+	}
+	return expr.String()
+}
+
+// joinColumns returns the columns selected by * from left joined to right.
+// The columns of USING or a NATURAL join are merged and listed first.
+func joinColumns(left, right []*Column, join *query.JoinClause) []*Column {
+	var using []string
+	if c, ok := join.Constraint.(*query.UsingConstraint); ok {
+		for _, col := range c.Columns {
+			using = append(using, col.Name)
+		}
+	} else if join.Operator != nil && join.Operator.Natural.IsValid() {
+		for _, col := range left {
+			if findColumn(right, col.Name) != nil {
+				using = append(using, col.Name)
+			}
+		}
+	}
+
+	var cols []*Column
+	for _, name := range using {
+		if col := findColumn(left, name); col != nil {
+			cols = append(cols, col)
+		}
+	}
+	for _, side := range [][]*Column{left, right} {
+		for _, col := range side {
+			if findColumn(cols, col.Name) == nil || !containsFold(using, col.Name) {
+				cols = append(cols, col)
+			}
+		}
+	}
+	return cols
+}
+
+func findColumn(cols []*Column, name string) *Column {
+	for _, col := range cols {
+		if strings.EqualFold(col.Name, name) {
+			return col
+		}
+	}
+	return nil
+}
+
+func containsFold(names []string, name string) bool {
+	for _, s := range names {
+		if strings.EqualFold(s, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the type of the column referenced by ident.
+func (s *typeScope) resolve(ident *query.MultiPartIdent) Type {
+	parts := identNames(ident)
+	name := parts[len(parts)-1]
+	for ; s != nil; s = s.parent {
+		if len(parts) > 1 {
+			qualifier := &query.MultiPartIdent{Name: &query.Ident{Name: strings.Join(parts[:len(parts)-1], ".")}}
+			if src := s.source(qualifier); src != nil {
+				if col := findColumn(src.columns, name); col != nil {
+					return ParseType(col.Type)
+				}
+				return Unknown
+			}
+			continue
+		}
+
+		for _, src := range s.sources {
+			if col := findColumn(src.columns, name); col != nil {
+				return ParseType(col.Type)
+			}
+		}
+		if t, ok := s.aliases[strings.ToLower(name)]; ok {
+			return t
+		}
+	}
+	return Unknown
+}
+
+// isGroupingFunc returns true if c calls GROUPING or GROUPING_ID, which
+// tell which expressions of ROLLUP, CUBE or GROUPING SETS a row groups by.
+func isGroupingFunc(c *query.Call) bool {
+	name := tableName(c.Name)
+	return strings.EqualFold(name, "GROUPING") || strings.EqualFold(name, "GROUPING_ID")
+}
+
+// grouped returns true if expr is one of the grouping expressions. A
+// column matches the same column with or without a qualifier.
+func grouped(expr query.Expr, grouping []query.Expr) bool {
+	x, _ := expr.(*query.MultiPartIdent)
+	for _, g := range grouping {
+		if query.Equal(expr, g, query.IgnorePositions(), query.IgnoreIdentCase()) {
+			return true
+		}
+		if y, ok := g.(*query.MultiPartIdent); ok && x != nil && isColumnRef(x) && isColumnRef(y) &&
+			(x.First == nil || y.First == nil) && strings.EqualFold(x.Name.Name, y.Name.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+func identNames(ident *query.MultiPartIdent) []string {
+	var names []string
+	for _, part := range []*query.Ident{ident.First, ident.Second, ident.Third, ident.Name} {
+		if part != nil {
+			names = append(names, part.Name)
+		}
+	}
+	return names
+}
+
+func (tc *typeChecker) window(def *query.WindowDefinition, scope *typeScope, env *typeEnv) {
+	if def == nil {
+		return
+	}
+	for _, expr := range def.Partitions {
+		tc.expr(expr, scope, env)
+	}
+	for _, term := range def.OrderingTerms {
+		tc.expr(term.X, scope, env)
+	}
+	if frame := def.Frame; frame != nil {
+		for _, bound := range []*query.FrameBound{frame.Start, frame.End} {
+			if bound != nil {
+				tc.expr(bound.X, scope, env)
+			}
+		}
+	}
+}
+
+// expr returns the type of expr and records the types of it and its
+// subexpressions.
+func (tc *typeChecker) expr(expr query.Expr, scope *typeScope, env *typeEnv) Type {
+	if expr == nil {
+		return Unknown
+	}
+	t := tc.inferExpr(expr, scope, env)
+	tc.info.Types[expr] = t
+	return t
+}
+
+func (tc *typeChecker) inferExpr(expr query.Expr, scope *typeScope, env *typeEnv) Type {
+	switch expr := expr.(type) {
+	case *query.NullLit:
+		return Null
+	case *query.BoolLit:
+		return Boolean
+	case *query.NumberLit:
+		if strings.ContainsAny(expr.Value, ".eE") && !strings.HasPrefix(strings.ToLower(expr.Value), "0x") {
+			return Float
+		}
+		return Integer
+	case *query.StringLit, *query.RawLit:
+		return String
+	case *query.TimestampLit:
+		return Timestamp
+	case *query.IntervalLit:
+		return Interval
+	case *query.ParenExpr:
+		return tc.expr(expr.X, scope, env)
+	case *query.Ident:
+		if t, ok := literalType(expr); ok {
+			return t
+		} else if expr.Tok != query.IDENT && expr.Tok != query.QIDENT {
+			return Unknown
+		}
+		return scope.resolve(&query.MultiPartIdent{Name: expr})
+	case *query.MultiPartIdent:
+		if expr.First == nil && expr.Name != nil {
+			if t, ok := literalType(expr.Name); ok {
+				return t
+			}
+		}
+		if !isColumnRef(expr) {
+			return Unknown
+		}
+		return scope.resolve(expr)
+	case *query.UnaryExpr:
+		x := tc.expr(expr.X, scope, env)
+		switch expr.Op {
+		case query.NOT:
+			return Boolean
+		case query.BITNOT:
+			return Integer
+		default:
+			if x.Kind.IsNumeric() || x.Kind == IntervalKind {
+				return x
+			}
+			return Unknown
+		}
+	case *query.BinaryExpr:
+		return tc.binaryExpr(expr, scope, env)
+	case *query.Null:
+		tc.expr(expr.X, scope, env)
+		return Boolean
+	case *query.Exists:
+		tc.query(expr.Select, env, scope)
+		return Boolean
+	case query.SelectExpr:
+		if cols, _ := tc.query(expr.SelectStatement, env, scope); len(cols) == 1 {
+			return ParseType(cols[0].Type)
+		}
+		return Unknown
+	case *query.ExprList:
+		for _, x := range expr.Exprs {
+			tc.expr(x, scope, env)
+		}
+		return Unknown
+	case *query.Rollup:
+		for _, x := range expr.Exprs {
+			tc.expr(x, scope, env)
+		}
+		return Unknown
+	case *query.Cube:
+		for _, x := range expr.Exprs {
+			tc.expr(x, scope, env)
+		}
+		return Unknown
+	case *query.GroupingSets:
+		for _, x := range expr.Exprs {
+			tc.expr(x, scope, env)
+		}
+		return Unknown
+	case *query.Range:
+		x, y := tc.expr(expr.X, scope, env), tc.expr(expr.Y, scope, env)
+		t, _ := CommonType(x, y)
+		return t
+	case *query.CastExpr:
+		tc.expr(expr.X, scope, env)
+		return typeOf(expr.Type)
+	case *query.CaseExpr:
+		return tc.caseExpr(expr, scope, env)
+	case *query.Call:
+		return tc.call(expr, scope, env)
+	case *query.IndexExpr:
+		x := tc.expr(expr.X, scope, env)
+		if expr.Call != nil {
+			tc.expr(expr.Call, scope, env)
+		}
+		return elemType(x)
+	default:
+		return Unknown
+	}
+}
+
+// elemType returns the type of the elements of the array type t, or
+// Unknown if t is not an array.
+func elemType(t Type) Type {
+	if name := t.String(); t.Kind == OtherKind && strings.HasPrefix(strings.ToUpper(name), "ARRAY<") && strings.HasSuffix(name, ">") {
+		return ParseType(name[len("ARRAY<") : len(name)-1])
+	}
+	return Unknown
+}
+
+// generatedTypes returns the types of the columns generated by the table
+// function of a lateral view, or nil if they are unknown.
+func generatedTypes(udtf *query.Call, types map[query.Expr]Type) []Type {
+	if udtf.Name == nil || len(udtf.Args) != 1 {
+		return nil
+	}
+	arg := types[udtf.Args[0].X]
+	var cols []Type
+	switch name := strings.TrimSuffix(strings.ToUpper(udtf.Name.Name.Name), "_OUTER"); name {
+	case "EXPLODE", "POSEXPLODE":
+		if k, v, ok := mapTypes(arg); ok {
+			cols = []Type{k, v}
+		} else {
+			cols = []Type{elemType(arg)}
+		}
+		if name == "POSEXPLODE" {
+			cols = append([]Type{Integer}, cols...)
+		}
+	}
+	return cols
+}
+
+// mapTypes returns the key and value types of a MAP<K, V> type.
+func mapTypes(t Type) (Type, Type, bool) {
+	name := t.String()
+	if t.Kind != OtherKind || !strings.HasPrefix(strings.ToUpper(name), "MAP<") || !strings.HasSuffix(name, ">") {
+		return Unknown, Unknown, false
+	}
+	inner, depth := name[len("MAP<"):len(name)-1], 0
+	for i, c := range inner {
+		switch c {
+		case '<', '(':
+			depth++
+		case '>', ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				return ParseType(strings.TrimSpace(inner[:i])), ParseType(strings.TrimSpace(inner[i+1:])), true
+			}
+		}
+	}
+	return Unknown, Unknown, false
+}
+
+// literalType returns the type of typed literals parsed as identifiers,
+// such as DATE '2024-01-01' and CURRENT_DATE.
+func literalType(ident *query.Ident) (Type, bool) {
+	switch ident.Tok {
+	case query.CURRENT_DATE:
+		return Date, true
+	case query.CURRENT_TIMESTAMP:
+		return Timestamp, true
+	case query.DATE:
+		if strings.Contains(ident.Name, "'") {
+			return Date, true
+		}
+	case query.TIMESTAMP:
+		if strings.Contains(ident.Name, "'") {
+			return Timestamp, true
+		}
+	}
+	return Unknown, false
+}
+
+func (tc *typeChecker) binaryExpr(expr *query.BinaryExpr, scope *typeScope, env *typeEnv) Type {
+	x := tc.expr(expr.X, scope, env)
+	y := tc.expr(expr.Y, scope, env)
+
+	switch expr.Op {
+	case query.EQ, query.NE, query.LT, query.LE, query.GT, query.GE, query.EQN:
+		tc.compare(expr.OpPos, expr.X, x, expr.Y, y)
+		return Boolean
+
+	case query.IN, query.NOTIN:
+		var values []query.Expr
+		switch list := expr.Y.(type) {
+		case *query.ExprList:
+			values = list.Exprs
+		case *query.ParenExpr:
+			values = []query.Expr{list.X}
+		}
+		for _, value := range values {
+			tc.compare(expr.OpPos, expr.X, x, value, tc.info.Types[value])
+		}
+		if sel, ok := expr.Y.(query.SelectExpr); ok && !y.IsUnknown() {
+			tc.compare(expr.OpPos, expr.X, x, sel, y)
+		}
+		return Boolean
+
+	case query.BETWEEN, query.NOTBETWEEN:
+		if rng, ok := expr.Y.(*query.Range); ok {
+			tc.compare(expr.OpPos, expr.X, x, rng.X, tc.info.Types[rng.X])
+			tc.compare(expr.OpPos, expr.X, x, rng.Y, tc.info.Types[rng.Y])
+		}
+		return Boolean
+
+	case query.AND, query.OR, query.IS, query.ISNOT, query.LIKE, query.NOTLIKE, query.GLOB, query.NOTGLOB,
+		query.MATCH, query.NOTMATCH, query.REGEXP, query.NOTREGEXP:
+		return Boolean
+
+	case query.CONCAT:
+		return String
+
+	case query.BITAND, query.BITOR, query.LSHIFT, query.RSHIFT:
+		return Integer
+
+	case query.PLUS, query.MINUS, query.STAR, query.SLASH, query.REM:
+		if expr.Op == query.PLUS || expr.Op == query.MINUS {
+			switch {
+			case isTime(x.Kind) && y.Kind == IntervalKind:
+				return x
+			case expr.Op == query.PLUS && x.Kind == IntervalKind && isTime(y.Kind):
+				return y
+			}
+		}
+		if x.Kind == NullKind {
+			x = y
+		} else if y.Kind == NullKind {
+			y = x
+		}
+		if !x.Kind.IsNumeric() || !y.Kind.IsNumeric() {
+			return Unknown
+		}
+		if expr.Op == query.SLASH && x.Kind == IntegerKind && y.Kind == IntegerKind {
+			return Float
+		}
+		t, _ := CommonType(x, y)
+		return t
+	}
+	return Unknown
+}
+
+// compare reports a mismatch if values of types x and y cannot be compared.
+func (tc *typeChecker) compare(pos query.Pos, xExpr query.Expr, x Type, yExpr query.Expr, y Type) {
+	if !Comparable(x, y) {
+		tc.report(pos, "cannot compare %s (%s) with %s (%s)", xExpr, x, yExpr, y)
+	}
+}
+
+func (tc *typeChecker) caseExpr(expr *query.CaseExpr, scope *typeScope, env *typeEnv) Type {
+	var operand Type
+	if expr.Operand != nil {
+		operand = tc.expr(expr.Operand, scope, env)
+	}
+
+	result := Null
+	merge := func(pos query.Pos, body query.Expr) {
+		t := tc.expr(body, scope, env)
+		if common, ok := CommonType(result, t); ok {
+			result = common
+		} else {
+			tc.report(pos, "CASE branches have incompatible types %s and %s", result, t)
+			result = Unknown
+		}
+	}
+
+	for _, blk := range expr.Blocks {
+		cond := tc.expr(blk.Condition, scope, env)
+		if expr.Operand != nil {
+			tc.compare(blk.When, expr.Operand, operand, blk.Condition, cond)
+		}
+		merge(blk.Then, blk.Body)
+	}
+	if expr.ElseExpr != nil {
+		merge(expr.Else, expr.ElseExpr)
+	}
+	return result
+}
+
+func (tc *typeChecker) call(c *query.Call, scope *typeScope, env *typeEnv) Type {
+	args := make([]Type, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = tc.expr(arg.X, scope, env)
+		if arg.Type != nil {
+			args[i] = typeOf(arg.Type)
+		}
+	}
+	for _, term := range c.OrderingTerms {
+		tc.expr(term.X, scope, env)
+	}
+	tc.expr(c.LimitExpr, scope, env)
+	tc.expr(c.SeparatorExpr, scope, env)
+	if c.Within != nil && c.Within.OrderingTerm != nil {
+		tc.expr(c.Within.OrderingTerm.X, scope, env)
+	}
+	tc.expr(c.FilterExpr, scope, env)
+	if c.Over != nil {
+		tc.window(c.Over.Definition, scope, env)
+	}
+
+	if c.Name == nil {
+		return Unknown
+	}
+	if isGroupingFunc(c) && len(scope.grouping) != 0 {
+		for _, arg := range c.Args {
+			if !grouped(arg.X, scope.grouping) {
+				tc.report(query.NodePos(arg.X), "%s is not grouped by GROUP BY", arg.X)
+			}
+		}
+	}
+	sig := tc.funcs.Lookup(tableName(c.Name))
+	if sig == nil {
+		return Unknown
+	}
+	return sig(args)
+}
@@ -0,0 +1,220 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+	"github.com/sbchaos/query/analysis"
+)
+
+func TestCheckTypes(t *testing.T) {
+	catalog := analysis.NewMapCatalog(
+		&analysis.Table{Name: "db.orders", Columns: []*analysis.Column{
+			{Name: "id", Type: "BIGINT"},
+			{Name: "user_id", Type: "BIGINT"},
+			{Name: "amount", Type: "DECIMAL(10,2)"},
+			{Name: "status", Type: "STRING"},
+			{Name: "pt", Type: "DATE"},
+		}},
+		&analysis.Table{Name: "db.users", Columns: []*analysis.Column{
+			{Name: "user_id", Type: "BIGINT"},
+			{Name: "name", Type: "STRING"},
+			{Name: "tags", Type: "ARRAY<STRING>"},
+		}},
+		&analysis.Table{Name: "db.summary", Columns: []*analysis.Column{
+			{Name: "user_id", Type: "BIGINT"},
+			{Name: "total", Type: "DECIMAL(18,2)"},
+		}},
+		&analysis.Table{Name: "db.events", Columns: []*analysis.Column{
+			{Name: "id", Type: "BIGINT"},
+			{Name: "props", Type: "MAP<STRING, DECIMAL(10,2)>"},
+		}},
+	)
+
+	t.Run("Expressions", func(t *testing.T) {
+		AssertOutputSchema(t, catalog, `SELECT
+			id + 1 AS a, amount * 2 AS b, id / 2 AS c, 1.5 AS d, 'x' || id AS e,
+			CASE WHEN amount > 0 THEN amount ELSE 0 END AS f, CAST(id AS STRING) AS g,
+			pt + INTERVAL 1 DAY AS h, DATE '2024-01-01' AS i, current_date AS j,
+			id IN (1, 2) AS k, NOT status IS NULL AS l, -amount AS m, status
+		FROM db.orders`,
+			"a BIGINT", "b DECIMAL", "c DOUBLE", "d DOUBLE", "e STRING",
+			"f DECIMAL", "g STRING", "h DATE", "i DATE", "j DATE",
+			"k BOOLEAN", "l BOOLEAN", "m DECIMAL(10,2)", "status STRING")
+	})
+
+	t.Run("Functions", func(t *testing.T) {
+		AssertOutputSchema(t, catalog, `SELECT
+			count(*) AS n, sum(id) AS s, sum(amount) AS sa, avg(id) AS av, max(status) AS mx,
+			row_number() OVER (PARTITION BY user_id ORDER BY pt) AS rn,
+			sum(amount) OVER (PARTITION BY user_id) AS running,
+			coalesce(status, 'none') AS st, if(id > 1, id, NULL) AS iff,
+			upper(status) AS up, date_add(pt, 1) AS next, my_udf(id) AS u, collect_list(status) AS l
+		FROM db.orders GROUP BY user_id`,
+			"n BIGINT", "s BIGINT", "sa DECIMAL", "av DOUBLE", "mx STRING",
+			"rn BIGINT", "running DECIMAL", "st STRING", "iff BIGINT",
+			"up STRING", "next DATE", "u ", "l ARRAY<STRING>")
+
+		funcs := analysis.DefaultFunctions()
+		funcs.Add("db.my_udf", analysis.Returns(analysis.ParseType("INT")))
+		info := analysis.CheckTypes(ParseStatement(t, `SELECT db.my_udf(1) AS x`), nil, funcs)
+		assert.Equal(t, []*analysis.Column{{Name: "x", Type: "INT"}}, info.Columns)
+	})
+
+	t.Run("Sources", func(t *testing.T) {
+		AssertOutputSchema(t, catalog, `SELECT * FROM db.orders o JOIN db.users USING (user_id)`,
+			"user_id BIGINT", "id BIGINT", "amount DECIMAL(10,2)", "status STRING", "pt DATE", "name STRING", "tags ARRAY<STRING>")
+		AssertOutputSchema(t, catalog, `SELECT * FROM db.users u LEFT SEMI JOIN db.orders o ON o.user_id = u.user_id`,
+			"user_id BIGINT", "name STRING", "tags ARRAY<STRING>")
+		AssertOutputSchema(t, catalog, `WITH t (uid, total) AS (SELECT user_id, sum(amount) FROM db.orders GROUP BY user_id)
+			SELECT s.*, t.total / 2 AS half, u.tags[0] AS tag FROM (SELECT uid, total FROM t) s JOIN db.users u ON u.user_id = s.uid, t`,
+			"uid BIGINT", "total DECIMAL", "half DECIMAL", "tag STRING")
+		AssertOutputSchema(t, catalog, `SELECT id, (SELECT max(name) FROM db.users u WHERE u.user_id = o.user_id) AS name FROM db.orders o`,
+			"id BIGINT", "name STRING")
+		AssertOutputSchema(t, catalog, `SELECT * FROM db.orders LATERAL VIEW explode(split(status, ',')) s AS part`,
+			"id BIGINT", "user_id BIGINT", "amount DECIMAL(10,2)", "status STRING", "pt DATE", "part ")
+		AssertOutputSchema(t, catalog, `VALUES (1, 'a')`, " BIGINT", " STRING")
+
+		info := analysis.CheckTypes(ParseStatement(t, `SELECT * FROM db.unknown`), catalog, nil)
+		assert.Nil(t, info.Columns)
+	})
+
+	t.Run("Pivot", func(t *testing.T) {
+		AssertOutputSchema(t, catalog, `SELECT * FROM db.orders PIVOT (sum(amount) FOR status IN ('paid', 'open' AS pending))`,
+			"id BIGINT", "user_id BIGINT", "pt DATE", "paid DECIMAL", "pending DECIMAL")
+		AssertOutputSchema(t, catalog, `SELECT p.user_id, p.paid_n FROM (SELECT user_id, amount, status FROM db.orders) o
+			PIVOT (sum(amount) AS total, count(*) AS n FOR status IN ('paid')) AS p`,
+			"user_id BIGINT", "paid_n BIGINT")
+		AssertOutputSchema(t, catalog, `SELECT * FROM db.summary UNPIVOT (value FOR kind IN (user_id, total AS sum))`,
+			"kind STRING", "value BIGINT")
+		AssertOutputSchema(t, catalog, `SELECT kind, value FROM db.summary UNPIVOT (value FOR kind IN (user_id AS 'user', total AS 'sum'))`,
+			"kind STRING", "value BIGINT")
+	})
+
+	t.Run("Unnest", func(t *testing.T) {
+		AssertOutputSchema(t, catalog, `SELECT u.name, tag, off FROM db.users u, UNNEST(u.tags) AS tag WITH OFFSET AS off`,
+			"name STRING", "tag STRING", "off BIGINT")
+		AssertOutputSchema(t, catalog, `SELECT s.* FROM db.users TABLESAMPLE (BUCKET 1 OUT OF 4) s CROSS JOIN UNNEST(s.tags) t`,
+			"user_id BIGINT", "name STRING", "tags ARRAY<STRING>")
+	})
+
+	t.Run("Lateral", func(t *testing.T) {
+		AssertOutputSchema(t, catalog, `SELECT u.name, t.tag FROM db.users u LATERAL VIEW explode(u.tags) t AS tag`,
+			"name STRING", "tag STRING")
+		AssertOutputSchema(t, catalog, `SELECT * FROM db.events LATERAL VIEW OUTER posexplode(props) p AS i, k, v`,
+			"id BIGINT", "props MAP<STRING, DECIMAL(10,2)>", "i BIGINT", "k STRING", "v DECIMAL(10,2)")
+		AssertOutputSchema(t, catalog, `SELECT s.user_id, tag FROM (SELECT user_id, tags FROM db.users) s
+			JOIN db.orders o ON o.user_id = s.user_id LATERAL VIEW explode(s.tags) t AS tag`,
+			"user_id BIGINT", "tag STRING")
+	})
+
+	t.Run("Union", func(t *testing.T) {
+		AssertOutputSchema(t, catalog, `SELECT id, NULL AS x FROM db.orders UNION ALL SELECT 1.5, 'a'`,
+			"id DOUBLE", "x STRING")
+		AssertTypeIssues(t, catalog, `SELECT id, status FROM db.orders UNION ALL SELECT user_id, pt FROM db.orders UNION SELECT 1 FROM db.users`,
+			`1:34: column 2 of UNION has incompatible types STRING and DATE`,
+			`1:78: UNION operands have 2 and 1 columns`)
+		AssertTypeIssues(t, catalog, `SELECT id FROM db.orders UNION SELECT status FROM db.orders INTERSECT SELECT name FROM db.users`,
+			`1:26: column 1 of UNION has incompatible types BIGINT and STRING`)
+		AssertTypeIssues(t, catalog, `(SELECT id FROM db.orders ORDER BY id LIMIT 1) EXCEPT SELECT pt FROM db.orders`,
+			`1:48: column 1 of EXCEPT has incompatible types BIGINT and DATE`)
+	})
+
+	t.Run("Grouping", func(t *testing.T) {
+		AssertOutputSchema(t, catalog, `SELECT user_id, status, GROUPING(user_id) AS g, grouping_id(user_id, status) AS gid, sum(amount) AS total
+		FROM db.orders GROUP BY ROLLUP (user_id, status)`,
+			"user_id BIGINT", "status STRING", "g BIGINT", "gid BIGINT", "total DECIMAL")
+		AssertTypeIssues(t, catalog, `SELECT GROUPING(o.status), GROUPING(o.user_id) FROM db.orders o GROUP BY GROUPING SETS ((user_id, O.Status), ())`)
+		AssertTypeIssues(t, catalog, `SELECT GROUPING_ID(user_id, pt) FROM db.orders GROUP BY CUBE (user_id, status)`,
+			`1:29: pt is not grouped by GROUP BY`)
+	})
+
+	t.Run("Comparisons", func(t *testing.T) {
+		AssertTypeIssues(t, catalog, `SELECT * FROM db.orders WHERE pt >= '2024-01-01' AND id = 1.0 AND status IN ('a', 'b') AND amount BETWEEN 1 AND 10`)
+		AssertTypeIssues(t, catalog, `SELECT * FROM db.orders o JOIN db.users u ON o.user_id = u.name WHERE o.id IN (1, 'a') AND pt BETWEEN 1 AND '2024-01-01'`,
+			`1:56: cannot compare o.user_id (BIGINT) with u.name (STRING)`,
+			`1:76: cannot compare o.id (BIGINT) with 'a' (STRING)`,
+			`1:95: cannot compare pt (DATE) with 1 (BIGINT)`)
+		AssertTypeIssues(t, catalog, `SELECT CASE status WHEN 1 THEN 'a' WHEN 'b' THEN 2 END FROM db.orders WHERE id IN (SELECT name FROM db.users)`,
+			`1:20: cannot compare status (STRING) with 1 (BIGINT)`,
+			`1:45: CASE branches have incompatible types STRING and BIGINT`,
+			`1:80: cannot compare id (BIGINT) with SELECT name FROM db.users (STRING)`)
+		AssertTypeIssues(t, catalog, `SELECT * FROM x WHERE a = 'b' AND c = 1`)
+		AssertTypeIssues(t, catalog, `SELECT count(*) FILTER (WHERE id = 'x') FROM db.orders`,
+			`1:34: cannot compare id (BIGINT) with 'x' (STRING)`)
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+		AssertTypeIssues(t, catalog, `INSERT INTO db.summary SELECT user_id, sum(amount) FROM db.orders GROUP BY user_id`)
+		AssertTypeIssues(t, catalog, `INSERT INTO db.summary (total, user_id) SELECT sum(amount), user_id FROM db.orders GROUP BY user_id`)
+		AssertTypeIssues(t, catalog, `INSERT INTO db.summary SELECT status, sum(amount) FROM db.orders GROUP BY status`,
+			`1:31: cannot insert STRING into column user_id of type BIGINT`)
+		AssertTypeIssues(t, catalog, `INSERT INTO db.summary SELECT * FROM db.orders`,
+			`1:1: INSERT into db.summary expects 2 columns but its query returns 5`)
+		AssertTypeIssues(t, catalog, `INSERT INTO db.summary (user_id, count) VALUES (1, 2)`,
+			`1:34: table db.summary has no column count`)
+		AssertTypeIssues(t, catalog, `INSERT INTO db.summary SELECT * FROM db.unknown`)
+		AssertTypeIssues(t, catalog, `INSERT INTO db.orders PARTITION (pt = '2024-01-01') SELECT id, user_id, amount, status FROM db.orders`)
+		AssertTypeIssues(t, catalog, `INSERT INTO db.orders PARTITION (pt) SELECT id, user_id, amount, status, pt FROM db.orders`)
+		AssertTypeIssues(t, catalog, `INSERT INTO db.orders PARTITION (pt) SELECT id, user_id, amount, status, id FROM db.orders`,
+			`1:74: cannot insert BIGINT into column pt of type DATE`)
+	})
+
+	t.Run("TypeOf", func(t *testing.T) {
+		stmt := ParseStatement(t, `SELECT amount + 1 FROM db.orders`).(*query.SelectStatement)
+		info := analysis.CheckTypes(stmt, catalog, nil)
+		sum := stmt.Columns[0].Expr.(*query.BinaryExpr)
+		assert.Equal(t, analysis.Decimal, info.TypeOf(sum))
+		assert.Equal(t, analysis.ParseType("DECIMAL(10,2)"), info.TypeOf(sum.X))
+		assert.Equal(t, analysis.Integer, info.TypeOf(sum.Y))
+		assert.Equal(t, []*analysis.Column{{Type: "DECIMAL"}}, info.Schema(stmt))
+	})
+}
+
+func TestCommonType(t *testing.T) {
+	for _, tt := range []struct {
+		x, y, want string
+		ok         bool
+	}{
+		{"INT", "int", "INT", true},
+		{"INT", "BIGINT", "BIGINT", true},
+		{"BIGINT", "DOUBLE", "DOUBLE", true},
+		{"DECIMAL(10,2)", "BIGINT", "DECIMAL", true},
+		{"DATE", "TIMESTAMP", "TIMESTAMP", true},
+		{"ARRAY<INT>", "ARRAY<INT>", "ARRAY<INT>", true},
+		{"", "STRING", "", true},
+		{"STRING", "BIGINT", "", false},
+		{"ARRAY<INT>", "ARRAY<STRING>", "", false},
+	} {
+		got, ok := analysis.CommonType(analysis.ParseType(tt.x), analysis.ParseType(tt.y))
+		assert.Equal(t, tt.ok, ok, "%s, %s", tt.x, tt.y)
+		assert.Equal(t, tt.want, got.String(), "%s, %s", tt.x, tt.y)
+	}
+}
+
+// AssertOutputSchema asserts that the result columns of s are want, each
+// formatted as "name type".
+func AssertOutputSchema(tb testing.TB, catalog analysis.Catalog, s string, want ...string) {
+	tb.Helper()
+
+	info := analysis.CheckTypes(ParseStatement(tb, s), catalog, nil)
+	var got []string
+	for _, col := range info.Columns {
+		got = append(got, col.Name+" "+col.Type)
+	}
+	assert.Equal(tb, want, got)
+	assert.Empty(tb, info.Issues)
+}
+
+// AssertTypeIssues asserts that checking the types of s reports want.
+func AssertTypeIssues(tb testing.TB, catalog analysis.Catalog, s string, want ...string) {
+	tb.Helper()
+
+	var got []string
+	for _, issue := range analysis.CheckTypes(ParseStatement(tb, s), catalog, nil).Issues {
+		got = append(got, issue.String())
+	}
+	assert.Equal(tb, want, got)
+}
@@ -0,0 +1,204 @@
+package analysis
+
+import (
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// Kind is the category of a Type.
+type Kind int
+
+const (
+	// UnknownKind is the kind of expressions whose type cannot be inferred,
+	// such as references to columns of unknown tables.
+	UnknownKind Kind = iota
+	NullKind
+	BooleanKind
+	IntegerKind
+	DecimalKind
+	FloatKind
+	StringKind
+	BytesKind
+	DateKind
+	TimestampKind
+	IntervalKind
+	// OtherKind is the kind of types the checker does not interpret, such as
+	// ARRAY<STRING>. Values of such types only match the same type.
+	OtherKind
+)
+
+var kindNames = [...]string{
+	UnknownKind:   "",
+	NullKind:      "NULL",
+	BooleanKind:   "BOOLEAN",
+	IntegerKind:   "BIGINT",
+	DecimalKind:   "DECIMAL",
+	FloatKind:     "DOUBLE",
+	StringKind:    "STRING",
+	BytesKind:     "BINARY",
+	DateKind:      "DATE",
+	TimestampKind: "TIMESTAMP",
+	IntervalKind:  "INTERVAL",
+	OtherKind:     "",
+}
+
+// String returns the name of the default type of the kind, e.g. "BIGINT".
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return ""
+	}
+	return kindNames[k]
+}
+
+// IsNumeric returns true for integers, decimals and floats.
+func (k Kind) IsNumeric() bool {
+	return k == IntegerKind || k == DecimalKind || k == FloatKind
+}
+
+// Type is the data type of an expression or column.
+type Type struct {
+	Kind Kind `json:"kind"`
+
+	// Name is the type as declared, e.g. "DECIMAL(10,2)" or "INT". It is
+	// blank for inferred types, which are named by their kind.
+	Name string `json:"name,omitempty"`
+}
+
+// Common types, named by their kind.
+var (
+	Unknown   = Type{Kind: UnknownKind}
+	Null      = Type{Kind: NullKind}
+	Boolean   = Type{Kind: BooleanKind}
+	Integer   = Type{Kind: IntegerKind}
+	Decimal   = Type{Kind: DecimalKind}
+	Float     = Type{Kind: FloatKind}
+	String    = Type{Kind: StringKind}
+	Bytes     = Type{Kind: BytesKind}
+	Date      = Type{Kind: DateKind}
+	Timestamp = Type{Kind: TimestampKind}
+	Interval  = Type{Kind: IntervalKind}
+)
+
+// String returns the name of the type. Unknown types have a blank name.
+func (t Type) String() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return t.Kind.String()
+}
+
+// IsUnknown returns true if the type could not be inferred.
+func (t Type) IsUnknown() bool {
+	return t.Kind == UnknownKind
+}
+
+// typeKinds maps type names, without parameters, to their kind.
+var typeKinds = map[string]Kind{
+	"BOOL":          BooleanKind,
+	"BOOLEAN":       BooleanKind,
+	"BIGINT":        IntegerKind,
+	"INT":           IntegerKind,
+	"INT64":         IntegerKind,
+	"INTEGER":       IntegerKind,
+	"SMALLINT":      IntegerKind,
+	"TINYINT":       IntegerKind,
+	"BIGNUMERIC":    DecimalKind,
+	"DECIMAL":       DecimalKind,
+	"NUMERIC":       DecimalKind,
+	"DOUBLE":        FloatKind,
+	"FLOAT":         FloatKind,
+	"FLOAT64":       FloatKind,
+	"REAL":          FloatKind,
+	"CHAR":          StringKind,
+	"CHARACTER":     StringKind,
+	"CLOB":          StringKind,
+	"NCHAR":         StringKind,
+	"NVARCHAR":      StringKind,
+	"STRING":        StringKind,
+	"TEXT":          StringKind,
+	"VARCHAR":       StringKind,
+	"BINARY":        BytesKind,
+	"BYTES":         BytesKind,
+	"DATE":          DateKind,
+	"DATETIME":      TimestampKind,
+	"TIMESTAMP":     TimestampKind,
+	"TIMESTAMP_LTZ": TimestampKind,
+	"TIMESTAMP_NTZ": TimestampKind,
+	"INTERVAL":      IntervalKind,
+	"NULL":          NullKind,
+}
+
+// ParseType returns the type named by s, such as "BIGINT" or "DECIMAL(10,2)".
+// Unrecognized names are of OtherKind; a blank name is Unknown.
+func ParseType(s string) Type {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Unknown
+	}
+
+	base := s
+	if i := strings.IndexAny(base, "(<"); i >= 0 {
+		base = base[:i]
+	}
+	kind, ok := typeKinds[strings.ToUpper(strings.TrimSpace(base))]
+	if !ok {
+		kind = OtherKind
+	}
+	return Type{Kind: kind, Name: s}
+}
+
+// typeOf returns the type declared by typ, e.g. in a CAST.
+func typeOf(typ *query.Type) Type {
+	if typ == nil || typ.Name == nil {
+		return Unknown
+	}
+	return ParseType(typ.String())
+}
+
+// CommonType returns the type that values of types x and y are both
+// converted to, e.g. by UNION or the branches of CASE. NULL converts to any
+// type, integers to decimals and floats, and dates to timestamps. Returns
+// false if x and y have no common type. Unknown types convert to any type.
+func CommonType(x, y Type) (Type, bool) {
+	switch {
+	case x.Kind == UnknownKind || y.Kind == UnknownKind:
+		return Unknown, true
+	case x.Kind == NullKind:
+		return y, true
+	case y.Kind == NullKind:
+		return x, true
+	case x.Kind == OtherKind || y.Kind == OtherKind:
+		if strings.EqualFold(x.Name, y.Name) {
+			return x, true
+		}
+		return Unknown, false
+	case x.Kind == y.Kind:
+		if strings.EqualFold(x.Name, y.Name) {
+			return x, true
+		}
+		return Type{Kind: x.Kind}, true
+	case x.Kind.IsNumeric() && y.Kind.IsNumeric():
+		if x.Kind > y.Kind {
+			return Type{Kind: x.Kind}, true
+		}
+		return Type{Kind: y.Kind}, true
+	case isTime(x.Kind) && isTime(y.Kind):
+		return Timestamp, true
+	default:
+		return Unknown, false
+	}
+}
+
+// Comparable returns true if values of types x and y can be compared.
+// Strings compare with dates and timestamps, which they are parsed as.
+func Comparable(x, y Type) bool {
+	if _, ok := CommonType(x, y); ok {
+		return true
+	}
+	return (x.Kind == StringKind && isTime(y.Kind)) || (y.Kind == StringKind && isTime(x.Kind))
+}
+
+func isTime(k Kind) bool {
+	return k == DateKind || k == TimestampKind
+}
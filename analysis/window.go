@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// ResolveWindow returns the window that over applies to within sel. A named
+// window, as in "OVER w", is looked up in the WINDOW clause of sel. A window
+// that extends a base window, as in "OVER (w ORDER BY a)" or "WINDOW v AS
+// (w ROWS UNBOUNDED PRECEDING)", is merged with its base: the partitions
+// come from the base, the ordering from whichever of the two has one, and
+// the frame from the extending window.
+//
+// The returned definition has no base and may share nodes with sel; sel is
+// not modified. It returns an error if a window is unknown, if windows
+// extend one another in a cycle, or if a window overrides the partitions,
+// ordering or frame of its base.
+func ResolveWindow(sel *query.SelectStatement, over *query.OverClause) (*query.WindowDefinition, error) {
+	if over == nil {
+		return nil, nil
+	}
+	wr := &windowResolver{sel: sel, seen: make(map[string]bool)}
+	if over.Name != nil {
+		return wr.named(over.Name)
+	}
+	return wr.resolve(over.Definition)
+}
+
+type windowResolver struct {
+	sel  *query.SelectStatement
+	seen map[string]bool
+}
+
+// named returns the resolved definition of the window named by name.
+func (wr *windowResolver) named(name *query.Ident) (*query.WindowDefinition, error) {
+	key := strings.ToLower(name.Name)
+	if wr.seen[key] {
+		return nil, fmt.Errorf("%s: window %s extends itself", name.NamePos, name.Name)
+	}
+
+	var window *query.Window
+	if wr.sel != nil {
+		for _, w := range wr.sel.Windows {
+			if w.Name != nil && strings.EqualFold(w.Name.Name, name.Name) {
+				window = w
+				break
+			}
+		}
+	}
+	if window == nil {
+		return nil, fmt.Errorf("%s: unknown window %s", name.NamePos, name.Name)
+	}
+
+	wr.seen[key] = true
+	defer delete(wr.seen, key)
+	return wr.resolve(window.Definition)
+}
+
+// resolve merges def with its base window, if any.
+func (wr *windowResolver) resolve(def *query.WindowDefinition) (*query.WindowDefinition, error) {
+	if def == nil || def.Base == nil {
+		return def, nil
+	}
+
+	base, err := wr.named(def.Base)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(def.Partitions) != 0:
+		return nil, fmt.Errorf("%s: cannot override PARTITION BY of window %s", def.Partition, def.Base.Name)
+	case len(def.OrderingTerms) != 0 && len(base.OrderingTerms) != 0:
+		return nil, fmt.Errorf("%s: cannot override ORDER BY of window %s", def.Order, def.Base.Name)
+	case base.Frame != nil:
+		return nil, fmt.Errorf("%s: cannot extend window %s, which has a frame", def.Base.NamePos, def.Base.Name)
+	}
+
+	merged := &query.WindowDefinition{
+		Lparen:        def.Lparen,
+		Partition:     base.Partition,
+		PartitionBy:   base.PartitionBy,
+		Partitions:    base.Partitions,
+		Order:         base.Order,
+		OrderBy:       base.OrderBy,
+		OrderingTerms: base.OrderingTerms,
+		Frame:         def.Frame,
+		Rparen:        def.Rparen,
+	}
+	if len(def.OrderingTerms) != 0 {
+		merged.Order, merged.OrderBy, merged.OrderingTerms = def.Order, def.OrderBy, def.OrderingTerms
+	}
+	return merged, nil
+}
@@ -0,0 +1,70 @@
+package analysis_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+	"github.com/sbchaos/query/analysis"
+)
+
+func TestResolveWindow(t *testing.T) {
+	t.Run("Inline", func(t *testing.T) {
+		AssertResolveWindow(t, `SELECT sum(a) OVER (PARTITION BY b ORDER BY c ROWS 1 PRECEDING) FROM t`,
+			`(PARTITION BY b ORDER BY c ROWS 1 PRECEDING)`)
+	})
+
+	t.Run("Named", func(t *testing.T) {
+		AssertResolveWindow(t, `SELECT sum(a) OVER W FROM t WINDOW w AS (PARTITION BY b ROWS UNBOUNDED PRECEDING)`,
+			`(PARTITION BY b ROWS UNBOUNDED PRECEDING)`)
+	})
+
+	t.Run("Extends", func(t *testing.T) {
+		AssertResolveWindow(t, `SELECT sum(a) OVER (w ORDER BY c RANGE BETWEEN INTERVAL 7 DAY PRECEDING AND CURRENT ROW) FROM t WINDOW w AS (PARTITION BY b)`,
+			`(PARTITION BY b ORDER BY c RANGE BETWEEN INTERVAL 7 DAY PRECEDING AND CURRENT ROW)`)
+		AssertResolveWindow(t, `SELECT sum(a) OVER (v GROUPS 1 FOLLOWING) FROM t WINDOW w AS (PARTITION BY b), v AS (w ORDER BY c)`,
+			`(PARTITION BY b ORDER BY c GROUPS 1 FOLLOWING)`)
+		AssertResolveWindow(t, `SELECT sum(a) OVER v FROM t WINDOW v AS (w), w AS (ORDER BY c)`,
+			`(ORDER BY c)`)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		AssertResolveWindowError(t, `SELECT sum(a) OVER w FROM t`, `1:20: unknown window w`)
+		AssertResolveWindowError(t, `SELECT sum(a) OVER (x) FROM t WINDOW w AS ()`, `1:21: unknown window x`)
+		AssertResolveWindowError(t, `SELECT sum(a) OVER w FROM t WINDOW w AS (v), v AS (w)`, `1:52: window w extends itself`)
+		AssertResolveWindowError(t, `SELECT sum(a) OVER (w PARTITION BY c) FROM t WINDOW w AS (PARTITION BY b)`,
+			`1:23: cannot override PARTITION BY of window w`)
+		AssertResolveWindowError(t, `SELECT sum(a) OVER (w ORDER BY c) FROM t WINDOW w AS (ORDER BY b)`,
+			`1:23: cannot override ORDER BY of window w`)
+		AssertResolveWindowError(t, `SELECT sum(a) OVER (w ORDER BY c) FROM t WINDOW w AS (ROWS CURRENT ROW)`,
+			`1:21: cannot extend window w, which has a frame`)
+	})
+}
+
+// AssertResolveWindow asserts that the OVER clause of the first result
+// column of s resolves to want.
+func AssertResolveWindow(tb testing.TB, s string, want string) {
+	tb.Helper()
+	sel, over := firstOverClause(tb, s)
+	def, err := analysis.ResolveWindow(sel, over)
+	if assert.NoError(tb, err) {
+		assert.Equal(tb, want, def.String())
+	}
+	assert.Equal(tb, s, sel.String(), "statement was modified")
+}
+
+// AssertResolveWindowError asserts that resolving the OVER clause of the
+// first result column of s fails with want.
+func AssertResolveWindowError(tb testing.TB, s string, want string) {
+	tb.Helper()
+	sel, over := firstOverClause(tb, s)
+	_, err := analysis.ResolveWindow(sel, over)
+	assert.EqualError(tb, err, want)
+}
+
+func firstOverClause(tb testing.TB, s string) (*query.SelectStatement, *query.OverClause) {
+	tb.Helper()
+	sel := ParseStatement(tb, s).(*query.SelectStatement)
+	return sel, sel.Columns[0].Expr.(*query.Call).Over
+}
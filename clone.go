@@ -0,0 +1,52 @@
+package query
+
+import "reflect"
+
+// Clone returns a deep copy of n. Modifying the copy never affects n.
+// Returns nil if n is nil.
+func Clone(n Node) Node {
+	if n == nil {
+		return nil
+	}
+	return cloneValue(reflect.ValueOf(n)).Interface().(Node)
+}
+
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+		return c
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+
+	case reflect.Struct:
+		c := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			c.Field(i).Set(cloneValue(v.Field(i)))
+		}
+		return c
+
+	default:
+		return v
+	}
+}
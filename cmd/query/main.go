@@ -0,0 +1,346 @@
+// Command query parses, formats and analyses sql files.
+//
+// Usage:
+//
+//	query <command> [flags] [path ...]
+//
+// Each path is a file or a directory, which is searched recursively for
+// files ending in .sql. With no paths, or a path of "-", the query is read
+// from standard input.
+//
+// The commands are:
+//
+//	parse     print the parsed statements as json
+//	fmt       rewrite files in their canonical form
+//	tables    list the tables read and written
+//	lineage   print the column lineage as yaml
+//	lint      report problems found by the built-in lint rules
+//	validate  report parse errors with their positions
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/sbchaos/query"
+	"github.com/sbchaos/query/lineage"
+	"github.com/sbchaos/query/lint"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(c *env, args []string) int
+}
+
+var commands = []command{
+	{"parse", "print the parsed statements as json", runParse},
+	{"fmt", "rewrite files in their canonical form", runFmt},
+	{"tables", "list the tables read and written", runTables},
+	{"lineage", "print the column lineage as yaml", runLineage},
+	{"lint", "report problems found by the built-in lint rules", runLint},
+	{"validate", "report parse errors with their positions", runValidate},
+}
+
+// env holds the streams of a single invocation.
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	c := &env{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		c.usage()
+		return exitUsage
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, args[1:])
+		}
+	}
+
+	if args[0] != "help" && args[0] != "-h" && args[0] != "-help" {
+		fmt.Fprintf(stderr, "query: unknown command %q\n", args[0])
+	}
+	c.usage()
+	return exitUsage
+}
+
+func (c *env) usage() {
+	fmt.Fprintln(c.stderr, "usage: query <command> [flags] [path ...]")
+	fmt.Fprintln(c.stderr)
+	fmt.Fprintln(c.stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stderr, "  %-9s %s\n", cmd.name, cmd.usage)
+	}
+}
+
+// flagSet returns a flag set for the named command that writes to stderr.
+func (c *env) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("query "+name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: query %s [flags] [path ...]\n", name)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// errorf reports an error on stderr and returns exitFailure.
+func (c *env) errorf(format string, args ...interface{}) int {
+	fmt.Fprintf(c.stderr, "query: "+format+"\n", args...)
+	return exitFailure
+}
+
+// input is a single query read from a file or standard input.
+type input struct {
+	name string // "-" for standard input
+	src  string
+}
+
+func (in *input) parse() ([]query.Statement, error) {
+	return query.NewParser(strings.NewReader(in.src)).ParseStatements()
+}
+
+// readInputs reads the queries named by paths.
+func (c *env) readInputs(paths []string) ([]*input, error) {
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	var inputs []*input
+	for _, path := range paths {
+		if path == "-" {
+			b, err := io.ReadAll(c.stdin)
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, &input{name: "-", src: string(b)})
+			continue
+		}
+
+		err := filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			} else if d.IsDir() || (name != path && filepath.Ext(name) != ".sql") {
+				return nil
+			}
+
+			b, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			inputs = append(inputs, &input{name: name, src: string(b)})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
+
+// parseInputs reads and parses the queries named by args. On failure the
+// error is reported and the exit code returned.
+func (c *env) parseInputs(args []string, fn func(in *input, stmts []query.Statement) error) int {
+	inputs, err := c.readInputs(args)
+	if err != nil {
+		return c.errorf("%s", err)
+	}
+
+	for _, in := range inputs {
+		stmts, err := in.parse()
+		if err != nil {
+			return c.errorf("%s:%s", in.name, err)
+		}
+		if err := fn(in, stmts); err != nil {
+			return c.errorf("%s: %s", in.name, err)
+		}
+	}
+	return exitOK
+}
+
+func runParse(c *env, args []string) int {
+	fs := c.flagSet("parse")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	return c.parseInputs(fs.Args(), func(in *input, stmts []query.Statement) error {
+		out := struct {
+			File       string            `json:"file"`
+			Statements []json.RawMessage `json:"statements"`
+		}{File: in.name}
+
+		for _, stmt := range stmts {
+			b, err := query.MarshalStatement(stmt)
+			if err != nil {
+				return err
+			}
+			out.Statements = append(out.Statements, b)
+		}
+
+		b, err := json.Marshal(out)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(c.stdout, "%s\n", b)
+		return err
+	})
+}
+
+func runFmt(c *env, args []string) int {
+	fs := c.flagSet("fmt")
+	check := fs.Bool("check", false, "list files whose formatting differs and exit with a non-zero status")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	inputs, err := c.readInputs(fs.Args())
+	if err != nil {
+		return c.errorf("%s", err)
+	}
+
+	code := exitOK
+	for _, in := range inputs {
+		out, err := query.Format(in.src)
+		if err != nil {
+			return c.errorf("%s:%s", in.name, err)
+		}
+
+		switch {
+		case *check:
+			if out != in.src {
+				fmt.Fprintln(c.stdout, in.name)
+				code = exitFailure
+			}
+		case in.name == "-":
+			io.WriteString(c.stdout, out)
+		case out != in.src:
+			if err := os.WriteFile(in.name, []byte(out), 0o644); err != nil {
+				return c.errorf("%s", err)
+			}
+		}
+	}
+	return code
+}
+
+func runTables(c *env, args []string) int {
+	fs := c.flagSet("tables")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	return c.parseInputs(fs.Args(), func(in *input, stmts []query.Statement) error {
+		read, write := lineage.Tables(stmts)
+		for _, name := range read {
+			fmt.Fprintf(c.stdout, "%s\tread\t%s\n", in.name, name)
+		}
+		for _, name := range write {
+			fmt.Fprintf(c.stdout, "%s\twrite\t%s\n", in.name, name)
+		}
+		return nil
+	})
+}
+
+func runLineage(c *env, args []string) int {
+	fs := c.flagSet("lineage")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	inputs, err := c.readInputs(fs.Args())
+	if err != nil {
+		return c.errorf("%s", err)
+	}
+
+	enc := yaml.NewEncoder(c.stdout)
+	defer enc.Close()
+	for _, in := range inputs {
+		t, err := lineage.ParseQuery(in.name, in.src)
+		if err != nil {
+			return c.errorf("%s", err)
+		}
+		if err := enc.Encode(t); err != nil {
+			return c.errorf("%s", err)
+		}
+	}
+	return exitOK
+}
+
+func runLint(c *env, args []string) int {
+	fs := c.flagSet("lint")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	inputs, err := c.readInputs(fs.Args())
+	if err != nil {
+		return c.errorf("%s", err)
+	}
+
+	code := exitOK
+	l := lint.New()
+	for _, in := range inputs {
+		findings, err := l.Lint(in.src)
+		if err != nil {
+			return c.errorf("%s:%s", in.name, err)
+		}
+		for _, f := range findings {
+			fmt.Fprintf(c.stdout, "%s:%s\n", in.name, f)
+			code = exitFailure
+		}
+	}
+	return code
+}
+
+func runValidate(c *env, args []string) int {
+	fs := c.flagSet("validate")
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	inputs, err := c.readInputs(fs.Args())
+	if err != nil {
+		return c.errorf("%s", err)
+	}
+
+	code := exitOK
+	for _, in := range inputs {
+		if _, err := in.parse(); err != nil {
+			fmt.Fprintf(c.stdout, "%s:%s\n", in.name, errorString(err))
+			code = exitFailure
+		}
+	}
+	return code
+}
+
+// errorString returns err prefixed with its line and column, if known.
+func errorString(err error) string {
+	var e *query.Error
+	if errors.As(err, &e) && e.Pos.IsValid() {
+		return fmt.Sprintf("%d:%d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+	}
+	return " " + err.Error()
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("Usage", func(t *testing.T) {
+		code, _, stderr := AssertRun(t, "", "unknown")
+		assert.Equal(t, exitUsage, code)
+		assert.Contains(t, stderr, `unknown command "unknown"`)
+	})
+
+	t.Run("Parse", func(t *testing.T) {
+		code, stdout, _ := AssertRun(t, "SELECT 1; SELECT 2", "parse")
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, 2, strings.Count(stdout, `"@type":"SelectStatement"`))
+		assert.True(t, strings.HasPrefix(stdout, `{"file":"-","statements":[{"version":5,`))
+	})
+
+	t.Run("Fmt", func(t *testing.T) {
+		dir := t.TempDir()
+		path := WriteFile(t, dir, "a.sql", "select a from t")
+		WriteFile(t, dir, "b.txt", "not sql")
+
+		code, stdout, _ := AssertRun(t, "", "fmt", "-check", dir)
+		assert.Equal(t, exitFailure, code)
+		assert.Equal(t, path+"\n", stdout)
+
+		code, _, _ = AssertRun(t, "", "fmt", dir)
+		assert.Equal(t, exitOK, code)
+		b, _ := os.ReadFile(path)
+		assert.Equal(t, "SELECT a FROM t;\n", string(b))
+
+		code, _, _ = AssertRun(t, "", "fmt", "-check", dir)
+		assert.Equal(t, exitOK, code)
+
+		_, stdout, _ = AssertRun(t, "select 1", "fmt")
+		assert.Equal(t, "SELECT 1;\n", stdout)
+	})
+
+	t.Run("Tables", func(t *testing.T) {
+		code, stdout, _ := AssertRun(t, `WITH c AS (SELECT * FROM a) INSERT INTO out SELECT * FROM c JOIN db.b ON c.id = b.id`, "tables")
+		assert.Equal(t, exitOK, code)
+		assert.Equal(t, "-\tread\ta\n-\tread\tdb.b\n-\twrite\tout\n", stdout)
+	})
+
+	t.Run("Lineage", func(t *testing.T) {
+		code, stdout, _ := AssertRun(t, "SELECT a FROM t", "lineage")
+		assert.Equal(t, exitOK, code)
+		assert.Contains(t, stdout, "name: t\n")
+	})
+
+	t.Run("Lint", func(t *testing.T) {
+		code, stdout, _ := AssertRun(t, "DELETE FROM t;\n-- lint:ignore\nDELETE FROM u", "lint")
+		assert.Equal(t, exitFailure, code)
+		assert.Equal(t, "-:1:1: error: DELETE without WHERE removes every row (delete-without-where)\n", stdout)
+
+		code, _, _ = AssertRun(t, "DELETE FROM t WHERE a = 1", "lint")
+		assert.Equal(t, exitOK, code)
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+		code, stdout, _ := AssertRun(t, "SELECT 1;\nSELECT FROM t", "validate")
+		assert.Equal(t, exitFailure, code)
+		assert.Equal(t, "-:2:8: expected expression, found 'FROM'\n", stdout)
+
+		code, stdout, _ = AssertRun(t, "SELECT 1", "validate")
+		assert.Equal(t, exitOK, code)
+		assert.Empty(t, stdout)
+	})
+}
+
+// AssertRun runs the command with stdin and returns its exit code and output.
+func AssertRun(tb testing.TB, stdin string, args ...string) (int, string, string) {
+	tb.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// WriteFile writes s to name in dir and returns its path.
+func WriteFile(tb testing.TB, dir, name, s string) string {
+	tb.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
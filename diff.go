@@ -0,0 +1,309 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// ChangeType describes how a node differs between two trees.
+type ChangeType int
+
+const (
+	Added ChangeType = iota + 1
+	Removed
+	Modified
+)
+
+// String returns the string representation of the change type.
+func (t ChangeType) String() string {
+	switch t {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	default:
+		return fmt.Sprintf("ChangeType(%d)", int(t))
+	}
+}
+
+// Change represents a single difference between two trees.
+type Change struct {
+	Type ChangeType `json:"type"`
+	Path string     `json:"path"` // e.g. "columns[1].expr", blank for the root
+	From Node       `json:"from"` // nil when added
+	To   Node       `json:"to"`   // nil when removed
+}
+
+// String returns the string representation of the change.
+func (c Change) String() string {
+	path := c.Path
+	if path == "" {
+		path = "."
+	}
+
+	switch c.Type {
+	case Added:
+		return fmt.Sprintf("%s %s: %s", c.Type, path, nodeText(c.To))
+	case Removed:
+		return fmt.Sprintf("%s %s: %s", c.Type, path, nodeText(c.From))
+	default:
+		return fmt.Sprintf("%s %s: %s -> %s", c.Type, path, nodeText(c.From), nodeText(c.To))
+	}
+}
+
+func nodeText(n Node) string {
+	return strings.TrimSpace(n.String())
+}
+
+// Diff compares a and b node by node and returns the changes needed to turn
+// a into b. Path segments are taken from the json tags of the fields.
+//
+// Positions are ignored, except for whether a position is set at all: many
+// keywords, such as DISTINCT or LEFT JOIN, are only recorded as a position.
+// A node whose own values differ is reported as modified; its children are
+// compared as well. Lists are aligned so that inserting an element reports a
+// single addition rather than a modification of every following element.
+func Diff(a, b Node) []Change {
+	var d differ
+	d.diff("", valueOf(a), valueOf(b))
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(typ ChangeType, path string, from, to reflect.Value) {
+	d.changes = append(d.changes, Change{Type: typ, Path: path, From: nodeOf(from), To: nodeOf(to)})
+}
+
+// diff compares two node values, each of which may be nil.
+func (d *differ) diff(path string, a, b reflect.Value) {
+	switch {
+	case isNilValue(a) && isNilValue(b):
+		return
+	case isNilValue(a):
+		d.add(Added, path, reflect.Value{}, b)
+		return
+	case isNilValue(b):
+		d.add(Removed, path, a, reflect.Value{})
+		return
+	}
+
+	a, b = elem(a), elem(b)
+	if a.Type() != b.Type() {
+		d.add(Modified, path, a, b)
+		return
+	}
+
+	sa, sb := structOf(a), structOf(b)
+	if !sa.IsValid() {
+		if !positionless.equal(a, b) {
+			d.add(Modified, path, a, b)
+		}
+		return
+	}
+
+	// Report the node itself if any of its own values differ. Positions that
+	// only differ because a child was added or removed, such as the WHERE
+	// keyword, are covered by the change to the child.
+	scalars, posOnly := diffScalars(sa, sb)
+	if scalars && !(posOnly && childPresenceChanged(sa, sb)) {
+		d.add(Modified, path, a, b)
+	}
+
+	d.diffFields(path, sa, sb)
+}
+
+// diffFields compares the children of two structs of the same type.
+func (d *differ) diffFields(path string, a, b reflect.Value) {
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		fa, fb := a.Field(i), b.Field(i)
+
+		switch {
+		case field.Anonymous:
+			d.diff(path, fa, fb)
+		case fa.Kind() == reflect.Slice:
+			d.diffList(joinPath(path, fieldName(field)), fa, fb)
+		case isChild(fa):
+			d.diff(joinPath(path, fieldName(field)), fa, fb)
+		}
+	}
+}
+
+// diffList aligns two lists on their longest common subsequence. Unmatched
+// runs are paired up as modifications; the remainder is added or removed.
+func (d *differ) diffList(path string, a, b reflect.Value) {
+	pairs := lcs(a, b)
+	pairs = append(pairs, [2]int{a.Len(), b.Len()})
+
+	var i, j int
+	for _, pair := range pairs {
+		for ; i < pair[0] && j < pair[1]; i, j = i+1, j+1 {
+			d.diff(fmt.Sprintf("%s[%d]", path, j), a.Index(i), b.Index(j))
+		}
+		for ; i < pair[0]; i++ {
+			d.add(Removed, fmt.Sprintf("%s[%d]", path, i), a.Index(i), reflect.Value{})
+		}
+		for ; j < pair[1]; j++ {
+			d.add(Added, fmt.Sprintf("%s[%d]", path, j), reflect.Value{}, b.Index(j))
+		}
+		i, j = pair[0]+1, pair[1]+1
+	}
+}
+
+// lcs returns the index pairs of the longest common subsequence of a and b.
+func lcs(a, b reflect.Value) [][2]int {
+	n, m := a.Len(), b.Len()
+	table := make([][]int, n+1)
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if positionless.equal(a.Index(i), b.Index(j)) {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case positionless.equal(a.Index(i), b.Index(j)):
+			pairs = append(pairs, [2]int{i, j})
+			i, j = i+1, j+1
+		case table[i+1][j] >= table[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return pairs
+}
+
+// diffScalars reports whether the non-child fields of a and b differ and, if
+// so, whether the difference is limited to positions being set or unset.
+func diffScalars(a, b reflect.Value) (changed, posOnly bool) {
+	posOnly = true
+	for i := 0; i < a.NumField(); i++ {
+		fa, fb := a.Field(i), b.Field(i)
+		if a.Type().Field(i).Anonymous || fa.Kind() == reflect.Slice || isChild(fa) {
+			continue
+		}
+
+		if fa.Type() == posType {
+			if fa.Interface().(Pos).IsValid() != fb.Interface().(Pos).IsValid() {
+				changed = true
+			}
+		} else if !positionless.equal(fa, fb) {
+			changed, posOnly = true, false
+		}
+	}
+	return changed, changed && posOnly
+}
+
+// childPresenceChanged returns true if a child exists in only one of a and b.
+func childPresenceChanged(a, b reflect.Value) bool {
+	for i := 0; i < a.NumField(); i++ {
+		fa, fb := a.Field(i), b.Field(i)
+		switch {
+		case fa.Kind() == reflect.Slice:
+			if (fa.Len() == 0) != (fb.Len() == 0) {
+				return true
+			}
+		case isChild(fa):
+			if isNilValue(fa) != isNilValue(fb) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// valueOf returns the reflected value of n, or the zero Value if n is nil.
+func valueOf(n Node) reflect.Value {
+	if n == nil {
+		return reflect.Value{}
+	}
+	return reflect.ValueOf(n)
+}
+
+// nodeOf returns the node held by v, or nil.
+func nodeOf(v reflect.Value) Node {
+	if isNilValue(v) {
+		return nil
+	}
+	n, _ := v.Interface().(Node)
+	return n
+}
+
+// elem unwraps interface values.
+func elem(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+	return v
+}
+
+// structOf returns the struct v points to, or the zero Value.
+func structOf(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	return v
+}
+
+// isChild returns true if v holds a child node rather than a plain value.
+func isChild(v reflect.Value) bool {
+	return v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface
+}
+
+func isNilValue(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// fieldName returns the json name of a field, falling back to the snake-cased
+// field name for fields without a tag.
+func fieldName(field reflect.StructField) string {
+	if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" {
+		return tag
+	}
+
+	var buf strings.Builder
+	for i, r := range field.Name {
+		if unicode.IsUpper(r) {
+			if i != 0 {
+				buf.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
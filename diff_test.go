@@ -0,0 +1,73 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+)
+
+func TestDiff(t *testing.T) {
+	t.Run("Equal", func(t *testing.T) {
+		AssertDiff(t, `SELECT a, b FROM t WHERE c = 1`, "SELECT a,\n  b\nFROM t\nWHERE c = 1")
+	})
+
+	t.Run("Columns", func(t *testing.T) {
+		AssertDiff(t, `SELECT a, b FROM t`, `SELECT a, x, b FROM t`,
+			`added columns[1]: x`)
+		AssertDiff(t, `SELECT a, x, b FROM t`, `SELECT a, b FROM t`,
+			`removed columns[1]: x`)
+		AssertDiff(t, `SELECT a, b FROM t`, `SELECT a, c FROM t`,
+			`modified columns[1].expr.name: b -> c`)
+		AssertDiff(t, `SELECT a FROM t`, `SELECT a AS z FROM t`,
+			`added columns[0].alias: z`)
+	})
+
+	t.Run("Join", func(t *testing.T) {
+		AssertDiff(t, `SELECT * FROM t LEFT JOIN u ON t.id = u.id`, `SELECT * FROM t INNER JOIN u ON t.id = u.id`,
+			`modified source.operator: LEFT JOIN -> INNER JOIN`)
+	})
+
+	t.Run("Where", func(t *testing.T) {
+		AssertDiff(t, `SELECT * FROM t WHERE a = 1`, `SELECT * FROM t WHERE a = 2`,
+			`modified where_expr.y: 1 -> 2`)
+		AssertDiff(t, `SELECT * FROM t WHERE a = 1`, `SELECT * FROM t WHERE a > 1`,
+			`modified where_expr: a = 1 -> a > 1`)
+		AssertDiff(t, `SELECT * FROM t`, `SELECT * FROM t WHERE a = 1`,
+			`added where_expr: a = 1`)
+		AssertDiff(t, `SELECT * FROM t WHERE a = 1`, `SELECT * FROM t WHERE b IS NULL`,
+			`modified where_expr: a = 1 -> b IS NULL`)
+	})
+
+	t.Run("Statement", func(t *testing.T) {
+		AssertDiff(t, `SELECT a FROM t`, `SELECT DISTINCT a FROM t`,
+			`modified .: SELECT a FROM t -> SELECT DISTINCT a FROM t`)
+		AssertDiff(t, `SELECT a FROM t`, `DELETE FROM t`,
+			`modified .: SELECT a FROM t -> DELETE FROM t`)
+		AssertDiff(t, `INSERT INTO t SELECT a FROM s`, `INSERT INTO t SELECT a FROM s2`,
+			`modified select.source.name.name: s -> s2`)
+	})
+
+	t.Run("UntaggedFields", func(t *testing.T) {
+		AssertDiff(t, `SELECT a FROM t WHERE EXISTS (SELECT 1 FROM u)`, `SELECT a FROM t WHERE EXISTS (SELECT 2 FROM u)`,
+			`modified where_expr.select.columns[0].expr: 1 -> 2`)
+	})
+
+	t.Run("Nil", func(t *testing.T) {
+		assert.Nil(t, query.Diff(nil, nil))
+		assert.Equal(t, []query.Change{{Type: query.Added, To: query.MustParseExprString(`a`)}},
+			query.Diff(nil, query.MustParseExprString(`a`)))
+	})
+}
+
+// AssertDiff asserts that diffing a with b returns the given changes.
+func AssertDiff(tb testing.TB, a, b string, want ...string) {
+	tb.Helper()
+
+	var got []string
+	for _, change := range query.Diff(ParseStatement(tb, a), ParseStatement(tb, b)) {
+		got = append(got, change.String())
+	}
+	assert.Equal(tb, want, got)
+}
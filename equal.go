@@ -0,0 +1,103 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+)
+
+// EqualOption configures how Equal compares two trees.
+type EqualOption func(*equalizer)
+
+// IgnorePositions compares positions only by whether they are set. Many
+// keywords, such as DISTINCT or LEFT JOIN, are recorded solely as a position,
+// so their presence is still significant.
+func IgnorePositions() EqualOption {
+	return func(e *equalizer) { e.ignorePos = true }
+}
+
+// IgnoreAllPositions skips positions entirely, so expected trees can leave
+// them unset. Keywords recorded solely as a position are then not compared
+// either; use IgnorePositions where their presence matters.
+func IgnoreAllPositions() EqualOption {
+	return func(e *equalizer) { e.ignoreAllPos = true }
+}
+
+// IgnoreIdentCase compares identifier names case-insensitively.
+func IgnoreIdentCase() EqualOption {
+	return func(e *equalizer) { e.ignoreIdentCase = true }
+}
+
+// Equal returns true if a and b are deeply equal. Every field is compared,
+// including positions, unless relaxed by opts. Nil and empty lists are equal.
+func Equal(a, b Node, opts ...EqualOption) bool {
+	var e equalizer
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e.equal(valueOf(a), valueOf(b))
+}
+
+type equalizer struct {
+	ignorePos       bool
+	ignoreAllPos    bool
+	ignoreIdentCase bool
+}
+
+// positionless is used to match nodes that print the same text.
+var positionless = equalizer{ignorePos: true}
+
+func (e equalizer) equal(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Slice && b.Kind() == reflect.Slice {
+		if a.Len() != b.Len() {
+			return false
+		}
+		for i := 0; i < a.Len(); i++ {
+			if !e.equal(a.Index(i), b.Index(i)) {
+				return false
+			}
+		}
+		return true
+	} else if isNilValue(a) || isNilValue(b) {
+		return isNilValue(a) == isNilValue(b)
+	}
+
+	a, b = elem(a), elem(b)
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a.Kind() {
+	case reflect.Ptr:
+		return e.equal(a.Elem(), b.Elem())
+	case reflect.Struct:
+		switch a.Type() {
+		case posType:
+			if e.ignoreAllPos {
+				return true
+			} else if e.ignorePos {
+				return a.Interface().(Pos).IsValid() == b.Interface().(Pos).IsValid()
+			}
+			return a.Interface() == b.Interface()
+		case identType:
+			if e.ignoreIdentCase {
+				x, y := a.Interface().(Ident), b.Interface().(Ident)
+				return x.Tok == y.Tok && strings.EqualFold(x.Name, y.Name) &&
+					e.equal(a.FieldByName("NamePos"), b.FieldByName("NamePos"))
+			}
+		}
+
+		for i := 0; i < a.NumField(); i++ {
+			if !e.equal(a.Field(i), b.Field(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a.Interface() == b.Interface()
+	}
+}
+
+var (
+	posType   = reflect.TypeOf(Pos{})
+	identType = reflect.TypeOf(Ident{})
+)
@@ -0,0 +1,94 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+)
+
+func TestEqual(t *testing.T) {
+	t.Run("Exact", func(t *testing.T) {
+		assert.True(t, query.Equal(ParseStatement(t, `SELECT a FROM t`), ParseStatement(t, `SELECT a FROM t`)))
+		assert.False(t, query.Equal(ParseStatement(t, `SELECT a FROM t`), ParseStatement(t, `SELECT  a FROM t`)))
+		assert.False(t, query.Equal(ParseStatement(t, `SELECT a FROM t`), ParseStatement(t, `SELECT b FROM t`)))
+		assert.True(t, query.Equal(nil, nil))
+		assert.False(t, query.Equal(nil, query.MustParseExprString(`a`)))
+	})
+
+	t.Run("IgnorePositions", func(t *testing.T) {
+		AssertEqual(t, `SELECT a FROM t WHERE b = 1`, "SELECT a\nFROM t\nWHERE b = 1", true, query.IgnorePositions())
+		AssertEqual(t, `SELECT a FROM t LEFT JOIN u`, `SELECT a FROM t INNER JOIN u`, false, query.IgnorePositions())
+		AssertEqual(t, `SELECT a FROM t`, `SELECT DISTINCT a FROM t`, false, query.IgnorePositions())
+		AssertEqual(t, `SELECT A FROM t`, `SELECT a FROM t`, false, query.IgnorePositions())
+	})
+
+	t.Run("IgnoreIdentCase", func(t *testing.T) {
+		AssertEqual(t, `SELECT A FROM T`, `select a from t`, true, query.IgnoreIdentCase())
+		AssertEqual(t, `SELECT A FROM T`, `select  a from t`, false, query.IgnoreIdentCase())
+		AssertEqual(t, `SELECT "A" FROM t`, `SELECT a FROM t`, false, query.IgnoreIdentCase())
+		AssertEqual(t, `SELECT 'A' FROM t`, `SELECT 'a' FROM t`, false, query.IgnoreIdentCase())
+		AssertEqual(t, "SELECT Max(A)\nFROM t", `SELECT max(a) FROM t`, true, query.IgnoreIdentCase(), query.IgnorePositions())
+	})
+
+	t.Run("Expected", func(t *testing.T) {
+		// Positions only need to be set, not exact.
+		valid := query.Pos{Line: 1}
+		assert.True(t, query.Equal(ParseStatement(t, `SELECT a FROM t`), &query.SelectStatement{
+			Select:  valid,
+			Columns: []*query.ResultColumn{{Expr: &query.MultiPartIdent{Name: &query.Ident{NamePos: valid, Name: "a", Tok: query.IDENT}}}},
+			From:    valid,
+			Source:  &query.QualifiedTableName{Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: valid, Name: "t", Tok: query.IDENT}}},
+		}, query.IgnorePositions()))
+
+		// Or left out entirely.
+		assert.True(t, query.Equal(ParseStatement(t, `SELECT a FROM t`), &query.SelectStatement{
+			Columns: []*query.ResultColumn{{Expr: &query.MultiPartIdent{Name: &query.Ident{Name: "a", Tok: query.IDENT}}}},
+			Source:  &query.QualifiedTableName{Name: &query.MultiPartIdent{Name: &query.Ident{Name: "t", Tok: query.IDENT}}},
+		}, query.IgnoreAllPositions()))
+	})
+
+	t.Run("IgnoreAllPositions", func(t *testing.T) {
+		AssertEqual(t, `SELECT a FROM t WHERE b = 1`, "SELECT a\nFROM t\nWHERE b = 1", true, query.IgnoreAllPositions())
+		AssertEqual(t, `SELECT a FROM t`, `SELECT DISTINCT a FROM t`, true, query.IgnoreAllPositions())
+		AssertEqual(t, `SELECT a FROM t`, `SELECT b FROM t`, false, query.IgnoreAllPositions())
+	})
+}
+
+func TestClone(t *testing.T) {
+	for _, s := range []string{
+		`WITH c (x) AS (SELECT x FROM s) SELECT DISTINCT a, f(b) OVER (PARTITION BY c ORDER BY d) FROM t LEFT JOIN c ON t.id = c.id WHERE d IN (1, 2) GROUP BY a HAVING count(*) > 1 ORDER BY a LIMIT 10`,
+		`SELECT a FROM t LATERAL VIEW explode(arr) v AS x WHERE EXISTS (SELECT 1 FROM u) AND CASE WHEN a THEN 1 ELSE CAST(b AS DECIMAL(10,2)) END`,
+		`INSERT OVERWRITE TABLE t (a, b) SELECT a, b FROM s UNION ALL SELECT a, b FROM u`,
+		`DELETE FROM t WHERE a = 1`,
+		`CREATE TABLE t (a BIGINT, b STRING)`,
+		`MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN UPDATE SET t.v = s.v WHEN NOT MATCHED THEN INSERT (id) VALUES (s.id)`,
+		`FUNCTION f (@n BIGINT) AS @n + 1`,
+		`@start_date := TO_DATE('2024-01-01');`,
+	} {
+		stmt := ParseStatement(t, s)
+		other := query.Clone(stmt)
+		assert.Equal(t, stmt, other)
+		assert.True(t, query.Equal(stmt, other))
+
+		// Renaming every identifier in the copy leaves the original untouched.
+		query.Inspect(other, func(n query.Node) bool {
+			if ident, ok := n.(*query.Ident); ok {
+				ident.Name += "_copy"
+			}
+			return true
+		})
+		assert.Equal(t, ParseStatement(t, s), stmt)
+		assert.False(t, query.Equal(stmt, other))
+	}
+
+	assert.Nil(t, query.Clone(nil))
+	assert.Equal(t, query.SelectExpr{}, query.Clone(query.SelectExpr{}))
+}
+
+// AssertEqual asserts that comparing the statements a and b with opts returns want.
+func AssertEqual(tb testing.TB, a, b string, want bool, opts ...query.EqualOption) {
+	tb.Helper()
+	assert.Equal(tb, want, query.Equal(ParseStatement(tb, a), ParseStatement(tb, b), opts...))
+}
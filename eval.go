@@ -0,0 +1,1002 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Value is the result of evaluating an expression with Eval.
+// It is one of nil (NULL), bool, int64, float64, string, time.Time or Interval.
+type Value interface{}
+
+// Interval is the value of an INTERVAL literal, e.g. INTERVAL 7 DAY.
+type Interval struct {
+	Value int64
+	Unit  string
+}
+
+// String returns the string representation of the interval.
+func (i Interval) String() string {
+	return fmt.Sprintf("INTERVAL %d %s", i.Value, i.Unit)
+}
+
+// Env resolves identifiers and bind parameters referenced by an expression.
+type Env interface {
+	// Lookup returns the value bound to name and whether it was found.
+	Lookup(name string) (Value, bool)
+}
+
+// MapEnv is an Env backed by a map. Names are matched exactly first
+// and then case-insensitively. A name that matches several keys differing
+// only by case, and none exactly, is ambiguous and not found.
+type MapEnv map[string]Value
+
+// Lookup returns the value bound to name.
+func (m MapEnv) Lookup(name string) (Value, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	var found Value
+	n := 0
+	for k, v := range m {
+		if strings.EqualFold(k, name) {
+			found = v
+			n++
+		}
+	}
+	return found, n == 1
+}
+
+// Func is a pure function that can be called from an evaluated expression.
+// NULL arguments are passed as nil.
+type Func func(args []Value) (Value, error)
+
+var (
+	funcsMu sync.RWMutex
+	funcs   = make(map[string]Func)
+)
+
+// RegisterFunc makes fn available to Eval under name. Names are case-insensitive.
+// Registering an existing name replaces the previous function.
+func RegisterFunc(name string, fn Func) {
+	funcsMu.Lock()
+	defer funcsMu.Unlock()
+	funcs[strings.ToUpper(name)] = fn
+}
+
+// UnregisterFunc removes the function registered under name, if any.
+func UnregisterFunc(name string) {
+	funcsMu.Lock()
+	defer funcsMu.Unlock()
+	delete(funcs, strings.ToUpper(name))
+}
+
+// LookupFunc returns the function registered under name.
+func LookupFunc(name string) (Func, bool) {
+	funcsMu.RLock()
+	defer funcsMu.RUnlock()
+	fn, ok := funcs[strings.ToUpper(name)]
+	return fn, ok
+}
+
+// Eval evaluates expr without access to any data. Identifiers and bind
+// parameters are resolved through env, which may be nil.
+//
+// Only the subset of expressions that can be computed from constants is
+// supported: literals, arithmetic, bitwise and comparison operators, AND/OR/NOT,
+// CASE, CAST, BETWEEN, IN lists, NULL checks, string concatenation and calls
+// to registered functions. NULL is handled with SQL three-valued logic.
+func Eval(expr Expr, env Env) (Value, error) {
+	e := evaluator{env: env}
+	return e.eval(expr)
+}
+
+type evaluator struct {
+	env Env
+}
+
+func (e *evaluator) eval(expr Expr) (Value, error) {
+	switch expr := expr.(type) {
+	case nil:
+		return nil, &Error{Msg: "missing expression"}
+	case *NullLit:
+		return nil, nil
+	case *BoolLit:
+		return expr.Value, nil
+	case *NumberLit:
+		v, err := parseNumber(expr.Value)
+		if err != nil {
+			return nil, &Error{Pos: expr.ValuePos, Msg: err.Error()}
+		}
+		return v, nil
+	case *StringLit:
+		return expr.Value, nil
+	case *RawLit:
+		return expr.Value, nil
+	case *TimestampLit:
+		return expr.Value, nil
+	case *IntervalLit:
+		n, err := strconv.ParseInt(expr.Value, 10, 64)
+		if err != nil {
+			return nil, &Error{Pos: expr.Interval, Msg: "invalid interval " + expr.Value}
+		}
+		return Interval{Value: n, Unit: strings.ToUpper(expr.Unit)}, nil
+	case *ParenExpr:
+		return e.eval(expr.X)
+	case *Ident:
+		return e.evalIdent(expr)
+	case *MultiPartIdent:
+		return e.evalMultiPartIdent(expr)
+	case *UnaryExpr:
+		return e.evalUnaryExpr(expr)
+	case *BinaryExpr:
+		return e.evalBinaryExpr(expr)
+	case *Null:
+		x, err := e.eval(expr.X)
+		if err != nil {
+			return nil, err
+		}
+		if expr.Op == ISNULL {
+			return x == nil, nil
+		}
+		return x != nil, nil
+	case *CaseExpr:
+		return e.evalCaseExpr(expr)
+	case *CastExpr:
+		x, err := e.eval(expr.X)
+		if err != nil {
+			return nil, err
+		}
+		v, err := castValue(x, expr.Type)
+		if err != nil {
+			return nil, &Error{Pos: expr.Cast, Msg: err.Error()}
+		}
+		return v, nil
+	case *Call:
+		return e.evalCall(expr)
+	default:
+		return nil, &Error{Msg: "cannot evaluate " + expr.String() + " without data"}
+	}
+}
+
+func (e *evaluator) evalIdent(ident *Ident) (Value, error) {
+	switch ident.Tok {
+	case DATE, TIMESTAMP:
+		// Typed literals are parsed as identifiers, e.g. DATE '2024-01-01'.
+		if i := strings.IndexByte(ident.Name, '\''); i > 0 {
+			s := strings.Trim(ident.Name[i:], "'")
+			t, _, err := parseTime(s)
+			if err != nil {
+				return nil, &Error{Pos: ident.NamePos, Msg: err.Error()}
+			}
+			return t, nil
+		}
+	}
+
+	if e.env != nil {
+		if v, ok := e.env.Lookup(ident.Name); ok {
+			return v, nil
+		}
+	}
+	return nil, &Error{Pos: ident.NamePos, Msg: "unbound identifier " + ident.String()}
+}
+
+func (e *evaluator) evalMultiPartIdent(m *MultiPartIdent) (Value, error) {
+	if m.First == nil {
+		return e.evalIdent(m.Name)
+	}
+
+	if e.env != nil {
+		var parts []string
+		for _, ident := range []*Ident{m.First, m.Second, m.Third, m.Name} {
+			if ident != nil {
+				parts = append(parts, ident.Name)
+			}
+		}
+		if v, ok := e.env.Lookup(strings.Join(parts, ".")); ok {
+			return v, nil
+		}
+		if v, ok := e.env.Lookup(m.Name.Name); ok {
+			return v, nil
+		}
+	}
+	return nil, &Error{Pos: m.First.NamePos, Msg: "unbound identifier " + m.String()}
+}
+
+func (e *evaluator) evalUnaryExpr(expr *UnaryExpr) (Value, error) {
+	x, err := e.eval(expr.X)
+	if err != nil {
+		return nil, err
+	}
+	if x == nil {
+		return nil, nil
+	}
+
+	switch expr.Op {
+	case PLUS:
+		if _, ok := toNumber(x); !ok {
+			return nil, &Error{Pos: expr.OpPos, Msg: "expected number, found " + typeName(x)}
+		}
+		return x, nil
+	case MINUS:
+		switch n, _ := toNumber(x); n := n.(type) {
+		case int64:
+			if n == math.MinInt64 {
+				return nil, &Error{Pos: expr.OpPos, Msg: "integer overflow"}
+			}
+			return -n, nil
+		case float64:
+			return -n, nil
+		}
+		return nil, &Error{Pos: expr.OpPos, Msg: "expected number, found " + typeName(x)}
+	case NOT:
+		b, ok := x.(bool)
+		if !ok {
+			return nil, &Error{Pos: expr.OpPos, Msg: "expected boolean, found " + typeName(x)}
+		}
+		return !b, nil
+	case BITNOT:
+		n, ok := x.(int64)
+		if !ok {
+			return nil, &Error{Pos: expr.OpPos, Msg: "expected integer, found " + typeName(x)}
+		}
+		return ^n, nil
+	default:
+		return nil, &Error{Pos: expr.OpPos, Msg: "unsupported operator " + expr.Op.String()}
+	}
+}
+
+func (e *evaluator) evalBinaryExpr(expr *BinaryExpr) (Value, error) {
+	switch expr.Op {
+	case AND, OR:
+		return e.evalLogical(expr)
+	case IN, NOTIN:
+		return e.evalIn(expr)
+	case BETWEEN, NOTBETWEEN:
+		return e.evalBetween(expr)
+	}
+
+	x, err := e.eval(expr.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := e.eval(expr.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	var v Value
+	switch expr.Op {
+	case EQN, IS, ISNOT:
+		// Null-safe equality never returns NULL.
+		if x == nil || y == nil {
+			v = x == nil && y == nil
+		} else {
+			var c int
+			if c, err = compareValues(x, y); err == nil {
+				v = c == 0
+			}
+		}
+		if err == nil && expr.Op == ISNOT {
+			v = !v.(bool)
+		}
+	case EQ, NE, LT, LE, GT, GE:
+		if x == nil || y == nil {
+			return nil, nil
+		}
+		var c int
+		if c, err = compareValues(x, y); err == nil {
+			v = compareResult(expr.Op, c)
+		}
+	case LIKE, NOTLIKE, REGEXP, NOTREGEXP, RLIKE:
+		v, err = matchValues(expr.Op, x, y)
+	case CONCAT:
+		if x == nil || y == nil {
+			return nil, nil
+		}
+		v = valueString(x) + valueString(y)
+	case PLUS, MINUS, STAR, SLASH, REM:
+		v, err = arithmetic(expr.Op, x, y)
+	case BITAND, BITOR, LSHIFT, RSHIFT:
+		v, err = bitwise(expr.Op, x, y)
+	default:
+		err = fmt.Errorf("unsupported operator %s", expr.Op)
+	}
+	if err != nil {
+		return nil, &Error{Pos: expr.OpPos, Msg: err.Error()}
+	}
+	return v, nil
+}
+
+// evalLogical evaluates AND & OR using three-valued logic.
+func (e *evaluator) evalLogical(expr *BinaryExpr) (Value, error) {
+	x, err := e.evalBool(expr.X, expr.OpPos)
+	if err != nil {
+		return nil, err
+	}
+	y, err := e.evalBool(expr.Y, expr.OpPos)
+	if err != nil {
+		return nil, err
+	}
+
+	// The dominant value decides the result regardless of NULL.
+	dominant := expr.Op == OR
+	if (x != nil && x.(bool) == dominant) || (y != nil && y.(bool) == dominant) {
+		return dominant, nil
+	}
+	if x == nil || y == nil {
+		return nil, nil
+	}
+	return !dominant, nil
+}
+
+func (e *evaluator) evalBool(expr Expr, pos Pos) (Value, error) {
+	v, err := e.eval(expr)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	if _, ok := v.(bool); !ok {
+		return nil, &Error{Pos: pos, Msg: "expected boolean, found " + typeName(v)}
+	}
+	return v, nil
+}
+
+func (e *evaluator) evalIn(expr *BinaryExpr) (Value, error) {
+	var items []Expr
+	switch y := expr.Y.(type) {
+	case *ExprList:
+		items = y.Exprs
+	case *ParenExpr:
+		items = []Expr{y.X}
+	default:
+		return nil, &Error{Pos: expr.OpPos, Msg: "cannot evaluate IN without a value list"}
+	}
+
+	x, err := e.eval(expr.X)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Value = false
+	for _, item := range items {
+		v, err := e.eval(item)
+		if err != nil {
+			return nil, err
+		}
+		if x == nil || v == nil {
+			result = nil
+			continue
+		}
+		c, err := compareValues(x, v)
+		if err != nil {
+			return nil, &Error{Pos: expr.OpPos, Msg: err.Error()}
+		}
+		if c == 0 {
+			result = true
+			break
+		}
+	}
+
+	if expr.Op == NOTIN && result != nil {
+		return !result.(bool), nil
+	}
+	return result, nil
+}
+
+func (e *evaluator) evalBetween(expr *BinaryExpr) (Value, error) {
+	rng, ok := expr.Y.(*Range)
+	if !ok {
+		return nil, &Error{Pos: expr.OpPos, Msg: "expected range for BETWEEN"}
+	}
+
+	x, err := e.eval(expr.X)
+	if err != nil {
+		return nil, err
+	}
+	lo, err := e.eval(rng.X)
+	if err != nil {
+		return nil, err
+	}
+	hi, err := e.eval(rng.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	// x BETWEEN lo AND hi is equivalent to x >= lo AND x <= hi.
+	bound := func(y Value, op Token) (Value, error) {
+		if x == nil || y == nil {
+			return nil, nil
+		}
+		c, err := compareValues(x, y)
+		if err != nil {
+			return nil, &Error{Pos: expr.OpPos, Msg: err.Error()}
+		}
+		return compareResult(op, c), nil
+	}
+	gte, err := bound(lo, GE)
+	if err != nil {
+		return nil, err
+	}
+	lte, err := bound(hi, LE)
+	if err != nil {
+		return nil, err
+	}
+
+	var v Value
+	switch {
+	case gte == false || lte == false:
+		v = false
+	case gte == nil || lte == nil:
+		return nil, nil
+	default:
+		v = true
+	}
+	if expr.Op == NOTBETWEEN {
+		return !v.(bool), nil
+	}
+	return v, nil
+}
+
+func (e *evaluator) evalCaseExpr(expr *CaseExpr) (Value, error) {
+	var operand Value
+	if expr.Operand != nil {
+		v, err := e.eval(expr.Operand)
+		if err != nil {
+			return nil, err
+		}
+		operand = v
+	}
+
+	for _, blk := range expr.Blocks {
+		cond, err := e.eval(blk.Condition)
+		if err != nil {
+			return nil, err
+		}
+
+		matched := false
+		if expr.Operand != nil {
+			// NULL never matches, not even another NULL.
+			if operand != nil && cond != nil {
+				c, err := compareValues(operand, cond)
+				if err != nil {
+					return nil, &Error{Pos: blk.When, Msg: err.Error()}
+				}
+				matched = c == 0
+			}
+		} else if cond != nil {
+			b, ok := cond.(bool)
+			if !ok {
+				return nil, &Error{Pos: blk.When, Msg: "expected boolean, found " + typeName(cond)}
+			}
+			matched = b
+		}
+
+		if matched {
+			return e.eval(blk.Body)
+		}
+	}
+
+	if expr.ElseExpr != nil {
+		return e.eval(expr.ElseExpr)
+	}
+	return nil, nil
+}
+
+func (e *evaluator) evalCall(c *Call) (Value, error) {
+	pos := c.Lparen
+	if c.Name != nil && c.Name.Name != nil {
+		pos = c.Name.Name.NamePos
+	}
+
+	switch {
+	case c.Over != nil:
+		return nil, &Error{Pos: pos, Msg: "cannot evaluate window function " + c.Name.String()}
+	case c.Star.IsValid(), c.Distinct.IsValid():
+		return nil, &Error{Pos: pos, Msg: "cannot evaluate aggregate " + c.String()}
+	}
+
+	fn, ok := LookupFunc(MIdentName(c.Name))
+	if !ok {
+		return nil, &Error{Pos: pos, Msg: "unknown function " + MIdentName(c.Name)}
+	}
+
+	args := make([]Value, len(c.Args))
+	for i, arg := range c.Args {
+		v, err := e.eval(arg.X)
+		if err != nil {
+			return nil, err
+		}
+		if arg.Type != nil {
+			if v, err = castValue(v, arg.Type); err != nil {
+				return nil, &Error{Pos: arg.As, Msg: err.Error()}
+			}
+		}
+		args[i] = v
+	}
+
+	v, err := fn(args)
+	if err != nil {
+		return nil, &Error{Pos: pos, Msg: MIdentName(c.Name) + ": " + err.Error()}
+	}
+	return v, nil
+}
+
+func parseNumber(s string) (Value, error) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		n, err := strconv.ParseInt(s[2:], 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s", s)
+		}
+		return n, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %s", s)
+	}
+	return f, nil
+}
+
+// toNumber converts v to an int64 or float64. Strings are converted
+// implicitly when they hold a number.
+func toNumber(v Value) (Value, bool) {
+	switch v := v.(type) {
+	case int64, float64:
+		return v, true
+	case string:
+		n, err := parseNumber(strings.TrimSpace(v))
+		return n, err == nil
+	default:
+		return nil, false
+	}
+}
+
+func toFloat(v Value) float64 {
+	if n, ok := v.(int64); ok {
+		return float64(n)
+	}
+	return v.(float64)
+}
+
+// errIntegerOverflow is returned when integer arithmetic leaves the range
+// of int64.
+var errIntegerOverflow = errors.New("integer overflow")
+
+func arithmetic(op Token, x, y Value) (Value, error) {
+	if x == nil || y == nil {
+		return nil, nil
+	}
+
+	// Date arithmetic with intervals.
+	if t, ok := x.(time.Time); ok {
+		if i, ok := y.(Interval); ok && (op == PLUS || op == MINUS) {
+			if op == MINUS {
+				i.Value = -i.Value
+			}
+			return addInterval(t, i)
+		}
+		if n, ok := y.(int64); ok && (op == PLUS || op == MINUS) {
+			if op == MINUS {
+				n = -n
+			}
+			return t.AddDate(0, 0, int(n)), nil
+		}
+	}
+
+	a, ok := toNumber(x)
+	if !ok {
+		return nil, fmt.Errorf("expected number, found %s", typeName(x))
+	}
+	b, ok := toNumber(y)
+	if !ok {
+		return nil, fmt.Errorf("expected number, found %s", typeName(y))
+	}
+
+	ai, aInt := a.(int64)
+	bi, bInt := b.(int64)
+	if aInt && bInt {
+		switch op {
+		case PLUS:
+			v := ai + bi
+			if (bi > 0 && v < ai) || (bi < 0 && v > ai) {
+				return nil, errIntegerOverflow
+			}
+			return v, nil
+		case MINUS:
+			v := ai - bi
+			if (bi > 0 && v > ai) || (bi < 0 && v < ai) {
+				return nil, errIntegerOverflow
+			}
+			return v, nil
+		case STAR:
+			v := ai * bi
+			if ai != 0 && (v/ai != bi || (ai == -1 && bi == math.MinInt64)) {
+				return nil, errIntegerOverflow
+			}
+			return v, nil
+		case REM:
+			if bi == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return ai % bi, nil
+		}
+	}
+
+	af, bf := toFloat(a), toFloat(b)
+	switch op {
+	case PLUS:
+		return af + bf, nil
+	case MINUS:
+		return af - bf, nil
+	case STAR:
+		return af * bf, nil
+	case SLASH:
+		if bf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return af / bf, nil
+	case REM:
+		if bf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return math.Mod(af, bf), nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", op)
+}
+
+func bitwise(op Token, x, y Value) (Value, error) {
+	if x == nil || y == nil {
+		return nil, nil
+	}
+	a, ok := x.(int64)
+	if !ok {
+		return nil, fmt.Errorf("expected integer, found %s", typeName(x))
+	}
+	b, ok := y.(int64)
+	if !ok {
+		return nil, fmt.Errorf("expected integer, found %s", typeName(y))
+	}
+
+	switch op {
+	case BITAND:
+		return a & b, nil
+	case BITOR:
+		return a | b, nil
+	}
+
+	if b < 0 {
+		return nil, fmt.Errorf("negative shift count %d", b)
+	}
+	if op == LSHIFT {
+		v := a << uint64(b)
+		if v>>uint64(b) != a {
+			return nil, errIntegerOverflow
+		}
+		return v, nil
+	}
+	return a >> uint64(b), nil
+}
+
+func compareResult(op Token, c int) bool {
+	switch op {
+	case EQ:
+		return c == 0
+	case NE:
+		return c != 0
+	case LT:
+		return c < 0
+	case LE:
+		return c <= 0
+	case GT:
+		return c > 0
+	default:
+		return c >= 0
+	}
+}
+
+// compareValues compares two non-NULL values, converting strings to
+// numbers or times when compared against those types.
+func compareValues(x, y Value) (int, error) {
+	switch a := x.(type) {
+	case bool:
+		b, ok := y.(bool)
+		if !ok {
+			break
+		}
+		switch {
+		case a == b:
+			return 0, nil
+		case !a:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+	case string:
+		switch b := y.(type) {
+		case string:
+			return strings.Compare(a, b), nil
+		case int64, float64, time.Time:
+			c, err := compareValues(y, x)
+			return -c, err
+		}
+	case int64, float64:
+		b, ok := toNumber(y)
+		if !ok {
+			break
+		}
+		if ai, ok := a.(int64); ok {
+			if bi, ok := b.(int64); ok {
+				return compareInts(ai, bi), nil
+			}
+		}
+		af, bf := toFloat(a), toFloat(b)
+		switch {
+		case af < bf:
+			return -1, nil
+		case af > bf:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+	case time.Time:
+		var b time.Time
+		switch y := y.(type) {
+		case time.Time:
+			b = y
+		case string:
+			t, _, err := parseTime(y)
+			if err != nil {
+				return 0, err
+			}
+			b = t
+		default:
+			return 0, fmt.Errorf("cannot compare %s with %s", typeName(x), typeName(y))
+		}
+		return a.Compare(b), nil
+	}
+	return 0, fmt.Errorf("cannot compare %s with %s", typeName(x), typeName(y))
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func matchValues(op Token, x, y Value) (Value, error) {
+	if x == nil || y == nil {
+		return nil, nil
+	}
+	s, ok := x.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected string, found %s", typeName(x))
+	}
+	pattern, ok := y.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected string pattern, found %s", typeName(y))
+	}
+
+	if op == LIKE || op == NOTLIKE {
+		pattern = likeToRegexp(pattern)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := re.MatchString(s)
+	if op == NOTLIKE || op == NOTREGEXP {
+		return !matched, nil
+	}
+	return matched, nil
+}
+
+// likeToRegexp converts a LIKE pattern using % and _ wildcards to an anchored regular expression.
+func likeToRegexp(pattern string) string {
+	var buf strings.Builder
+	buf.WriteString("(?s)^")
+	escaped := false
+	for _, ch := range pattern {
+		switch {
+		case escaped:
+			buf.WriteString(regexp.QuoteMeta(string(ch)))
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == '%':
+			buf.WriteString(".*")
+		case ch == '_':
+			buf.WriteString(".")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(ch)))
+		}
+	}
+	buf.WriteString("$")
+	return buf.String()
+}
+
+func castValue(v Value, typ *Type) (Value, error) {
+	if v == nil || typ == nil || typ.Name == nil {
+		return v, nil
+	}
+
+	switch name := strings.ToUpper(typ.Name.Name); name {
+	case "BIGINT", "INT", "INT64", "INTEGER", "SMALLINT", "TINYINT":
+		switch x := v.(type) {
+		case int64:
+			return x, nil
+		case float64:
+			return int64(x), nil
+		case bool:
+			if x {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case string:
+			n, ok := toNumber(x)
+			if !ok {
+				return nil, fmt.Errorf("cannot cast %q to %s", x, name)
+			}
+			if f, ok := n.(float64); ok {
+				return int64(f), nil
+			}
+			return n, nil
+		}
+	case "DOUBLE", "FLOAT", "REAL", "DECIMAL", "NUMERIC":
+		switch x := v.(type) {
+		case int64:
+			return float64(x), nil
+		case float64:
+			return x, nil
+		case string:
+			n, ok := toNumber(x)
+			if !ok {
+				return nil, fmt.Errorf("cannot cast %q to %s", x, name)
+			}
+			return toFloat(n), nil
+		}
+	case "STRING", "VARCHAR", "NVARCHAR", "CHARACTER", "NCHAR", "TEXT", "CLOB":
+		return valueString(v), nil
+	case "BOOLEAN":
+		switch x := v.(type) {
+		case bool:
+			return x, nil
+		case int64:
+			return x != 0, nil
+		case string:
+			b, err := strconv.ParseBool(x)
+			if err != nil {
+				return nil, fmt.Errorf("cannot cast %q to %s", x, name)
+			}
+			return b, nil
+		}
+	case "DATE", "DATETIME", "TIMESTAMP", "TIMESTAMP_NTZ":
+		switch x := v.(type) {
+		case time.Time:
+			if name == "DATE" {
+				return x.Truncate(24 * time.Hour), nil
+			}
+			return x, nil
+		case string:
+			t, _, err := parseTime(x)
+			if err != nil {
+				return nil, err
+			}
+			if name == "DATE" {
+				return t.Truncate(24 * time.Hour), nil
+			}
+			return t, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported cast to %s", name)
+	}
+	return nil, fmt.Errorf("cannot cast %s to %s", typeName(v), strings.ToUpper(typ.Name.Name))
+}
+
+// valueString returns the string form of v as used by string concatenation.
+func valueString(v Value) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return formatTime(v)
+	case Interval:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func typeName(v Value) string {
+	switch v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		return "boolean"
+	case int64:
+		return "integer"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case time.Time:
+		return "datetime"
+	case Interval:
+		return "interval"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+var timeLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05",
+	"20060102",
+	"2006010215",
+}
+
+// parseTime parses s as a date or datetime and returns the layout it matched.
+func parseTime(s string) (time.Time, string, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, layout, nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("invalid datetime %q", s)
+}
+
+func formatTime(t time.Time) string {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func addInterval(t time.Time, i Interval) (time.Time, error) {
+	n := int(i.Value)
+	switch strings.ToUpper(i.Unit) {
+	case "YEAR", "YEARS", "YYYY":
+		return t.AddDate(n, 0, 0), nil
+	case "MONTH", "MONTHS", "MM":
+		return t.AddDate(0, n, 0), nil
+	case "WEEK", "WEEKS":
+		return t.AddDate(0, 0, 7*n), nil
+	case "DAY", "DAYS", "DD":
+		return t.AddDate(0, 0, n), nil
+	case "HOUR", "HOURS", "HH":
+		return t.Add(time.Duration(n) * time.Hour), nil
+	case "MINUTE", "MINUTES", "MI":
+		return t.Add(time.Duration(n) * time.Minute), nil
+	case "SECOND", "SECONDS", "SS":
+		return t.Add(time.Duration(n) * time.Second), nil
+	default:
+		return time.Time{}, fmt.Errorf("unsupported interval unit %s", i.Unit)
+	}
+}
@@ -0,0 +1,161 @@
+package query_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+)
+
+func TestEval(t *testing.T) {
+	t.Run("Literals", func(t *testing.T) {
+		AssertEval(t, `123`, nil, int64(123))
+		AssertEval(t, `0x1F`, nil, int64(31))
+		AssertEval(t, `1.5`, nil, 1.5)
+		AssertEval(t, `'foo'`, nil, "foo")
+		AssertEval(t, `TRUE`, nil, true)
+		AssertEval(t, `NULL`, nil, nil)
+		AssertEval(t, `DATE '2024-01-31'`, nil, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC))
+	})
+
+	t.Run("Arithmetic", func(t *testing.T) {
+		AssertEval(t, `1 + 2 * 3`, nil, int64(7))
+		AssertEval(t, `(1 + 2) * 3`, nil, int64(9))
+		AssertEval(t, `7 % 3`, nil, int64(1))
+		AssertEval(t, `7 / 2`, nil, 3.5)
+		AssertEval(t, `1 + 0.5`, nil, 1.5)
+		AssertEval(t, `-(2 - 5)`, nil, int64(3))
+		AssertEval(t, `'10' + 1`, nil, int64(11))
+		AssertEval(t, `1 + NULL`, nil, nil)
+		AssertEvalError(t, `1 / 0`, nil, `1:3: division by zero`)
+		AssertEvalError(t, `9223372036854775807 + 1`, nil, `1:21: integer overflow`)
+		AssertEvalError(t, `-9223372036854775807 - 2`, nil, `1:22: integer overflow`)
+		AssertEvalError(t, `4611686018427387904 * 2`, nil, `1:21: integer overflow`)
+		AssertEval(t, `-4611686018427387904 * 2`, nil, int64(-9223372036854775808))
+	})
+
+	t.Run("Bitwise", func(t *testing.T) {
+		AssertEval(t, `6 & 3`, nil, int64(2))
+		AssertEval(t, `6 | 3`, nil, int64(7))
+		AssertEval(t, `1 << 4`, nil, int64(16))
+		AssertEval(t, `~0`, nil, int64(-1))
+		AssertEval(t, `-16 >> 2`, nil, int64(-4))
+		AssertEvalError(t, `1 << -1`, nil, `1:3: negative shift count -1`)
+		AssertEvalError(t, `1 >> -1`, nil, `1:3: negative shift count -1`)
+		AssertEvalError(t, `1 << 63`, nil, `1:3: integer overflow`)
+	})
+
+	t.Run("Comparison", func(t *testing.T) {
+		AssertEval(t, `1 < 2`, nil, true)
+		AssertEval(t, `'b' >= 'a'`, nil, true)
+		AssertEval(t, `1 = 1.0`, nil, true)
+		AssertEval(t, `1 != NULL`, nil, nil)
+		AssertEval(t, `NULL <=> NULL`, nil, true)
+		AssertEval(t, `1 IS NOT NULL`, nil, true)
+		AssertEval(t, `DATE '2024-01-01' < '2024-02-01'`, nil, true)
+		AssertEval(t, `'abc' LIKE 'a%'`, nil, true)
+		AssertEval(t, `'abc' NOT LIKE 'a_'`, nil, true)
+		AssertEvalError(t, `TRUE = 'x'`, nil, `1:6: cannot compare boolean with string`)
+	})
+
+	t.Run("ThreeValuedLogic", func(t *testing.T) {
+		AssertEval(t, `NULL AND FALSE`, nil, false)
+		AssertEval(t, `NULL AND TRUE`, nil, nil)
+		AssertEval(t, `NULL OR TRUE`, nil, true)
+		AssertEval(t, `NULL OR FALSE`, nil, nil)
+		AssertEval(t, `NOT NULL`, nil, nil)
+		AssertEval(t, `NULL IS NULL`, nil, true)
+	})
+
+	t.Run("In", func(t *testing.T) {
+		AssertEval(t, `2 IN (1, 2, 3)`, nil, true)
+		AssertEval(t, `4 IN (1, 2, 3)`, nil, false)
+		AssertEval(t, `4 IN (1, NULL)`, nil, nil)
+		AssertEval(t, `1 IN (1, NULL)`, nil, true)
+		AssertEval(t, `4 NOT IN (1, 2)`, nil, true)
+		AssertEval(t, `4 NOT IN (1, NULL)`, nil, nil)
+	})
+
+	t.Run("Between", func(t *testing.T) {
+		AssertEval(t, `5 BETWEEN 1 AND 10`, nil, true)
+		AssertEval(t, `5 NOT BETWEEN 1 AND 10`, nil, false)
+		AssertEval(t, `5 BETWEEN NULL AND 4`, nil, false)
+		AssertEval(t, `5 BETWEEN NULL AND 10`, nil, nil)
+	})
+
+	t.Run("Case", func(t *testing.T) {
+		AssertEval(t, `CASE WHEN 1 > 2 THEN 'a' WHEN 2 > 1 THEN 'b' END`, nil, "b")
+		AssertEval(t, `CASE 2 WHEN 1 THEN 'a' ELSE 'c' END`, nil, "c")
+		AssertEval(t, `CASE NULL WHEN NULL THEN 'a' END`, nil, nil)
+	})
+
+	t.Run("Cast", func(t *testing.T) {
+		AssertEval(t, `CAST('12' AS BIGINT)`, nil, int64(12))
+		AssertEval(t, `CAST(1.9 AS INT)`, nil, int64(1))
+		AssertEval(t, `CAST(12 AS STRING)`, nil, "12")
+		AssertEval(t, `CAST('2024-01-02' AS DATE)`, nil, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC))
+		AssertEvalError(t, `CAST('x' AS BIGINT)`, nil, `1:1: cannot cast "x" to BIGINT`)
+	})
+
+	t.Run("Concat", func(t *testing.T) {
+		AssertEval(t, `'a' || 1 || 'b'`, nil, "a1b")
+		AssertEval(t, `'a' || NULL`, nil, nil)
+		AssertEval(t, `concat('pt=', 20240101)`, nil, "pt=20240101")
+	})
+
+	t.Run("Functions", func(t *testing.T) {
+		AssertEval(t, `date_add('2024-01-31', 1)`, nil, "2024-02-01")
+		AssertEval(t, `date_sub(DATE '2024-03-01', 1)`, nil, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC))
+		AssertEval(t, `DATEADD('2024-01-31 10:00:00', -1, 'mm')`, nil, "2023-12-31 10:00:00")
+		AssertEval(t, `to_char(to_date('20240105', 'yyyymmdd'), 'yyyy-mm-dd')`, nil, "2024-01-05")
+		AssertEval(t, `to_char('2024-01-05', 'yyyy')`, nil, "2024")
+		AssertEval(t, `to_char(12)`, nil, "12")
+		AssertEvalError(t, `to_char('x', 'yyyy')`, nil, `invalid datetime "x"`)
+		AssertEvalError(t, `to_char(12, 'yyyy')`, nil, `expected datetime, found`)
+		AssertEval(t, `datediff('2024-01-10', '2024-01-01')`, nil, int64(9))
+		AssertEval(t, `coalesce(NULL, 'x')`, nil, "x")
+		AssertEval(t, `IF(1 > 2, 'a', 'b')`, nil, "b")
+		AssertEval(t, `substr('abcdef', 2, 3)`, nil, "bcd")
+		AssertEval(t, `upper('abc')`, nil, "ABC")
+		AssertEvalError(t, `rand()`, nil, `1:1: unknown function rand`)
+		AssertEvalError(t, `count(*)`, nil, `1:1: cannot evaluate aggregate count(*)`)
+	})
+
+	t.Run("Env", func(t *testing.T) {
+		env := query.MapEnv{"@dt": "2024-01-01", "pt": int64(5)}
+		AssertEval(t, `date_add(@dt, 1)`, env, "2024-01-02")
+		AssertEval(t, `PT BETWEEN 1 AND 10`, env, true)
+		AssertEval(t, `t.pt = 5`, env, true)
+		AssertEval(t, `Pt`, query.MapEnv{"pt": int64(1), "PT": int64(2), "Pt": int64(3)}, int64(3))
+		AssertEvalError(t, `pT = 1`, query.MapEnv{"pt": int64(1), "PT": int64(2)}, `1:1: unbound identifier pT`)
+		AssertEvalError(t, `x = 1`, env, `1:1: unbound identifier x`)
+		AssertEvalError(t, `(SELECT 1)`, nil, `cannot evaluate`)
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		query.RegisterFunc("double_it", func(args []query.Value) (query.Value, error) {
+			return args[0].(int64) * 2, nil
+		})
+		t.Cleanup(func() { query.UnregisterFunc("double_it") })
+		AssertEval(t, `DOUBLE_IT(21)`, nil, int64(42))
+	})
+}
+
+// AssertEval asserts that s evaluates to want.
+func AssertEval(tb testing.TB, s string, env query.Env, want query.Value) {
+	tb.Helper()
+
+	v, err := query.Eval(query.MustParseExprString(s), env)
+	assert.NoError(tb, err)
+	assert.Equal(tb, want, v)
+}
+
+// AssertEvalError asserts that evaluating s returns an error containing want.
+func AssertEvalError(tb testing.TB, s string, env query.Env, want string) {
+	tb.Helper()
+
+	_, err := query.Eval(query.MustParseExprString(s), env)
+	assert.ErrorContains(tb, err, want)
+}
@@ -25,9 +25,11 @@ func (*ExprList) node()       {}
 func (*Exists) node()         {}
 func (*Ident) node()          {}
 func (*MultiPartIdent) node() {}
+func (*Params) node()         {}
 func (*ParenExpr) node()      {}
 func (*Range) node()          {}
 func (*QualifiedRef) node()   {}
+func (*Type) node()           {}
 func (*UnaryExpr) node()      {}
 func (*IndexExpr) node()      {}
 func (SelectExpr) node()      {}
@@ -154,8 +156,41 @@ type Call struct {
 	Star     Pos             `json:"star"`
 	Distinct Pos             `json:"distinct"`
 	Args     []*Params       `json:"args"`
-	Rparen   Pos             `json:"rparen"`
-	Over     *OverClause     `json:"over"`
+
+	// IGNORE NULLS or RESPECT NULLS inside the parens, e.g. ARRAY_AGG(x IGNORE NULLS).
+	Ignore  Pos `json:"ignore"`
+	Respect Pos `json:"respect"`
+	Nulls   Pos `json:"nulls"`
+
+	Order         Pos             `json:"order"`
+	OrderBy       Pos             `json:"order_by"`
+	OrderingTerms []*OrderingTerm `json:"ordering_terms"`
+	Limit         Pos             `json:"limit"`
+	LimitExpr     Expr            `json:"limit_expr"`
+	Separator     Pos             `json:"separator"`
+	SeparatorExpr Expr            `json:"separator_expr"`
+	Rparen        Pos             `json:"rparen"`
+
+	Within *Within `json:"within"`
+
+	Filter       Pos  `json:"filter"`
+	FilterLparen Pos  `json:"filter_lparen"`
+	FilterWhere  Pos  `json:"filter_where"`
+	FilterExpr   Expr `json:"filter_expr"`
+	FilterRparen Pos  `json:"filter_rparen"`
+
+	// IGNORE NULLS or RESPECT NULLS after the parens, e.g. FIRST_VALUE(x) IGNORE NULLS.
+	AfterIgnore  Pos `json:"after_ignore"`
+	AfterRespect Pos `json:"after_respect"`
+	AfterNulls   Pos `json:"after_nulls"`
+
+	Over *OverClause `json:"over"`
+}
+
+// IgnoresNulls returns true if the call has IGNORE NULLS, inside or after
+// its parens.
+func (c *Call) IgnoresNulls() bool {
+	return c.Ignore.IsValid() || c.AfterIgnore.IsValid()
 }
 
 // String returns the string representation of the expression.
@@ -179,8 +214,50 @@ func (c *Call) String() string {
 			buf.WriteString(arg.String())
 		}
 	}
+
+	if c.Ignore.IsValid() {
+		buf.WriteString(" IGNORE NULLS")
+	} else if c.Respect.IsValid() {
+		buf.WriteString(" RESPECT NULLS")
+	}
+
+	if len(c.OrderingTerms) != 0 {
+		buf.WriteString(" ORDER BY ")
+		for i, term := range c.OrderingTerms {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(term.String())
+		}
+	}
+	if c.LimitExpr != nil {
+		fmt.Fprintf(&buf, " LIMIT %s", c.LimitExpr.String())
+	}
+	if c.SeparatorExpr != nil {
+		fmt.Fprintf(&buf, " SEPARATOR %s", c.SeparatorExpr.String())
+	}
 	buf.WriteString(")")
 
+	if c.Within != nil {
+		buf.WriteString(" ")
+		buf.WriteString(c.Within.String())
+	}
+
+	if c.FilterExpr != nil {
+		fmt.Fprintf(&buf, " FILTER (WHERE %s)", c.FilterExpr.String())
+	}
+
+	if c.AfterIgnore.IsValid() {
+		buf.WriteString(" IGNORE NULLS")
+	} else if c.AfterRespect.IsValid() {
+		buf.WriteString(" RESPECT NULLS")
+	}
+
+	if c.Over != nil {
+		buf.WriteString(" ")
+		buf.WriteString(c.Over.String())
+	}
+
 	return buf.String()
 }
 
@@ -348,7 +425,7 @@ func (i *Ident) String() string {
 	case QIDENT:
 		return `"` + i.Name + `"`
 	case STRING:
-		return "'" + i.Name + "'"
+		return (&StringLit{Value: i.Name, Quote: '\''}).String()
 	case TSTRING:
 		return "`" + i.Name + "`"
 	case TMPL:
@@ -426,7 +503,7 @@ func (expr *UnaryExpr) String() string {
 
 // SelectExpr represents a SELECT statement inside an expression.
 type SelectExpr struct {
-	*SelectStatement
+	*SelectStatement `json:"select"`
 }
 
 type IndexExpr struct {
@@ -445,11 +522,11 @@ func (expr *IndexExpr) String() string {
 }
 
 type Exists struct {
-	Not    Pos              // position of optional NOT keyword
-	Exists Pos              // position of EXISTS keyword
-	Lparen Pos              // position of left paren
-	Select *SelectStatement // select statement
-	Rparen Pos              // position of right paren
+	Not    Pos              `json:"not"`    // position of optional NOT keyword
+	Exists Pos              `json:"exists"` // position of EXISTS keyword
+	Lparen Pos              `json:"lparen"` // position of left paren
+	Select *SelectStatement `json:"select"` // select statement
+	Rparen Pos              `json:"rparen"` // position of right paren
 }
 
 // String returns the string representation of the expression.
@@ -3,6 +3,20 @@ package query
 func (p *Parser) parseParenExpr() (Expr, error) {
 	lparen, _, _ := p.scan()
 
+	// Parse a query whose first operand is parenthesised, which would
+	// otherwise be taken for a parenthesised expression.
+	if p.peekSetOperand() {
+		stmt, err := p.parseSelectStatement(nil)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != RP {
+			return nil, p.errorExpected(p.pos, p.tok, "right paren")
+		}
+		rparen, _, _ := p.scan()
+		return &ParenExpr{Lparen: lparen, X: SelectExpr{stmt}, Rparen: rparen}, nil
+	}
+
 	// Parse the first expression
 	x, err := p.ParseExpr()
 	if err != nil {
@@ -150,7 +164,7 @@ func (p *Parser) parseOperand() (expr Expr, err error) {
 		}
 		return p.parseIdentifier(ident)
 	case tok == STRING:
-		return &StringLit{ValuePos: pos, Value: lit}, nil
+		return &StringLit{ValuePos: pos, Value: lit, Quote: '\''}, nil
 	case tok == TMPL:
 		return &TemplateStr{TmplPos: pos, Template: lit}, nil
 	case tok == RAWSTR:
@@ -194,7 +208,7 @@ func (p *Parser) parseOperand() (expr Expr, err error) {
 		return p.parseExists(Pos{})
 	case tok == SELECT, tok == WITH:
 		p.unscan()
-		selectStmt, err := p.parseSelectStatement(false, nil)
+		selectStmt, err := p.parseSelectStatement(nil)
 		return SelectExpr{selectStmt}, err
 	default:
 		return nil, p.errorExpected(p.pos, p.tok, "expression")
@@ -250,7 +264,7 @@ func (p *Parser) parseMultiIdent(ident *Ident) (*MultiPartIdent, Pos) {
 		}, emptyPos
 	}
 	dot1, _, _ := p.scan()
-	if !isIdentToken(p.peek()) {
+	if !isFieldToken(p.peek()) {
 		return &MultiPartIdent{Name: ident}, dot1
 	}
 	// Next ident
@@ -262,7 +276,7 @@ func (p *Parser) parseMultiIdent(ident *Ident) (*MultiPartIdent, Pos) {
 	}
 
 	dot2, _, _ := p.scan()
-	if !isIdentToken(p.peek()) {
+	if !isFieldToken(p.peek()) {
 		return &MultiPartIdent{First: ident, Dot1: dot1, Name: ident2}, dot2
 	}
 
@@ -275,7 +289,7 @@ func (p *Parser) parseMultiIdent(ident *Ident) (*MultiPartIdent, Pos) {
 	}
 
 	dot3, _, _ := p.scan()
-	if !isIdentToken(p.peek()) {
+	if !isFieldToken(p.peek()) {
 		return &MultiPartIdent{First: ident, Dot1: dot1, Second: ident2, Dot2: dot2, Name: ident3}, dot3
 	}
 
@@ -448,6 +462,19 @@ func (p *Parser) parseExprList() (_ *ExprList, err error) {
 	}
 	list.Lparen, _, _ = p.scan()
 
+	// A query whose first operand is parenthesised, as in
+	// "x IN ((SELECT a FROM t) UNION (SELECT b FROM u))".
+	if p.peekSetOperand() {
+		stmt, err := p.parseSelectStatement(nil)
+		if err != nil {
+			return &list, err
+		}
+		list.Exprs = append(list.Exprs, SelectExpr{stmt})
+		if p.peek() != RP {
+			return &list, p.errorExpected(p.pos, p.tok, "right paren")
+		}
+	}
+
 	for p.peek() != RP {
 		x, err := p.ParseExpr()
 		if err != nil {
@@ -483,7 +510,7 @@ func (p *Parser) parseCall(name *MultiPartIdent) (*Call, error) {
 		if p.peek() == DISTINCT {
 			expr.Distinct, _, _ = p.scan()
 		}
-		for p.peek() != RP {
+		for p.peek() != RP && !p.peekCallModifier() {
 			var p1 Params
 			x, err := p.ParseExpr()
 			if err != nil {
@@ -503,18 +530,90 @@ func (p *Parser) parseCall(name *MultiPartIdent) (*Call, error) {
 
 			if tok := p.peek(); tok == COMMA {
 				p.scan()
-			} else if tok != RP {
+			} else if tok != RP && !p.peekCallModifier() {
 				return &expr, p.errorExpected(p.pos, p.tok, "comma or right paren")
 			}
 
 		}
 	}
 
+	// Parse optional "IGNORE|RESPECT NULLS", "ORDER BY ...", "LIMIT n" and
+	// "SEPARATOR expr", in that order.
+	if expr.Ignore, expr.Respect, expr.Nulls, err = p.parseNullTreatment(); err != nil {
+		return &expr, err
+	}
+
+	if p.peek() == ORDER {
+		expr.Order, _, _ = p.scan()
+		if p.peek() != BY {
+			return &expr, p.errorExpected(p.pos, p.tok, "BY")
+		}
+		expr.OrderBy, _, _ = p.scan()
+
+		for {
+			term, err := p.parseOrderingTerm()
+			if err != nil {
+				return &expr, err
+			}
+			expr.OrderingTerms = append(expr.OrderingTerms, term)
+
+			if p.peek() != COMMA {
+				break
+			}
+			p.scan()
+		}
+	}
+
+	if p.peek() == LIMIT {
+		expr.Limit, _, _ = p.scan()
+		if expr.LimitExpr, err = p.ParseExpr(); err != nil {
+			return &expr, err
+		}
+	}
+
+	if p.peekSeparator() {
+		expr.Separator, _, _ = p.scan()
+		if expr.SeparatorExpr, err = p.ParseExpr(); err != nil {
+			return &expr, err
+		}
+	}
+
 	if p.peek() != RP {
 		return &expr, p.errorExpected(p.pos, p.tok, "right paren")
 	}
 	expr.Rparen, _, _ = p.scan()
 
+	// Parse optional "WITHIN GROUP (ORDER BY ...)".
+	if p.peek() == WITHIN {
+		if expr.Within, err = p.parseWithinClause(); err != nil {
+			return &expr, err
+		}
+	}
+
+	// Parse optional "FILTER (WHERE expr)". FILTER alone is an alias.
+	if p.peekWord("FILTER") && p.peek2() == LP {
+		expr.Filter, _, _ = p.scan()
+		if p.peek() != LP {
+			return &expr, p.errorExpected(p.pos, p.tok, "left paren")
+		}
+		expr.FilterLparen, _, _ = p.scan()
+		if p.peek() != WHERE {
+			return &expr, p.errorExpected(p.pos, p.tok, "WHERE")
+		}
+		expr.FilterWhere, _, _ = p.scan()
+		if expr.FilterExpr, err = p.ParseExpr(); err != nil {
+			return &expr, err
+		}
+		if p.peek() != RP {
+			return &expr, p.errorExpected(p.pos, p.tok, "right paren")
+		}
+		expr.FilterRparen, _, _ = p.scan()
+	}
+
+	if expr.AfterIgnore, expr.AfterRespect, expr.AfterNulls, err = p.parseNullTreatment(); err != nil {
+		return &expr, err
+	}
+
 	// Parse optional over clause.
 	if p.peek() == OVER {
 		if expr.Over, err = p.parseOverClause(); err != nil {
@@ -525,6 +624,42 @@ func (p *Parser) parseCall(name *MultiPartIdent) (*Call, error) {
 	return &expr, nil
 }
 
+// peekCallModifier returns true if the next token ends the arguments of a
+// call and starts one of its modifiers, such as ORDER BY.
+func (p *Parser) peekCallModifier() bool {
+	return p.peek() == ORDER || p.peek() == LIMIT || p.peekNullTreatment() || p.peekSeparator()
+}
+
+// peekNullTreatment returns true if "IGNORE NULLS" or "RESPECT NULLS" is
+// next. Either word alone is an identifier.
+func (p *Parser) peekNullTreatment() bool {
+	return (p.peekWord("IGNORE") || p.peekWord("RESPECT")) && p.peek2() == NULLS
+}
+
+// peekSeparator returns true if the SEPARATOR of a call is next. SEPARATOR
+// is an identifier where it ends an argument.
+func (p *Parser) peekSeparator() bool {
+	if !p.peekWord("SEPARATOR") {
+		return false
+	}
+	tok := p.peek2()
+	return tok != RP && tok != COMMA
+}
+
+// parseNullTreatment parses an optional "IGNORE NULLS" or "RESPECT NULLS".
+func (p *Parser) parseNullTreatment() (ignore, respect, nulls Pos, err error) {
+	if !p.peekNullTreatment() {
+		return ignore, respect, nulls, nil
+	}
+	if p.peekWord("IGNORE") {
+		ignore, _, _ = p.scan()
+	} else {
+		respect, _, _ = p.scan()
+	}
+	nulls, _, _ = p.scan()
+	return ignore, respect, nulls, nil
+}
+
 func (p *Parser) parseCaseExpr() (_ *CaseExpr, err error) {
 	assert(p.peek() == CASE)
 
@@ -600,7 +735,7 @@ func (p *Parser) parseExists(notPos Pos) (_ *Exists, err error) {
 	}
 	expr.Lparen, _, _ = p.scan()
 
-	if expr.Select, err = p.parseSelectStatement(false, nil); err != nil {
+	if expr.Select, err = p.parseSelectStatement(nil); err != nil {
 		return &expr, err
 	}
 
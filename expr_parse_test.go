@@ -101,6 +101,19 @@ func TestCall_String(t *testing.T) {
 			{X: &query.NullLit{}},
 		},
 	}, `foo(DISTINCT NULL, NULL)`)
+
+	x := &query.MultiPartIdent{Name: &query.Ident{Name: "x", Tok: query.IDENT}}
+	AssertExprStringer(t, &query.Call{
+		Name:          &query.MultiPartIdent{Name: &query.Ident{Name: "string_agg", Tok: query.IDENT}},
+		Args:          []*query.Params{{X: x}},
+		Respect:       pos(0),
+		OrderingTerms: []*query.OrderingTerm{{X: x, Desc: pos(0)}},
+		LimitExpr:     &query.NumberLit{Value: "10"},
+		SeparatorExpr: &query.StringLit{Value: ",", Quote: '\''},
+		FilterExpr:    &query.BinaryExpr{Op: query.GT, X: x, Y: &query.NumberLit{Value: "0"}},
+		AfterIgnore:   pos(0),
+		Over:          &query.OverClause{Name: &query.Ident{Name: "w", Tok: query.IDENT}},
+	}, `string_agg(x RESPECT NULLS ORDER BY x DESC LIMIT 10 SEPARATOR ',') FILTER (WHERE x > 0) IGNORE NULLS OVER w`)
 }
 
 func TestParser_ParseExpr(t *testing.T) {
@@ -108,7 +121,7 @@ func TestParser_ParseExpr(t *testing.T) {
 		AssertParseExpr(t, `fooBAR_123'`, &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(0), Name: `fooBAR_123`, Tok: query.IDENT}})
 	})
 	t.Run("StringLit", func(t *testing.T) {
-		AssertParseExpr(t, `'foo bar'`, &query.StringLit{ValuePos: pos(0), Value: `foo bar`})
+		AssertParseExpr(t, `'foo bar'`, &query.StringLit{ValuePos: pos(0), Value: `foo bar`, Quote: '\''})
 	})
 	t.Run("Integer", func(t *testing.T) {
 		AssertParseExpr(t, `123`, &query.NumberLit{ValuePos: pos(0), Value: `123`})
@@ -445,7 +458,7 @@ func TestParser_ParseExpr(t *testing.T) {
 			Lparen:   pos(3),
 			Distinct: pos(4),
 			Args: []*query.Params{
-				{X: &query.StringLit{ValuePos: pos(13), Value: "foo"}},
+				{X: &query.StringLit{ValuePos: pos(13), Value: "foo", Quote: '\''}},
 			},
 			Rparen: pos(18),
 		})
@@ -497,9 +510,76 @@ func TestParser_ParseExpr(t *testing.T) {
 			},
 			Rparen: pos(23),
 		})
+		AssertParseExpr(t, `array_agg(x IGNORE NULLS ORDER BY y DESC LIMIT 1)`, &query.Call{
+			Name: &query.MultiPartIdent{
+				Name: &query.Ident{NamePos: pos(0), Name: "array_agg", Tok: query.IDENT},
+			},
+			Lparen: pos(9),
+			Args: []*query.Params{
+				{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(10), Name: "x", Tok: query.IDENT}}},
+			},
+			Ignore:  pos(12),
+			Nulls:   pos(19),
+			Order:   pos(25),
+			OrderBy: pos(31),
+			OrderingTerms: []*query.OrderingTerm{
+				{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(34), Name: "y", Tok: query.IDENT}}, Desc: pos(36)},
+			},
+			Limit:     pos(41),
+			LimitExpr: &query.NumberLit{ValuePos: pos(47), Value: "1"},
+			Rparen:    pos(48),
+		})
+		AssertParseExpr(t, `count(*) FILTER (WHERE a > 1)`, &query.Call{
+			Name: &query.MultiPartIdent{
+				Name: &query.Ident{NamePos: pos(0), Name: "count", Tok: query.IDENT},
+			},
+			Lparen:       pos(5),
+			Star:         pos(6),
+			Rparen:       pos(7),
+			Filter:       pos(9),
+			FilterLparen: pos(16),
+			FilterWhere:  pos(17),
+			FilterExpr: &query.BinaryExpr{
+				X:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(23), Name: "a", Tok: query.IDENT}},
+				OpPos: pos(25),
+				Op:    query.GT,
+				Y:     &query.NumberLit{ValuePos: pos(27), Value: "1"},
+			},
+			FilterRparen: pos(28),
+		})
+		AssertParseExpr(t, `group_concat(x SEPARATOR '-')`, &query.Call{
+			Name: &query.MultiPartIdent{
+				Name: &query.Ident{NamePos: pos(0), Name: "group_concat", Tok: query.IDENT},
+			},
+			Lparen: pos(12),
+			Args: []*query.Params{
+				{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(13), Name: "x", Tok: query.IDENT}}},
+			},
+			Separator:     pos(15),
+			SeparatorExpr: &query.StringLit{ValuePos: pos(25), Value: "-", Quote: '\''},
+			Rparen:        pos(28),
+		})
+		AssertParseExpr(t, `first_value(x) respect nulls`, &query.Call{
+			Name: &query.MultiPartIdent{
+				Name: &query.Ident{NamePos: pos(0), Name: "first_value", Tok: query.IDENT},
+			},
+			Lparen: pos(11),
+			Args: []*query.Params{
+				{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(12), Name: "x", Tok: query.IDENT}}},
+			},
+			Rparen:       pos(13),
+			AfterRespect: pos(15),
+			AfterNulls:   pos(23),
+		})
 		AssertParseExprError(t, `sum(`, `1:4: expected expression, found 'EOF'`)
 		AssertParseExprError(t, `sum(*`, `1:5: expected right paren, found 'EOF'`)
 		AssertParseExprError(t, `sum(foo foo`, `1:9: expected comma or right paren, found foo`)
+		AssertParseExprError(t, `sum(foo IGNORE`, `1:9: expected comma or right paren, found IGNORE`)
+		AssertParseExprError(t, `sum(foo ORDER`, `1:13: expected BY, found 'EOF'`)
+		AssertParseExprError(t, `sum(foo LIMIT 1 foo`, `1:17: expected right paren, found foo`)
+		AssertParseExprError(t, `sum(foo) FILTER (a`, `1:18: expected WHERE, found a`)
+		AssertParseExprError(t, `sum(foo) FILTER (WHERE a`, `1:24: expected right paren, found 'EOF'`)
+		AssertParseExprError(t, `sum(foo RESPECT NULLS foo`, `1:23: expected right paren, found foo`)
 	})
 	t.Run("Case", func(t *testing.T) {
 		AssertParseExpr(t, `CASE 1 WHEN 2 THEN 3 WHEN 4 THEN 5 ELSE 6 END`, &query.CaseExpr{
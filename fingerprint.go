@@ -0,0 +1,138 @@
+package query
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Fingerprint returns a hash identifying the shape of stmt. Statements that
+// differ only in literal values, the length of literal IN lists, whitespace,
+// comments or identifier case share the same fingerprint.
+func Fingerprint(stmt Statement) string {
+	s, _ := Normalize(stmt)
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// Normalize returns the canonical text used by Fingerprint along with the
+// literals replaced by placeholders, in the order they appear in stmt.
+//
+// Literals are printed as "?", IN lists and VALUES rows made up only of
+// placeholders are collapsed to a single entry, and unquoted identifiers are
+// lower-cased. Comments are dropped by the parser and never reach the AST.
+// The statement itself is not modified.
+func Normalize(stmt Statement) (string, []Expr) {
+	if stmt == nil {
+		return "", nil
+	}
+
+	var n normalizer
+	out, _ := Walk(&n, Clone(stmt))
+	return out.String(), n.literals
+}
+
+type normalizer struct {
+	literals []Expr
+}
+
+func (n *normalizer) Visit(node Node) (Visitor, Node, error) {
+	switch node := node.(type) {
+	case *Type:
+		// Precision and scale are part of the shape of the query.
+		return nil, node, nil
+	case *StringLit, *RawLit, *BoolLit, *TimestampLit, *IntervalLit:
+		return nil, n.extract(node.(Expr)), nil
+	case *NumberLit:
+		// Numbers can be held by fields typed *NumberLit, e.g. array indices.
+		n.literals = append(n.literals, node)
+		return nil, &NumberLit{Value: "?"}, nil
+	case *UnaryExpr:
+		// Signed numbers are a single literal.
+		if _, ok := node.X.(*NumberLit); ok && (node.Op == PLUS || node.Op == MINUS) {
+			return nil, n.extract(node), nil
+		}
+	case *MultiPartIdent:
+		// Typed literals, e.g. DATE '2024-01-01', are parsed as identifiers.
+		if isTypedLiteral(node) {
+			n.literals = append(n.literals, node)
+			return nil, &MultiPartIdent{Name: placeholder()}, nil
+		}
+	case *Ident:
+		switch node.Tok {
+		case QIDENT, STRING, TMPL, BIND:
+		default:
+			node.Name = strings.ToLower(node.Name)
+		}
+	}
+	return n, node, nil
+}
+
+func (n *normalizer) VisitEnd(node Node) (Node, error) {
+	switch node := node.(type) {
+	case *BinaryExpr:
+		if node.Op == IN || node.Op == NOTIN {
+			if list, ok := node.Y.(*ExprList); ok && len(list.Exprs) > 1 && allPlaceholders(list.Exprs) {
+				list.Exprs = list.Exprs[:1]
+			}
+		}
+	case *SelectStatement:
+		node.ValueLists = collapseValueLists(node.ValueLists)
+	case *InsertStatement:
+		node.ValueLists = collapseValueLists(node.ValueLists)
+	}
+	return node, nil
+}
+
+// extract records lit and returns the placeholder that replaces it.
+func (n *normalizer) extract(lit Expr) Expr {
+	n.literals = append(n.literals, lit)
+	return placeholder()
+}
+
+func placeholder() *Ident {
+	return &Ident{Name: "?", Tok: BIND}
+}
+
+func isPlaceholder(expr Expr) bool {
+	return expr.String() == "?"
+}
+
+func allPlaceholders(exprs []Expr) bool {
+	for _, expr := range exprs {
+		if !isPlaceholder(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// collapseValueLists keeps only the first of a run of VALUES rows that
+// consist entirely of placeholders.
+func collapseValueLists(lists []*ExprList) []*ExprList {
+	if len(lists) < 2 {
+		return lists
+	}
+
+	other := make([]*ExprList, 0, len(lists))
+	for i, list := range lists {
+		if i > 0 && allPlaceholders(list.Exprs) && list.String() == other[len(other)-1].String() {
+			continue
+		}
+		other = append(other, list)
+	}
+	return other
+}
+
+// isTypedLiteral returns true if ident holds a literal such as DATE '2024-01-01'.
+func isTypedLiteral(ident *MultiPartIdent) bool {
+	if ident.First != nil || ident.Name == nil {
+		return false
+	}
+	switch ident.Name.Tok {
+	case DATE, TIMESTAMP:
+		return strings.Contains(ident.Name.Name, "'")
+	default:
+		return false
+	}
+}
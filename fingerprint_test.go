@@ -0,0 +1,114 @@
+package query_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Run("Literals", func(t *testing.T) {
+		AssertNormalize(t, `SELECT a, 'x' FROM t WHERE b = 1 AND c > -2.5 LIMIT 10`,
+			`SELECT a, ? FROM t WHERE b = ? AND c > ? LIMIT ?`,
+			`'x'`, `1`, `-2.5`, `10`)
+		AssertNormalize(t, `SELECT * FROM t WHERE d = DATE '2024-01-01' OR e = TRUE`,
+			`SELECT * FROM t WHERE d = ? OR e = ?`,
+			`DATE '2024-01-01'`, `TRUE`)
+		AssertNormalize(t, `SELECT arr[0], CAST(x AS DECIMAL(10,2)) FROM t WHERE y IS NULL`,
+			`SELECT arr[?], CAST(x AS DECIMAL(10,2)) FROM t WHERE y IS NULL`,
+			`0`)
+		AssertNormalize(t, `SELECT a FROM t WHERE pt = {{ .DSTART }}`,
+			`SELECT a FROM t WHERE pt = {{ .DSTART }}`)
+	})
+
+	t.Run("InList", func(t *testing.T) {
+		AssertNormalize(t, `SELECT a FROM t WHERE b IN (1, 2, 3)`,
+			`SELECT a FROM t WHERE b IN (?)`,
+			`1`, `2`, `3`)
+		AssertNormalize(t, `SELECT a FROM t WHERE b NOT IN ('x', c)`,
+			`SELECT a FROM t WHERE b NOT IN (?, c)`,
+			`'x'`)
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		AssertNormalize(t, `INSERT INTO t (a, b) VALUES (1, 'x'), (2, 'y')`,
+			`INSERT INTO t (a, b) VALUES (?, ?)`,
+			`1`, `'x'`, `2`, `'y'`)
+		AssertNormalize(t, `INSERT OVERWRITE TABLE t SELECT * FROM s`,
+			`INSERT OVERWRITE TABLE t SELECT * FROM s`)
+	})
+
+	t.Run("IdentCase", func(t *testing.T) {
+		AssertNormalize(t, `SELECT Max(A) AS Total FROM Proj.Sch.Tbl`,
+			`SELECT max(a) AS total FROM proj.sch.tbl`)
+		AssertNormalize(t, `SELECT "Col" FROM t`,
+			`SELECT "Col" FROM t`)
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		AssertNormalize(t, `MERGE INTO t USING s ON t.id = s.id WHEN MATCHED AND s.v > 5 THEN UPDATE SET t.v = s.v WHEN NOT MATCHED THEN INSERT (id) VALUES (s.id)`,
+			`MERGE INTO t USING s ON t.id = s.id WHEN MATCHED AND s.v > ? THEN UPDATE SET t.v = s.v WHEN NOT MATCHED THEN INSERT (id) VALUES (s.id)`,
+			`5`)
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		stmt := ParseStatement(t, `SELECT A FROM t WHERE b IN (1, 2)`)
+		s := stmt.String()
+		query.Normalize(stmt)
+		assert.Equal(t, s, stmt.String())
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	AssertSameFingerprint(t,
+		`SELECT a FROM t WHERE b = 1 AND c IN ('x', 'y')`,
+		"select A\n  from T -- trailing comment\n where B = 42 /* note */ and C in ('z')")
+	AssertSameFingerprint(t,
+		`INSERT INTO t VALUES (1, 2)`,
+		`INSERT INTO t VALUES (3, 4), (5, 6)`)
+
+	AssertDifferentFingerprint(t, `SELECT a FROM t`, `SELECT b FROM t`)
+	AssertDifferentFingerprint(t, `SELECT a FROM t GROUP BY a`, `SELECT a FROM t GROUP BY b`)
+	AssertDifferentFingerprint(t, `SELECT a FROM t WHERE b IS NULL`, `SELECT a FROM t WHERE b = 1`)
+	AssertDifferentFingerprint(t, `INSERT INTO t SELECT * FROM s`, `INSERT OVERWRITE t SELECT * FROM s`)
+}
+
+// AssertNormalize asserts that s normalizes to want and extracts the given literals.
+func AssertNormalize(tb testing.TB, s string, want string, literals ...string) {
+	tb.Helper()
+
+	got, lits := query.Normalize(ParseStatement(tb, s))
+	assert.Equal(tb, want, got)
+
+	var strs []string
+	for _, lit := range lits {
+		strs = append(strs, lit.String())
+	}
+	assert.Equal(tb, literals, strs)
+}
+
+// AssertSameFingerprint asserts that a and b have the same fingerprint.
+func AssertSameFingerprint(tb testing.TB, a, b string) {
+	tb.Helper()
+	assert.Equal(tb, query.Fingerprint(ParseStatement(tb, a)), query.Fingerprint(ParseStatement(tb, b)))
+}
+
+// AssertDifferentFingerprint asserts that a and b have different fingerprints.
+func AssertDifferentFingerprint(tb testing.TB, a, b string) {
+	tb.Helper()
+	assert.NotEqual(tb, query.Fingerprint(ParseStatement(tb, a)), query.Fingerprint(ParseStatement(tb, b)))
+}
+
+// ParseStatement parses s into a single statement and fails the test on error.
+func ParseStatement(tb testing.TB, s string) query.Statement {
+	tb.Helper()
+
+	stmt, err := query.NewParser(strings.NewReader(s)).ParseStatement()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return stmt
+}
@@ -0,0 +1,108 @@
+package query
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// Format parses src and rewrites every statement in its canonical form, as
+// returned by String(), terminated by a semicolon and separated by a blank
+// line.
+//
+// Comments between statements are kept; a comment that starts on the line a
+// statement ends on stays on that line. A statement that contains a comment,
+// or whose canonical form does not parse back to the same statement, is kept
+// as written so formatting never changes the meaning of src.
+func Format(src string) (string, error) {
+	p := NewParser(strings.NewReader(src))
+	text := []rune(src)
+
+	var f formatter
+	for {
+		_, _, _ = p.peekScan()
+		start := p.pos
+
+		stmt, err := p.ParseStatement()
+		if err == io.EOF {
+			break
+		} else if errors.Is(err, EmptyStmt) {
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		end := p.pos
+		if p.tok == EOF {
+			end.Offset = len(text)
+		} else {
+			end.Offset++
+		}
+
+		comments := p.Comments()
+		for ; f.next < len(comments) && comments[f.next].Pos.Offset < start.Offset; f.next++ {
+			f.writeComment(comments[f.next])
+		}
+
+		// Keep the original text if a comment falls inside the statement.
+		s := formatStatement(stmt)
+		for ; f.next < len(comments) && comments[f.next].Pos.Offset < end.Offset; f.next++ {
+			s = ""
+		}
+		if s == "" {
+			s = strings.TrimSuffix(strings.TrimSpace(string(text[start.Offset:end.Offset])), ";")
+		}
+		f.writeStatement(s, end.Line)
+	}
+
+	for _, c := range p.Comments()[f.next:] {
+		f.writeComment(c)
+	}
+	if f.buf.Len() != 0 {
+		f.buf.WriteString("\n")
+	}
+	return f.buf.String(), nil
+}
+
+type formatter struct {
+	buf  strings.Builder
+	next int // index of the next comment to write
+
+	afterStmt bool // true if the last write ended a statement
+	line      int  // line the last statement ended on
+}
+
+func (f *formatter) writeComment(c Comment) {
+	switch {
+	case f.afterStmt && c.Pos.Line == f.line:
+		f.buf.WriteString(" ")
+	case f.afterStmt:
+		f.buf.WriteString("\n\n")
+	case f.buf.Len() != 0:
+		f.buf.WriteString("\n")
+	}
+	f.buf.WriteString(c.Text)
+	f.afterStmt = f.afterStmt && c.Pos.Line == f.line
+}
+
+func (f *formatter) writeStatement(s string, line int) {
+	if f.afterStmt {
+		f.buf.WriteString("\n\n")
+	} else if f.buf.Len() != 0 {
+		f.buf.WriteString("\n")
+	}
+	f.buf.WriteString(s)
+	f.buf.WriteString(";")
+	f.afterStmt, f.line = true, line
+}
+
+// formatStatement returns the canonical text of stmt, or a blank string if
+// that text does not parse back to an equal statement.
+func formatStatement(stmt Statement) string {
+	s := stmt.String()
+	other, err := NewParser(strings.NewReader(s + ";")).ParseStatement()
+	if err != nil || !Equal(stmt, other, IgnorePositions()) {
+		return ""
+	}
+	return s
+}
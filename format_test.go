@@ -0,0 +1,82 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+)
+
+func TestFormat(t *testing.T) {
+	t.Run("Canonical", func(t *testing.T) {
+		AssertFormat(t, "select a,b from t where x=1;select 2",
+			"SELECT a, b FROM t WHERE x = 1;\n\nSELECT 2;\n")
+		AssertFormat(t, "insert overwrite table t select *   from s ;",
+			"INSERT OVERWRITE TABLE t SELECT * FROM s;\n")
+		AssertFormat(t, "select * from a right outer join b on a.id=b.id left semi join c on a.id=c.id anti join d using (id)",
+			"SELECT * FROM a RIGHT OUTER JOIN b ON a.id = b.id LEFT SEMI JOIN c ON a.id = c.id ANTI JOIN d USING (id);\n")
+		AssertFormat(t, "select sum(x) over (partition by a order by b rows between unbounded preceding and current row exclude no others) from t",
+			"SELECT sum(x) OVER (PARTITION BY a ORDER BY b ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW EXCLUDE NO OTHERS) FROM t;\n")
+		AssertFormat(t, "select avg(x) over (w range between interval 7 DAY preceding and current row) from t window w as (order by d)",
+			"SELECT avg(x) OVER (w RANGE BETWEEN INTERVAL 7 DAY PRECEDING AND CURRENT ROW) FROM t WINDOW w AS (ORDER BY d);\n")
+		AssertFormat(t, "select count(*) over (groups 2 following exclude current row) from t",
+			"SELECT count(*) OVER (GROUPS 2 FOLLOWING EXCLUDE CURRENT ROW) FROM t;\n")
+		AssertFormat(t, "select group_concat(distinct a order by b separator ';'), count(*) filter (where c) from t",
+			"SELECT group_concat(DISTINCT a ORDER BY b SEPARATOR ';'), count(*) FILTER (WHERE c) FROM t;\n")
+		AssertFormat(t, "select last_value(a) ignore nulls over (order by b), percentile_cont(0.5) within group (order by c) from t",
+			"SELECT last_value(a) IGNORE NULLS OVER (ORDER BY b), percentile_cont(0.5) WITHIN GROUP (ORDER BY c) FROM t;\n")
+		AssertFormat(t, "insert overwrite table t partition (dt) select a, dt from s distribute by dt sort by a desc",
+			"INSERT OVERWRITE TABLE t PARTITION (dt) SELECT a, dt FROM s DISTRIBUTE BY dt SORT BY a DESC;\n")
+		AssertFormat(t, "select a from s union all select a from u cluster by a limit 10",
+			"SELECT a FROM s UNION ALL SELECT a FROM u CLUSTER BY a LIMIT 10;\n")
+		AssertFormat(t, "select a, b, grouping_id(a, b) from t group by rollup(a, b), cube(c), grouping sets ((a, c), ())",
+			"SELECT a, b, grouping_id(a, b) FROM t GROUP BY ROLLUP (a, b), CUBE (c), GROUPING SETS ((a, c), ());\n")
+		AssertFormat(t, "select * from t as a pivot (sum(x) as s, max(y) for (c, d) in ((1, 'a') as one, (2, 'b'))) as p",
+			"SELECT * FROM t AS a PIVOT (sum(x) AS s, max(y) FOR (c, d) IN ((1, 'a') AS one, (2, 'b'))) AS p;\n")
+		AssertFormat(t, "select * from (select a, b from t) as s unpivot include nulls ((x, y) for n in ((a, b) as ab, (b, a)))",
+			"SELECT * FROM (SELECT a, b FROM t) AS s UNPIVOT INCLUDE NULLS ((x, y) FOR n IN ((a, b) AS ab, (b, a)));\n")
+		AssertFormat(t, "select x, off from t as a cross join unnest(a.arr) as x with offset as off",
+			"SELECT x, off FROM t AS a CROSS JOIN UNNEST(a.arr) AS x WITH OFFSET AS off;\n")
+		AssertFormat(t, "select * from t tablesample system (10 percent), u tablesample (bucket 1 out of 4 on rand()) as s",
+			"SELECT * FROM t TABLESAMPLE system (10 PERCENT), u TABLESAMPLE (BUCKET 1 OUT OF 4 ON rand()) AS s;\n")
+		AssertFormat(t, "select * from a join b on a.id = b.id lateral view outer explode(b.arr) t as x, y",
+			"SELECT * FROM a JOIN b ON a.id = b.id LATERAL VIEW OUTER explode(b.arr) t AS x, y;\n")
+		AssertFormat(t, ";;", "")
+	})
+
+	t.Run("Comments", func(t *testing.T) {
+		AssertFormat(t, "-- head\nselect 1; -- trailing\n-- lead\nselect  2 ;\n-- end",
+			"-- head\nSELECT 1; -- trailing\n\n-- lead\nSELECT 2;\n\n-- end\n")
+		AssertFormat(t, "/* a */ select 1",
+			"/* a */\nSELECT 1;\n")
+	})
+
+	t.Run("KeepOriginal", func(t *testing.T) {
+		AssertFormat(t, "select a -- inner\nfrom t;select b from t",
+			"select a -- inner\nfrom t;\n\nSELECT b FROM t;\n")
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		out := AssertFormat(t, "-- x\nselect a from t; -- y\nselect b -- z\n from u;",
+			"-- x\nSELECT a FROM t; -- y\n\nselect b -- z\n from u;\n")
+		AssertFormat(t, out, out)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := query.Format("select from")
+		assert.Error(t, err)
+	})
+}
+
+// AssertFormat asserts that src formats as want and returns the output.
+func AssertFormat(tb testing.TB, src, want string) string {
+	tb.Helper()
+
+	out, err := query.Format(src)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	assert.Equal(tb, want, out)
+	return out
+}
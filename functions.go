@@ -0,0 +1,356 @@
+package query
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func init() {
+	RegisterFunc("CONCAT", fnConcat)
+	RegisterFunc("COALESCE", fnCoalesce)
+	RegisterFunc("NVL", fnNvl)
+	RegisterFunc("IF", fnIf)
+	RegisterFunc("UPPER", stringFunc(strings.ToUpper))
+	RegisterFunc("LOWER", stringFunc(strings.ToLower))
+	RegisterFunc("TRIM", stringFunc(strings.TrimSpace))
+	RegisterFunc("LENGTH", fnLength)
+	RegisterFunc("SUBSTR", fnSubstr)
+	RegisterFunc("SUBSTRING", fnSubstr)
+	RegisterFunc("REPLACE", fnReplace)
+	RegisterFunc("ABS", fnAbs)
+	RegisterFunc("DATE_ADD", dateAddFunc(1))
+	RegisterFunc("DATE_SUB", dateAddFunc(-1))
+	RegisterFunc("DATEADD", fnDateAdd)
+	RegisterFunc("DATEDIFF", fnDateDiff)
+	RegisterFunc("TO_DATE", fnToDate)
+	RegisterFunc("TO_CHAR", fnToChar)
+}
+
+func checkArgs(args []Value, minArgs, maxArgs int) error {
+	if len(args) < minArgs || len(args) > maxArgs {
+		if minArgs == maxArgs {
+			return fmt.Errorf("expected %d arguments, found %d", minArgs, len(args))
+		}
+		return fmt.Errorf("expected %d to %d arguments, found %d", minArgs, maxArgs, len(args))
+	}
+	return nil
+}
+
+func hasNull(args []Value) bool {
+	for _, arg := range args {
+		if arg == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func fnConcat(args []Value) (Value, error) {
+	if hasNull(args) {
+		return nil, nil
+	}
+	var buf strings.Builder
+	for _, arg := range args {
+		buf.WriteString(valueString(arg))
+	}
+	return buf.String(), nil
+}
+
+func fnCoalesce(args []Value) (Value, error) {
+	for _, arg := range args {
+		if arg != nil {
+			return arg, nil
+		}
+	}
+	return nil, nil
+}
+
+func fnNvl(args []Value) (Value, error) {
+	if err := checkArgs(args, 2, 2); err != nil {
+		return nil, err
+	}
+	return fnCoalesce(args)
+}
+
+func fnIf(args []Value) (Value, error) {
+	if err := checkArgs(args, 2, 3); err != nil {
+		return nil, err
+	}
+	if b, ok := args[0].(bool); ok && b {
+		return args[1], nil
+	} else if !ok && args[0] != nil {
+		return nil, fmt.Errorf("expected boolean, found %s", typeName(args[0]))
+	}
+	if len(args) == 3 {
+		return args[2], nil
+	}
+	return nil, nil
+}
+
+func stringFunc(fn func(string) string) Func {
+	return func(args []Value) (Value, error) {
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		if args[0] == nil {
+			return nil, nil
+		}
+		return fn(valueString(args[0])), nil
+	}
+}
+
+func fnLength(args []Value) (Value, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	return int64(utf8.RuneCountInString(valueString(args[0]))), nil
+}
+
+// fnSubstr implements SUBSTR(str, start[, length]) with a 1-based start.
+// A negative start counts from the end of the string.
+func fnSubstr(args []Value) (Value, error) {
+	if err := checkArgs(args, 2, 3); err != nil {
+		return nil, err
+	}
+	if hasNull(args) {
+		return nil, nil
+	}
+
+	s := []rune(valueString(args[0]))
+	start, ok := args[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("expected integer start, found %s", typeName(args[1]))
+	}
+	if start < 0 {
+		start = int64(len(s)) + start + 1
+	}
+	if start < 1 {
+		start = 1
+	}
+	if start > int64(len(s)) {
+		return "", nil
+	}
+
+	end := int64(len(s))
+	if len(args) == 3 {
+		n, ok := args[2].(int64)
+		if !ok {
+			return nil, fmt.Errorf("expected integer length, found %s", typeName(args[2]))
+		}
+		if n < 0 {
+			return "", nil
+		}
+		end = min(start-1+n, end)
+	}
+	return string(s[start-1 : end]), nil
+}
+
+func fnReplace(args []Value) (Value, error) {
+	if err := checkArgs(args, 3, 3); err != nil {
+		return nil, err
+	}
+	if hasNull(args) {
+		return nil, nil
+	}
+	return strings.ReplaceAll(valueString(args[0]), valueString(args[1]), valueString(args[2])), nil
+}
+
+func fnAbs(args []Value) (Value, error) {
+	if err := checkArgs(args, 1, 1); err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return nil, nil
+	}
+	switch n, _ := toNumber(args[0]); n := n.(type) {
+	case int64:
+		if n < 0 {
+			return -n, nil
+		}
+		return n, nil
+	case float64:
+		return math.Abs(n), nil
+	}
+	return nil, fmt.Errorf("expected number, found %s", typeName(args[0]))
+}
+
+// dateArg converts v to a time. It also returns the layout of v when v is
+// a string, so that the result of a date function can keep the input format.
+func dateArg(v Value) (time.Time, string, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, "", nil
+	case string:
+		return parseTime(v)
+	default:
+		return time.Time{}, "", fmt.Errorf("expected datetime, found %s", typeName(v))
+	}
+}
+
+func dateResult(t time.Time, layout string) Value {
+	if layout == "" {
+		return t
+	}
+	return t.Format(layout)
+}
+
+// dateAddFunc implements DATE_ADD(date, days) and DATE_SUB(date, days).
+func dateAddFunc(sign int64) Func {
+	return func(args []Value) (Value, error) {
+		if err := checkArgs(args, 2, 2); err != nil {
+			return nil, err
+		}
+		if hasNull(args) {
+			return nil, nil
+		}
+		t, layout, err := dateArg(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		switch delta := args[1].(type) {
+		case int64:
+			return dateResult(t.AddDate(0, 0, int(sign*delta)), layout), nil
+		case Interval:
+			delta.Value *= sign
+			t, err := addInterval(t, delta)
+			if err != nil {
+				return nil, err
+			}
+			return dateResult(t, layout), nil
+		default:
+			return nil, fmt.Errorf("expected integer or interval, found %s", typeName(args[1]))
+		}
+	}
+}
+
+// fnDateAdd implements DATEADD(date, delta, unit) where unit is one of
+// yyyy, mm, dd, hh, mi or ss.
+func fnDateAdd(args []Value) (Value, error) {
+	if err := checkArgs(args, 3, 3); err != nil {
+		return nil, err
+	}
+	if hasNull(args) {
+		return nil, nil
+	}
+	t, layout, err := dateArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	delta, ok := args[1].(int64)
+	if !ok {
+		return nil, fmt.Errorf("expected integer delta, found %s", typeName(args[1]))
+	}
+	t, err = addInterval(t, Interval{Value: delta, Unit: valueString(args[2])})
+	if err != nil {
+		return nil, err
+	}
+	return dateResult(t, layout), nil
+}
+
+// fnDateDiff implements DATEDIFF(end, start[, unit]) returning the
+// difference in days unless another unit is given.
+func fnDateDiff(args []Value) (Value, error) {
+	if err := checkArgs(args, 2, 3); err != nil {
+		return nil, err
+	}
+	if hasNull(args) {
+		return nil, nil
+	}
+	end, _, err := dateArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	start, _, err := dateArg(args[1])
+	if err != nil {
+		return nil, err
+	}
+
+	unit := "dd"
+	if len(args) == 3 {
+		unit = strings.ToLower(valueString(args[2]))
+	}
+	switch unit {
+	case "yyyy", "year":
+		return int64(end.Year() - start.Year()), nil
+	case "mm", "month":
+		return int64((end.Year()-start.Year())*12 + int(end.Month()) - int(start.Month())), nil
+	case "dd", "day":
+		return int64(end.Sub(start).Hours() / 24), nil
+	case "hh", "hour":
+		return int64(end.Sub(start).Hours()), nil
+	case "mi", "minute":
+		return int64(end.Sub(start).Minutes()), nil
+	case "ss", "second":
+		return int64(end.Sub(start).Seconds()), nil
+	default:
+		return nil, fmt.Errorf("unsupported unit %s", unit)
+	}
+}
+
+// fnToDate implements TO_DATE(str[, format]).
+func fnToDate(args []Value) (Value, error) {
+	if err := checkArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+	if hasNull(args) {
+		return nil, nil
+	}
+	if t, ok := args[0].(time.Time); ok {
+		return t, nil
+	}
+
+	s := valueString(args[0])
+	if len(args) == 1 {
+		t, _, err := parseTime(s)
+		return t, err
+	}
+	return time.Parse(goLayout(valueString(args[1])), s)
+}
+
+// fnToChar implements TO_CHAR(value[, format]). Datetimes and datetime
+// strings are formatted with format; without one, value is converted to
+// a string.
+func fnToChar(args []Value) (Value, error) {
+	if err := checkArgs(args, 1, 2); err != nil {
+		return nil, err
+	}
+	if hasNull(args) {
+		return nil, nil
+	}
+
+	if len(args) == 1 {
+		return valueString(args[0]), nil
+	}
+	t, _, err := dateArg(args[0])
+	if err != nil {
+		return nil, err
+	}
+	return t.Format(goLayout(valueString(args[1]))), nil
+}
+
+// goLayout converts a SQL datetime format such as "yyyy-mm-dd hh:mi:ss"
+// to a Go time layout.
+func goLayout(format string) string {
+	replacer := strings.NewReplacer(
+		"yyyy", "2006",
+		"YYYY", "2006",
+		"mm", "01",
+		"MM", "01",
+		"dd", "02",
+		"DD", "02",
+		"hh", "15",
+		"HH", "15",
+		"mi", "04",
+		"MI", "04",
+		"ss", "05",
+		"SS", "05",
+	)
+	return replacer.Replace(format)
+}
@@ -0,0 +1,283 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// JSONVersion is the version of the encoding written by MarshalStatement.
+// It is incremented whenever the encoding of a node changes, including new
+// fields and node types. UnmarshalStatement rejects every other version, as
+// it cannot decode their shapes exactly.
+const JSONVersion = 5
+
+// nodeTypes maps the "@type" discriminator of an encoded node to its Go type.
+var nodeTypes = make(map[string]reflect.Type)
+
+func init() {
+	for _, n := range []Node{
+		// Expressions
+		&BinaryExpr{}, &Call{}, &CaseBlock{}, &CaseExpr{}, &CastExpr{}, &Null{}, &ExprList{},
+		&Exists{}, &Ident{}, &MultiPartIdent{}, &Params{}, &ParenExpr{}, &Range{},
+		&QualifiedRef{}, &Type{}, &UnaryExpr{}, &IndexExpr{}, SelectExpr{},
+
+		// Literals
+		&BoolLit{}, &IntervalLit{}, &NullLit{}, &NumberLit{}, &RawLit{}, &StringLit{},
+		&TimestampLit{}, &TemplateStr{},
+
+		// Select
+		&SelectStatement{}, &SetOperation{}, &WithClause{}, &CTE{}, &Within{}, &ResultColumn{}, &LateralView{},
+		&QualifiedTableName{}, &ParenSource{}, &JoinClause{}, &JoinOperator{}, &OnConstraint{},
+		&UsingConstraint{}, &QualifiedTableFunctionName{}, &OverClause{}, &OrderingTerm{},
+		&Window{}, &WindowDefinition{}, &FrameSpec{}, &FrameBound{}, &GroupingSets{}, &Rollup{}, &Cube{},
+		&PivotSource{}, &UnpivotSource{}, &PivotExpr{}, &UnnestSource{}, &SampleSource{},
+
+		// Statements
+		&DeclarationStatement{}, &DeleteStatement{}, &InsertStatement{}, &PartitionSpec{}, &PartitionColumn{}, &SetStatement{},
+		&CreateTableStatement{}, &DropTableStatement{}, &MergeStatement{}, &FunctionStatement{},
+		&TruncateStatement{}, &UpsertClause{}, &ReturningClause{}, &Assignment{}, &IndexedColumn{},
+		&ColumnDefinition{}, &MatchedCondition{},
+	} {
+		typ := reflect.TypeOf(n)
+		nodeTypes[nodeTypeName(typ)] = typ
+	}
+}
+
+var tokenType = reflect.TypeOf(Token(0))
+
+type jsonEnvelope struct {
+	Version   int             `json:"version"`
+	Statement json.RawMessage `json:"statement"`
+}
+
+// MarshalStatement encodes stmt as versioned JSON:
+//
+//	{"version": 5, "statement": {"@type": "SelectStatement", ...}}
+//
+// Every node is an object whose "@type" key names the node, followed by its
+// fields under their json tag names. Tokens are encoded by name, e.g. "AND"
+// or ">=". Unset fields are omitted.
+func MarshalStatement(stmt Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, reflect.ValueOf(stmt)); err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonEnvelope{Version: JSONVersion, Statement: buf.Bytes()})
+}
+
+// UnmarshalStatement decodes a statement encoded by MarshalStatement with
+// the current JSONVersion.
+func UnmarshalStatement(data []byte) (Statement, error) {
+	var env jsonEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	} else if env.Version == 0 {
+		return nil, fmt.Errorf("missing json version")
+	} else if env.Version != JSONVersion {
+		return nil, fmt.Errorf("unsupported json version %d, expected %d", env.Version, JSONVersion)
+	}
+
+	var stmt Statement
+	if err := decodeJSON(env.Statement, reflect.ValueOf(&stmt).Elem()); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func encodeJSON(buf *bytes.Buffer, v reflect.Value) error {
+	switch {
+	case !v.IsValid() || isNilValue(v):
+		buf.WriteString("null")
+		return nil
+	case v.Type() == tokenType:
+		return writeJSON(buf, v.Interface().(Token).String())
+	case v.Type() == posType:
+		return writeJSON(buf, v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return encodeJSON(buf, v.Elem())
+	case reflect.Ptr:
+		if v.Elem().Kind() != reflect.Struct {
+			return encodeJSON(buf, v.Elem())
+		}
+		return encodeJSONStruct(buf, v.Elem())
+	case reflect.Struct:
+		return encodeJSONStruct(buf, v)
+	case reflect.Slice:
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSON(buf, v.Index(i)); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	default:
+		return writeJSON(buf, v.Interface())
+	}
+}
+
+func encodeJSONStruct(buf *bytes.Buffer, v reflect.Value) error {
+	buf.WriteString(`{"@type":`)
+	if err := writeJSON(buf, v.Type().Name()); err != nil {
+		return err
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).IsZero() {
+			continue
+		}
+
+		buf.WriteByte(',')
+		if err := writeJSON(buf, fieldName(v.Type().Field(i))); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := encodeJSON(buf, v.Field(i)); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func writeJSON(buf *bytes.Buffer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	buf.Write(b)
+	return nil
+}
+
+// decodeJSON decodes data into v, which must be settable.
+func decodeJSON(data json.RawMessage, v reflect.Value) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	switch {
+	case v.Type() == tokenType:
+		var name string
+		if err := json.Unmarshal(data, &name); err != nil {
+			return err
+		}
+		tok, ok := tokenNames[name]
+		if !ok {
+			return fmt.Errorf("unknown token %q", name)
+		}
+		v.Set(reflect.ValueOf(tok))
+		return nil
+	case v.Type() == posType:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		typ, err := decodeJSONType(data)
+		if err != nil {
+			return err
+		} else if !typ.Implements(v.Type()) {
+			return fmt.Errorf("node type %s is not a %s", nodeTypeName(typ), v.Type().Name())
+		}
+
+		n := reflect.New(typ).Elem()
+		if err := decodeJSON(data, n); err != nil {
+			return err
+		}
+		v.Set(n)
+		return nil
+
+	case reflect.Ptr:
+		if v.Type().Elem().Kind() != reflect.Struct {
+			n := reflect.New(v.Type().Elem())
+			if err := decodeJSON(data, n.Elem()); err != nil {
+				return err
+			}
+			v.Set(n)
+			return nil
+		}
+
+		typ, err := decodeJSONType(data)
+		if err != nil {
+			return err
+		} else if typ != v.Type() {
+			return fmt.Errorf("unexpected node type %s, expected %s", nodeTypeName(typ), nodeTypeName(v.Type()))
+		}
+
+		n := reflect.New(v.Type().Elem())
+		if err := decodeJSONStruct(data, n.Elem()); err != nil {
+			return err
+		}
+		v.Set(n)
+		return nil
+
+	case reflect.Struct:
+		return decodeJSONStruct(data, v)
+
+	case reflect.Slice:
+		var elems []json.RawMessage
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return err
+		}
+
+		s := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, elem := range elems {
+			if err := decodeJSON(elem, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+
+	default:
+		return json.Unmarshal(data, v.Addr().Interface())
+	}
+}
+
+func decodeJSONStruct(data json.RawMessage, v reflect.Value) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		name := fieldName(v.Type().Field(i))
+		if err := decodeJSON(fields[name], v.Field(i)); err != nil {
+			return fmt.Errorf("%s.%s: %w", v.Type().Name(), name, err)
+		}
+	}
+	return nil
+}
+
+// decodeJSONType returns the Go type named by the "@type" key of data.
+func decodeJSONType(data json.RawMessage) (reflect.Type, error) {
+	var obj struct {
+		Type string `json:"@type"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, err
+	} else if obj.Type == "" {
+		return nil, fmt.Errorf("missing node type")
+	}
+
+	typ, ok := nodeTypes[obj.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown node type %q", obj.Type)
+	}
+	return typ, nil
+}
+
+func nodeTypeName(typ reflect.Type) string {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Name()
+}
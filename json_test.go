@@ -0,0 +1,99 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+)
+
+func TestMarshalStatement(t *testing.T) {
+	t.Run("Encoding", func(t *testing.T) {
+		buf, err := query.MarshalStatement(ParseStatement(t, `SELECT a >= 1`))
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"version": 5,
+			"statement": {
+				"@type": "SelectStatement",
+				"select": {"offset": 0, "line": 1, "column": 1},
+				"columns": [{
+					"@type": "ResultColumn",
+					"expr": {
+						"@type": "BinaryExpr",
+						"x": {
+							"@type": "MultiPartIdent",
+							"name": {"@type": "Ident", "name_pos": {"offset": 7, "line": 1, "column": 8}, "name": "a", "tok": "IDENT"}
+						},
+						"op_pos": {"offset": 9, "line": 1, "column": 10},
+						"op": ">=",
+						"y": {"@type": "NumberLit", "value_pos": {"offset": 12, "line": 1, "column": 13}, "value": "1"}
+					}
+				}]
+			}
+		}`, string(buf))
+	})
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		AssertJSONRoundTrip(t, `WITH c (x) AS (SELECT x FROM s) SELECT DISTINCT a, f(b) OVER (PARTITION BY c ORDER BY d DESC) FROM t LEFT JOIN c ON t.id = c.id WHERE d IN (1, 2) AND e <=> NULL GROUP BY a HAVING count(*) > 1 ORDER BY a LIMIT 10`)
+		AssertJSONRoundTrip(t, `SELECT a, count(*) OVER w FROM t LATERAL VIEW OUTER explode(arr) v AS x, y JOIN u USING (id) WINDOW w AS (PARTITION BY a)`)
+		AssertJSONRoundTrip(t, `SELECT sum(a) OVER (w ROWS BETWEEN 1 PRECEDING AND UNBOUNDED FOLLOWING EXCLUDE GROUP) FROM t WINDOW w AS (ORDER BY a)`)
+		AssertJSONRoundTrip(t, `SELECT array_agg(a IGNORE NULLS ORDER BY b LIMIT 1), count(*) FILTER (WHERE c > 0), string_agg(d, ',') WITHIN GROUP (ORDER BY d) FROM t`)
+		AssertJSONRoundTrip(t, `SELECT a, GROUPING(a) FROM t GROUP BY b, ROLLUP (a, (b, c)), CUBE (d), GROUPING SETS ((a), (), e)`)
+		AssertJSONRoundTrip(t, `SELECT a FROM t GROUP BY a, b GROUPING SETS ((a, b), a)`)
+		AssertJSONRoundTrip(t, `SELECT a FROM t GROUP BY a, b WITH ROLLUP`)
+		AssertJSONRoundTrip(t, `SELECT * EXCEPT (a) FROM (SELECT a, b FROM t) AS s WHERE NOT EXISTS (SELECT 1 FROM u) AND b BETWEEN 1 AND 2 AND c IS NOT NULL`)
+		AssertJSONRoundTrip(t, `SELECT CASE WHEN a THEN 'x' ELSE CAST(b AS DECIMAL(10,2)) END, DATE '2024-01-01', arr[0], INTERVAL 1 DAY, r'raw', "q", `+"`tick`"+`, TRUE FROM t`)
+		AssertJSONRoundTrip(t, `SELECT * FROM t UNPIVOT EXCLUDE NULLS (v FOR n IN (a, b AS c)) u PIVOT (sum(v) AS s FOR n IN ('a', 'c' AS x)) AS p`)
+		AssertJSONRoundTrip(t, `SELECT * FROM t UNPIVOT (v FOR n IN (a AS 'x', b AS 'y'))`)
+		AssertJSONRoundTrip(t, `SELECT x FROM t TABLESAMPLE (BUCKET 1 OUT OF 4 ON id) s, UNNEST(s.arr) x WITH OFFSET o, u TABLESAMPLE BERNOULLI (5 ROWS)`)
+		AssertJSONRoundTrip(t, `SELECT a FROM t UNION ALL SELECT a FROM u`)
+		AssertJSONRoundTrip(t, `(SELECT a FROM t ORDER BY a LIMIT 1) EXCEPT SELECT a FROM u INTERSECT ALL SELECT a FROM v ORDER BY a`)
+		AssertJSONRoundTrip(t, `INSERT OVERWRITE TABLE t (a, b) SELECT a, b FROM s`)
+		AssertJSONRoundTrip(t, `INSERT OVERWRITE TABLE t PARTITION (pt = '${dt}', region) SELECT a, region FROM s`)
+		AssertJSONRoundTrip(t, `INSERT OVERWRITE TABLE t SELECT a, b FROM s DISTRIBUTE BY a SORT BY b DESC`)
+		AssertJSONRoundTrip(t, `INSERT INTO t VALUES (1, 'a'), (2, 'b')`)
+		AssertJSONRoundTrip(t, `DELETE FROM t WHERE a = 1`)
+		AssertJSONRoundTrip(t, `CREATE TABLE IF NOT EXISTS t (a BIGINT, b STRING)`)
+		AssertJSONRoundTrip(t, `DROP TABLE IF EXISTS t`)
+		AssertJSONRoundTrip(t, `TRUNCATE TABLE t`)
+		AssertJSONRoundTrip(t, `SET odps.sql.allow=true;`)
+		AssertJSONRoundTrip(t, `@start_date := DATE '{{ .DSTART | Date }}';`)
+		AssertJSONRoundTrip(t, `MERGE INTO t USING s ON t.id = s.id WHEN MATCHED AND s.v > 1 THEN UPDATE SET t.v = s.v WHEN NOT MATCHED THEN INSERT (id) VALUES (s.id)`)
+		AssertJSONRoundTrip(t, `FUNCTION f (@n BIGINT) AS @n + 1`)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		AssertUnmarshalStatementError(t, `{"statement": {"@type": "SelectStatement"}}`, `missing json version`)
+		AssertUnmarshalStatementError(t, `{"version": 99, "statement": {"@type": "SelectStatement"}}`, `unsupported json version 99, expected 5`)
+		AssertUnmarshalStatementError(t, `{"version": 4, "statement": {"@type": "SelectStatement", "compound": {"@type": "SelectStatement"}}}`, `unsupported json version 4, expected 5`)
+		AssertUnmarshalStatementError(t, `{"version": 5, "statement": {"@type": "Bogus"}}`, `unknown node type "Bogus"`)
+		AssertUnmarshalStatementError(t, `{"version": 5, "statement": {"@type": "Ident"}}`, `node type Ident is not a Statement`)
+		AssertUnmarshalStatementError(t, `{"version": 5, "statement": {"@type": "SelectStatement", "columns": [{"@type": "Ident"}]}}`,
+			`SelectStatement.columns: unexpected node type Ident, expected ResultColumn`)
+		AssertUnmarshalStatementError(t, `{"version": 5, "statement": {"@type": "BinaryExpr"}}`, `node type BinaryExpr is not a Statement`)
+		AssertUnmarshalStatementError(t, `{"version": 5, "statement": {"@type": "DropTableStatement", "name": {"@type": "MultiPartIdent", "name": {"@type": "Ident", "tok": "NOPE"}}}}`,
+			`unknown token "NOPE"`)
+	})
+}
+
+// AssertJSONRoundTrip asserts that s decodes to the same statement it was encoded from.
+func AssertJSONRoundTrip(tb testing.TB, s string) {
+	tb.Helper()
+
+	stmt := ParseStatement(tb, s)
+	buf, err := query.MarshalStatement(stmt)
+	assert.NoError(tb, err)
+
+	other, err := query.UnmarshalStatement(buf)
+	assert.NoError(tb, err)
+	assert.Equal(tb, stmt, other)
+}
+
+// AssertUnmarshalStatementError asserts that decoding s returns an error containing want.
+func AssertUnmarshalStatementError(tb testing.TB, s string, want string) {
+	tb.Helper()
+
+	_, err := query.UnmarshalStatement([]byte(s))
+	assert.ErrorContains(tb, err, want)
+}
@@ -1,6 +1,11 @@
 package lineage
 
-import "github.com/sbchaos/query"
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbchaos/query"
+)
 
 type Column struct {
 	Ref  string `json:"ref"`
@@ -9,6 +14,15 @@ type Column struct {
 	Transform string `json:"transform"`
 }
 
+// GeneratedColumn is a column generated by a PIVOT, an UNPIVOT, an UNNEST
+// or a lateral view. It holds the columns read for it and, for pivots, the
+// pivot value Value.
+type GeneratedColumn struct {
+	Name    string   `json:"name"`
+	Value   string   `json:"value"`
+	Columns []Column `json:"columns"`
+}
+
 func processColumn(col *query.ResultColumn) []Column {
 	if col.Star.IsValid() {
 		return []Column{{Name: "*"}}
@@ -36,8 +50,8 @@ func processExpr(expr query.Expr) []Column {
 
 	case *query.CastExpr:
 		cols := processExpr(ex.X)
-		for _, c := range cols {
-			c.Transform = ex.String()
+		for i := range cols {
+			cols[i].Transform = ex.String()
 		}
 		return cols
 
@@ -56,16 +70,16 @@ func processExpr(expr query.Expr) []Column {
 
 	case *query.UnaryExpr:
 		cols := processExpr(ex.X)
-		for _, c := range cols {
-			c.Transform = ex.String()
+		for i := range cols {
+			cols[i].Transform = ex.String()
 		}
 		return cols
 
 	case *query.IndexExpr:
 		cols := processExpr(ex.X)
 		transform := ex.String()
-		for _, c := range cols {
-			c.Transform = transform
+		for i := range cols {
+			cols[i].Transform = transform
 		}
 		return cols
 	// Multiple return expr
@@ -73,8 +87,8 @@ func processExpr(expr query.Expr) []Column {
 		cols := processExpr(ex.X)
 		cols = append(cols, processExpr(ex.Y)...)
 		transform := ex.String()
-		for _, col := range cols {
-			col.Transform = transform
+		for i := range cols {
+			cols[i].Transform = transform
 		}
 		return cols
 
@@ -85,8 +99,8 @@ func processExpr(expr query.Expr) []Column {
 			cols = append(cols, processExpr(cs)...)
 		}
 		transform := ex.String()
-		for _, col := range cols {
-			col.Transform = transform
+		for i := range cols {
+			cols[i].Transform = transform
 		}
 		return cols
 
@@ -99,9 +113,10 @@ func processExpr(expr query.Expr) []Column {
 			cols = append(cols, processExpr(c1.Body)...)
 		}
 		transform := ex.String()
-		for _, col := range cols {
-			col.Transform = transform
+		for i := range cols {
+			cols[i].Transform = transform
 		}
+		return cols
 
 	case *query.Call:
 		cols := []Column{}
@@ -109,8 +124,8 @@ func processExpr(expr query.Expr) []Column {
 			cols = append(cols, processExpr(a.X)...)
 		}
 		transform := ex.String()
-		for _, col := range cols {
-			col.Transform = transform
+		for i := range cols {
+			cols[i].Transform = transform
 		}
 		return cols
 
@@ -119,8 +134,8 @@ func processExpr(expr query.Expr) []Column {
 		cols = append(cols, processExpr(ex.X)...)
 		cols = append(cols, processExpr(ex.Y)...)
 		transform := ex.String()
-		for _, col := range cols {
-			col.Transform = transform
+		for i := range cols {
+			cols[i].Transform = transform
 		}
 		return cols
 
@@ -130,3 +145,118 @@ func processExpr(expr query.Expr) []Column {
 
 	return nil
 }
+
+// isGroupingID returns true if expr is GROUPING_ID() without arguments, or
+// Hive's GROUPING__ID, which depend on every grouping expression.
+func isGroupingID(expr query.Expr) bool {
+	switch ex := expr.(type) {
+	case *query.Call:
+		return len(ex.Args) == 0 && ex.Name != nil && ex.Name.First == nil && strings.EqualFold(ex.Name.Name.Name, "GROUPING_ID")
+	case *query.MultiPartIdent:
+		return ex.First == nil && ex.Name != nil && strings.EqualFold(ex.Name.Name, "GROUPING__ID")
+	}
+	return false
+}
+
+// groupingColumns returns the columns grouped by sel, transformed by expr.
+func groupingColumns(sel *query.SelectStatement, expr query.Expr) []Column {
+	cols := []Column{}
+	for _, g := range sel.GroupingExprs() {
+		cols = append(cols, processExpr(g)...)
+	}
+	transform := expr.String()
+	for i := range cols {
+		cols[i].Transform = transform
+	}
+	return cols
+}
+
+// pivotColumns returns a column for each value and aggregate of src.
+func pivotColumns(src *query.PivotSource) []GeneratedColumn {
+	var cols []GeneratedColumn
+	for _, value := range src.Values {
+		for _, agg := range src.Aggregates {
+			cols = append(cols, GeneratedColumn{
+				Name:    src.ColumnName(value, agg),
+				Value:   value.X.String(),
+				Columns: processExpr(agg.X),
+			})
+		}
+	}
+	return cols
+}
+
+// unpivotColumns returns a column for each value column of src and each
+// listed column it takes values from, and a name column, reading nothing,
+// for each listed column.
+func unpivotColumns(src *query.UnpivotSource) []GeneratedColumn {
+	values := listExprs(src.Value)
+	var cols []GeneratedColumn
+	for _, col := range src.Columns {
+		if src.Name != nil {
+			cols = append(cols, GeneratedColumn{Name: src.Name.Name, Value: col.Name()})
+		}
+		for i, expr := range listExprs(col.X) {
+			if i >= len(values) {
+				break
+			}
+			cols = append(cols, GeneratedColumn{
+				Name:    values[i].String(),
+				Value:   col.Name(),
+				Columns: processExpr(expr),
+			})
+		}
+	}
+	return cols
+}
+
+// lateralColumns returns a column for each column alias of src, reading the
+// arguments of its table function. Arguments generated by the lateral views
+// of t before src are resolved to the columns they read.
+func (t *Table) lateralColumns(src *query.LateralView) []GeneratedColumn {
+	var args []Column
+	if src.Udtf != nil {
+		for _, c1 := range processExpr(src.Udtf) {
+			if cols, ok := t.generated(c1); ok {
+				args = append(args, cols...)
+			} else {
+				args = append(args, c1)
+			}
+		}
+	}
+
+	var cols []GeneratedColumn
+	for _, alias := range src.ColAlias {
+		cols = append(cols, GeneratedColumn{Name: alias.Name, Columns: args})
+	}
+	return cols
+}
+
+// unnestColumns returns the element column of src, reading the arrays it
+// unnests, and its offset column, reading nothing. The element column is
+// named by the alias of src; without one it cannot be referenced.
+func unnestColumns(src *query.UnnestSource) []GeneratedColumn {
+	var cols []GeneratedColumn
+	if src.Alias != nil {
+		reads := processExpr(src.X)
+		transform := fmt.Sprintf("UNNEST(%s)", src.X.String())
+		for i := range reads {
+			reads[i].Transform = transform
+		}
+		cols = append(cols, GeneratedColumn{Name: src.Alias.Name, Columns: reads})
+	}
+	if src.OffsetAlias != nil {
+		cols = append(cols, GeneratedColumn{Name: src.OffsetAlias.Name})
+	} else if src.With.IsValid() {
+		cols = append(cols, GeneratedColumn{Name: "offset"})
+	}
+	return cols
+}
+
+// listExprs returns the expressions of a list, or expr itself.
+func listExprs(expr query.Expr) []query.Expr {
+	if list, ok := expr.(*query.ExprList); ok {
+		return list.Exprs
+	}
+	return []query.Expr{expr}
+}
@@ -19,7 +19,28 @@ type Table struct {
 	IsCte    bool     `yaml:"isCte"`
 	Alias    string   `yaml:"alias"`
 
+	// Filter is set on tables joined by a semi or anti join, which filter
+	// the rows of the tables before them but provide no columns.
+	Filter bool `yaml:"filter,omitempty"`
+
 	Columns []Column `yaml:"columns"`
+
+	// Generated holds the columns generated by a PIVOT, an UNPIVOT, an
+	// UNNEST or a lateral view of the table.
+	Generated []GeneratedColumn `yaml:"generated,omitempty"`
+
+	// Target is the table written by an INSERT, with the partition it
+	// writes, if any.
+	Target     *Table      `yaml:"target,omitempty"`
+	Partitions []Partition `yaml:"partitions,omitempty"`
+}
+
+// Partition is a partition column written by an INSERT. Static partitions
+// have a value; dynamic partitions take theirs from the query.
+type Partition struct {
+	Column  string `yaml:"column"`
+	Value   string `yaml:"value,omitempty"`
+	Dynamic bool   `yaml:"dynamic,omitempty"`
 }
 
 func ParseQuery(name string, str string) (*Table, error) {
@@ -33,6 +54,15 @@ func ParseQuery(name string, str string) (*Table, error) {
 		switch stmt := stmt.(type) {
 		case *query.SelectStatement:
 			t.fromSelect(stmt)
+		case *query.InsertStatement:
+			t.Target = insertTarget(stmt)
+			if stmt.Select != nil {
+				t.fromSelect(stmt.Select)
+			}
+		case *query.CreateTableStatement:
+			if stmt.Select != nil {
+				t.fromSelect(stmt.Select)
+			}
 		}
 	}
 	return t, nil
@@ -59,18 +89,24 @@ func (t *Table) fromSelect(sel *query.SelectStatement) {
 		}
 	}
 
-	if sel.Source != nil {
-		t.processSource(sel.Source)
-	}
-
 	if sel.Compound != nil {
+		t.fromSelect(sel.Compound.X)
+
 		t2 := &Table{}
 		t.SubTable = append(t.SubTable, t2)
-		t2.fromSelect(sel.Compound)
+		t2.fromSelect(sel.Compound.Y)
+		return
+	}
+
+	if sel.Source != nil {
+		t.processSource(sel.Source)
 	}
 
 	for _, col := range sel.Columns {
 		cs := processColumn(col)
+		if isGroupingID(col.Expr) {
+			cs = groupingColumns(sel, col.Expr)
+		}
 		for _, c1 := range cs {
 			if c1.Name != "" {
 				t.addColumn(c1)
@@ -107,7 +143,7 @@ func (t *Table) processSource(src query.Source) {
 	switch src := src.(type) {
 	case *query.SelectStatement:
 		t2 := &Table{}
-		t2.SubTable = append(t2.SubTable, t2)
+		t.SubTable = append(t.SubTable, t2)
 		t2.fromSelect(src)
 
 	case *query.JoinClause:
@@ -115,14 +151,14 @@ func (t *Table) processSource(src query.Source) {
 			t.processSource(src.X)
 		}
 		if src.Y != nil {
-			t2 := &Table{}
+			t2 := &Table{Filter: src.Operator != nil && src.Operator.IsFilter()}
 			t.Join = append(t.Join, t2)
 			t2.processSource(src.Y)
 		}
 
 	case *query.ParenSource:
 		t2 := &Table{}
-		t2.SubTable = append(t2.SubTable, t2)
+		t.SubTable = append(t.SubTable, t2)
 		if src.Alias != nil {
 			t2.Alias = src.Alias.String()
 		}
@@ -131,28 +167,121 @@ func (t *Table) processSource(src query.Source) {
 			t2.processSource(src.X)
 		}
 
+	case *query.PivotSource:
+		// Columns the pivot passes through remain those of its source.
+		if src.X != nil {
+			t.processSource(src.X)
+		}
+		if src.Alias != nil {
+			t.Alias = src.Alias.Name
+		}
+		t.Generated = append(t.Generated, pivotColumns(src)...)
+
+	case *query.UnpivotSource:
+		if src.X != nil {
+			t.processSource(src.X)
+		}
+		if src.Alias != nil {
+			t.Alias = src.Alias.Name
+		}
+		t.Generated = append(t.Generated, unpivotColumns(src)...)
+
+	case *query.LateralView:
+		if src.X != nil {
+			t.processSource(src.X)
+		}
+		t2 := &Table{Generated: t.lateralColumns(src)}
+		if src.TableAlias != nil {
+			t2.Alias = src.TableAlias.Name
+		}
+		t.Join = append(t.Join, t2)
+
+	case *query.UnnestSource:
+		if src.Alias != nil {
+			t.Alias = src.Alias.Name
+		}
+		t.Generated = append(t.Generated, unnestColumns(src)...)
+
+	case *query.SampleSource:
+		if src.X != nil {
+			t.processSource(src.X)
+		}
+		if src.Alias != nil {
+			t.Alias = src.Alias.Name
+		}
+
 	case *query.QualifiedTableName:
 		if src.Alias != nil {
 			t.Alias = src.Alias.Name
 		}
 
 		if src.Name != nil {
-			mIdent := src.Name
-			if mIdent.First != nil {
-				if src.Name.Second != nil {
-					t.Project = mIdent.First.Name
-					t.Schema = mIdent.Second.Name
-				} else {
-					t.Schema = mIdent.First.Name
-				}
+			t.setName(src.Name)
+		}
+	}
+}
+
+func (t *Table) setName(mIdent *query.MultiPartIdent) {
+	if mIdent.First != nil {
+		if mIdent.Second != nil {
+			t.Project = mIdent.First.Name
+			t.Schema = mIdent.Second.Name
+		} else {
+			t.Schema = mIdent.First.Name
+		}
+	}
+	if mIdent.Name != nil {
+		t.Name = mIdent.Name.Name
+	}
+}
+
+func insertTarget(stmt *query.InsertStatement) *Table {
+	if stmt.Table == nil {
+		return nil
+	}
+
+	t := &Table{}
+	t.setName(stmt.Table)
+	if stmt.Alias != nil {
+		t.Alias = stmt.Alias.Name
+	}
+
+	if stmt.Partition != nil {
+		for _, col := range stmt.Partition.Columns {
+			p := Partition{Column: col.Name.Name, Dynamic: col.IsDynamic()}
+			if lit, ok := col.Value.(*query.StringLit); ok {
+				p.Value = lit.Value
+			} else if col.Value != nil {
+				p.Value = col.Value.String()
 			}
-			t.Name = mIdent.Name.Name
+			t.Partitions = append(t.Partitions, p)
 		}
 	}
+	return t
 }
 
 func (t *Table) addColumn(c1 Column) {
-	if c1.Ref == "" {
+	// Generated columns read the columns of their PIVOT, UNPIVOT, UNNEST or
+	// lateral view instead.
+	if cols, ok := t.generated(c1); ok {
+		for _, c2 := range cols {
+			t.addSourceColumn(c2)
+		}
+		return
+	}
+	for _, t2 := range t.Join {
+		if t2.hasGenerated(c1) {
+			t2.addColumn(c1)
+			return
+		}
+	}
+	t.addSourceColumn(c1)
+}
+
+// addSourceColumn adds c1 to t, or to the subquery or joined table it
+// references.
+func (t *Table) addSourceColumn(c1 Column) {
+	if c1.Ref == "" || strings.EqualFold(t.Alias, c1.Ref) {
 		t.Columns = append(t.Columns, c1)
 		return
 	}
@@ -164,8 +293,68 @@ func (t *Table) addColumn(c1 Column) {
 	}
 
 	for _, t2 := range t.Join {
-		if strings.EqualFold(t2.Alias, c1.Ref) {
+		if !t2.Filter && strings.EqualFold(t2.Alias, c1.Ref) {
 			t2.addColumn(c1)
 		}
 	}
 }
+
+// generated returns the columns read for the columns generated by a PIVOT,
+// an UNPIVOT, an UNNEST or a lateral view of t that c1 references, and whether c1
+// references any. An UNPIVOT generates a value column from several.
+func (t *Table) generated(c1 Column) ([]Column, bool) {
+	name := c1.Name
+	if c1.Ref != "" {
+		name = strings.TrimPrefix(name, c1.Ref+".")
+	}
+
+	// Joined tables with sources of their own resolve the columns they
+	// generate themselves; see hasGenerated.
+	tables := []*Table{t}
+	for _, t2 := range t.Join {
+		if t2.readsParent() {
+			tables = append(tables, t2)
+		}
+	}
+
+	var cols []Column
+	found := false
+	for _, t2 := range tables {
+		if c1.Ref != "" && !strings.EqualFold(t2.Alias, c1.Ref) {
+			continue
+		}
+		for _, g := range t2.Generated {
+			// The element of an UNNEST is named by its alias, so x.y is
+			// a field of the element x.
+			if strings.EqualFold(g.Name, name) || strings.EqualFold(g.Name, c1.Ref) {
+				cols = append(cols, g.Columns...)
+				found = true
+			}
+		}
+		if found {
+			break
+		}
+	}
+	return cols, found
+}
+
+// readsParent returns true if t has no source of its own, as for lateral
+// views and UNNEST, so that the columns it generates read those of the
+// tables before it.
+func (t *Table) readsParent() bool {
+	return t.Name == "" && len(t.SubTable) == 0 && len(t.Join) == 0
+}
+
+// hasGenerated returns true if c1 references a column generated for t or
+// for the tables joined to it.
+func (t *Table) hasGenerated(c1 Column) bool {
+	if _, ok := t.generated(c1); ok {
+		return true
+	}
+	for _, t2 := range t.Join {
+		if t2.hasGenerated(c1) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package lineage_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query/lineage"
+)
+
+func TestParseQuery(t *testing.T) {
+	t.Run("InsertPartition", func(t *testing.T) {
+		tbl, err := lineage.ParseQuery("test", `INSERT OVERWRITE TABLE db.out PARTITION (pt='2024-01-01', region='id') SELECT a FROM db.t`)
+		assert.NoError(t, err)
+		assert.Equal(t, "db.out", tbl.Target.DisplayName())
+		assert.Equal(t, []lineage.Partition{{Column: "pt", Value: "2024-01-01"}, {Column: "region", Value: "id"}}, tbl.Target.Partitions)
+		assert.Empty(t, tbl.Partitions)
+
+		tbl, err = lineage.ParseQuery("test", `INSERT INTO TABLE p.db.out PARTITION (pt) SELECT a, pt FROM db.t`)
+		assert.NoError(t, err)
+		assert.Equal(t, "p.db.out", tbl.Target.DisplayName())
+		assert.Equal(t, []lineage.Partition{{Column: "pt", Dynamic: true}}, tbl.Target.Partitions)
+
+		AssertColumns(t, `INSERT INTO TABLE db.out PARTITION (pt) SELECT a, pt FROM db.t`,
+			`db.t: a`,
+			`db.t: pt`)
+	})
+
+	t.Run("SemiJoin", func(t *testing.T) {
+		for _, sql := range []string{
+			`SELECT a.x, b.y FROM db.a a LEFT SEMI JOIN db.b b ON a.id = b.id`,
+			`SELECT a.x, b.y FROM db.a a LEFT ANTI JOIN db.b b ON a.id = b.id`,
+		} {
+			tbl, err := lineage.ParseQuery("test", sql)
+			assert.NoError(t, err)
+			if assert.Len(t, tbl.Join, 1) {
+				assert.True(t, tbl.Join[0].Filter)
+				assert.Equal(t, "db.b", tbl.Join[0].DisplayName())
+				assert.Empty(t, tbl.Join[0].Columns)
+			}
+			AssertColumns(t, sql, `db.a: a.x`)
+		}
+
+		AssertColumns(t, `SELECT x, b.y FROM db.a LEFT SEMI JOIN db.b AS b ON a.id = b.id`,
+			`db.a: x`)
+	})
+
+	t.Run("Grouping", func(t *testing.T) {
+		AssertColumns(t, `SELECT a, GROUPING__ID FROM db.t GROUP BY a, b WITH ROLLUP`,
+			`db.t: a`,
+			`db.t: a (GROUPING__ID)`,
+			`db.t: b (GROUPING__ID)`)
+		AssertColumns(t, `SELECT grouping_id() AS g FROM db.t GROUP BY a, b GROUPING SETS ((a, b), a)`,
+			`db.t: a (grouping_id())`,
+			`db.t: b (grouping_id())`)
+		AssertColumns(t, `SELECT GROUPING_ID() FROM db.t GROUP BY ROLLUP (a, b), CUBE (c, a)`,
+			`db.t: a (GROUPING_ID())`,
+			`db.t: b (GROUPING_ID())`,
+			`db.t: c (GROUPING_ID())`)
+		AssertColumns(t, `SELECT grouping_id(a), grouping(c) FROM db.t GROUP BY CUBE (a, c)`,
+			`db.t: a (grouping_id(a))`,
+			`db.t: c (GROUPING(c))`)
+	})
+
+	t.Run("Pivot", func(t *testing.T) {
+		AssertColumns(t, `SELECT p.a, b, d FROM db.t PIVOT (sum(x) FOR c IN ('a', 'b')) p`,
+			`db.t: x (sum(x))`,
+			`db.t: x (sum(x))`,
+			`db.t: d`)
+		AssertColumns(t, `SELECT a_total, b_n, t.d FROM db.t AS t PIVOT (sum(x) AS total, count(y) AS n FOR c IN ('a' AS a, 'b' AS b))`,
+			`db.t: x (sum(x))`,
+			`db.t: y (count(y))`,
+			`db.t: t.d`)
+		AssertColumns(t, `SELECT a, u.y FROM db.t PIVOT (max(x) FOR c IN ('a')) AS p JOIN db.u AS u ON p.id = u.id`,
+			`db.t: x (max(x))`,
+			`db.u: u.y`)
+		AssertColumns(t, `SELECT s.a FROM (SELECT c, x FROM db.t) s PIVOT (sum(x) FOR c IN ('a')) s`,
+			`s: x (sum(x))`,
+			`db.t: c`,
+			`db.t: x`)
+	})
+
+	t.Run("Unpivot", func(t *testing.T) {
+		AssertColumns(t, `SELECT id, u.v, k FROM db.t UNPIVOT (v FOR k IN (a, b)) u`,
+			`db.t: id`,
+			`db.t: a`,
+			`db.t: b`)
+		tbl, err := lineage.ParseQuery("test", `SELECT k, v FROM db.t UNPIVOT (v FOR k IN (a AS 'x', b AS 'y'))`)
+		assert.NoError(t, err)
+		assert.Equal(t, []lineage.GeneratedColumn{
+			{Name: "k", Value: "x"},
+			{Name: "v", Value: "x", Columns: []lineage.Column{{Name: "a"}}},
+			{Name: "k", Value: "y"},
+			{Name: "v", Value: "y", Columns: []lineage.Column{{Name: "b"}}},
+		}, tbl.Generated)
+		assert.Equal(t, []lineage.Column{{Name: "a"}, {Name: "b"}}, tbl.Columns)
+
+		AssertColumns(t, `SELECT v1, v2 FROM db.t UNPIVOT ((v1, v2) FOR k IN ((a, b) AS x, (c, d) AS y))`,
+			`db.t: a`,
+			`db.t: c`,
+			`db.t: b`,
+			`db.t: d`)
+	})
+
+	t.Run("LateralView", func(t *testing.T) {
+		AssertColumns(t, `SELECT x.item, item FROM db.t AS t LATERAL VIEW explode(t.arr) x AS item`,
+			`db.t: t.arr (explode(t.arr))`,
+			`db.t: t.arr (explode(t.arr))`)
+		AssertColumns(t, `SELECT id, v FROM db.t LATERAL VIEW explode(m) x AS k, v`,
+			`db.t: id`,
+			`db.t: m (explode(m))`)
+		AssertColumns(t, `SELECT y.e FROM db.t t LATERAL VIEW explode(t.m) x AS k, v LATERAL VIEW explode(x.v) y AS e`,
+			`db.t: t.m (explode(t.m))`)
+		AssertColumns(t, `SELECT x.item FROM (SELECT arr FROM db.t) s LATERAL VIEW explode(s.arr) x AS item`,
+			`s: s.arr (explode(s.arr))`,
+			`db.t: arr`)
+		AssertColumns(t, `SELECT x.item, a.id FROM db.t a JOIN db.u b ON a.id = b.id LATERAL VIEW explode(b.arr) x AS item`,
+			`db.t: a.id`,
+			`db.u: b.arr (explode(b.arr))`)
+		AssertColumns(t, `SELECT x.item, y.item FROM db.t a CROSS JOIN db.u b LATERAL VIEW explode(a.arr) x AS item LATERAL VIEW explode(b.arr) y AS item`,
+			`db.t: a.arr (explode(a.arr))`,
+			`db.u: b.arr (explode(b.arr))`)
+		AssertColumns(t, `SELECT x.item FROM db.t a JOIN db.u b LATERAL VIEW explode(a.arr) x AS item ON a.id = b.id`,
+			`db.t: a.arr (explode(a.arr))`)
+		AssertColumns(t, `SELECT p.a, x.item FROM db.u u LATERAL VIEW explode(u.arr) x AS item JOIN db.t PIVOT (sum(v) FOR c IN ('a')) p ON u.id = p.id`,
+			`db.u: u.arr (explode(u.arr))`,
+			`db.t: v (sum(v))`)
+	})
+
+	t.Run("Unnest", func(t *testing.T) {
+		AssertColumns(t, `SELECT x, off FROM db.t AS t, UNNEST(t.arr) AS x WITH OFFSET AS off`,
+			`db.t: t.arr (UNNEST(t.arr))`)
+		AssertColumns(t, `SELECT t.id, x.y, offset FROM db.t AS t CROSS JOIN UNNEST(t.arr) x WITH OFFSET`,
+			`db.t: t.id`,
+			`db.t: t.arr (UNNEST(t.arr))`)
+		AssertColumns(t, `SELECT id, x FROM db.t, UNNEST(arr) AS x`,
+			`db.t: id`,
+			`db.t: arr (UNNEST(arr))`)
+		AssertColumns(t, `SELECT x, offset FROM UNNEST(split('a,b', ',')) AS x WITH OFFSET`)
+	})
+}
+
+// AssertColumns asserts that the lineage of s reads want, each given as
+// "table: column (transform)" in the order of the tables in the tree.
+func AssertColumns(tb testing.TB, s string, want ...string) {
+	tb.Helper()
+
+	t, err := lineage.ParseQuery("test", s)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	var got []string
+	var add func(*lineage.Table)
+	add = func(t *lineage.Table) {
+		name := t.DisplayName()
+		if name == "" {
+			name = t.Alias
+		}
+		for _, c := range t.Columns {
+			col := name + ": " + c.Name
+			if c.Transform != "" {
+				col += " (" + c.Transform + ")"
+			}
+			got = append(got, col)
+		}
+		for _, tables := range [][]*lineage.Table{t.CTE, t.SubTable, t.Join} {
+			for _, t2 := range tables {
+				add(t2)
+			}
+		}
+	}
+	add(t)
+	assert.Equal(tb, want, got)
+}
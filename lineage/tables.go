@@ -0,0 +1,79 @@
+package lineage
+
+import (
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// Tables returns the names of the tables read and written by stmts, in the
+// order they first appear. References to CTEs are not tables and are skipped.
+func Tables(stmts []query.Statement) (read, write []string) {
+	var r, w names
+	for _, stmt := range stmts {
+		var target query.Node
+		switch stmt := stmt.(type) {
+		case *query.InsertStatement:
+			w.add(stmt.Table)
+		case *query.CreateTableStatement:
+			w.add(stmt.Name)
+		case *query.DropTableStatement:
+			w.add(stmt.Name)
+		case *query.TruncateStatement:
+			w.add(stmt.Name)
+		case *query.DeleteStatement:
+			if stmt.Table != nil {
+				w.add(stmt.Table.Name)
+				target = stmt.Table
+			}
+		case *query.MergeStatement:
+			if src, ok := stmt.Target.(*query.QualifiedTableName); ok {
+				w.add(src.Name)
+				target = src
+			}
+		}
+
+		ctes := make(map[string]bool)
+		query.Inspect(stmt, func(n query.Node) bool {
+			if cte, ok := n.(*query.CTE); ok && cte.TableName != nil {
+				ctes[strings.ToLower(cte.TableName.Name)] = true
+			}
+			return true
+		})
+
+		query.Inspect(stmt, func(n query.Node) bool {
+			src, ok := n.(*query.QualifiedTableName)
+			if !ok || n == target || src.Name == nil {
+				return true
+			}
+			if src.Name.First == nil && src.Name.Name != nil && ctes[strings.ToLower(src.Name.Name.Name)] {
+				return true
+			}
+			r.add(src.Name)
+			return true
+		})
+	}
+	return r.list, w.list
+}
+
+// names is a list of table names without duplicates.
+type names struct {
+	list []string
+	seen map[string]bool
+}
+
+func (n *names) add(name *query.MultiPartIdent) {
+	if name == nil {
+		return
+	}
+
+	s := name.String()
+	if n.seen[s] {
+		return
+	}
+	if n.seen == nil {
+		n.seen = make(map[string]bool)
+	}
+	n.seen[s] = true
+	n.list = append(n.list, s)
+}
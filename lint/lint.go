@@ -0,0 +1,208 @@
+// Package lint checks sql statements against a set of rules.
+//
+// A finding can be suppressed with a comment on the line it is reported on,
+// or alone on the line before it:
+//
+//	-- lint:ignore
+//	-- lint:ignore delete-without-where, unused-cte
+//
+// Without rule names every finding on those lines is ignored.
+package lint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// Severity is how serious a finding is.
+type Severity int
+
+const (
+	Info Severity = iota + 1
+	Warning
+	Error
+)
+
+// String returns the name of the severity.
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Finding is a problem reported by a rule.
+type Finding struct {
+	Pos      query.Pos `json:"pos"`
+	Rule     string    `json:"rule"`
+	Severity Severity  `json:"severity"`
+	Message  string    `json:"message"`
+}
+
+// String returns the finding as "line:column: severity: message (rule)".
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s: %s (%s)", f.Pos, f.Severity, f.Message, f.Rule)
+}
+
+// Rule checks a single statement.
+type Rule interface {
+	// Name identifies the rule in findings and lint:ignore comments.
+	Name() string
+
+	// Severity is assigned to findings that do not set their own.
+	Severity() Severity
+
+	// Check returns the problems found in stmt. The Rule and Severity of
+	// each finding are filled in by the Linter if left blank.
+	Check(stmt query.Statement) []Finding
+}
+
+// DefaultRules returns the built-in rules.
+func DefaultRules() []Rule {
+	return []Rule{
+		SelectStarInsert{},
+		DeleteWithoutWhere{},
+		ImplicitCrossJoin{},
+		UnionDistinct{},
+		NondeterministicPartitionFilter{Columns: DefaultPartitionColumns},
+		UnusedCTE{},
+		UnaliasedCTASColumn{},
+		SubqueryOrderWithoutLimit{},
+	}
+}
+
+// Linter runs a set of rules over statements.
+type Linter struct {
+	Rules []Rule
+}
+
+// New returns a linter that runs rules, or the built-in rules if none are given.
+func New(rules ...Rule) *Linter {
+	if len(rules) == 0 {
+		rules = DefaultRules()
+	}
+	return &Linter{Rules: rules}
+}
+
+// Lint parses src and returns the findings not suppressed by a lint:ignore
+// comment, ordered by position.
+func (l *Linter) Lint(src string) ([]Finding, error) {
+	p := query.NewParser(strings.NewReader(src))
+	stmts, err := p.ParseStatements()
+	if err != nil {
+		return nil, err
+	}
+
+	ignores := parseIgnores(src, p.Comments())
+
+	var findings []Finding
+	for _, stmt := range stmts {
+		for _, f := range l.Check(stmt) {
+			if !ignores.ignored(f) {
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings, nil
+}
+
+// Check returns the findings of every rule for stmt, ordered by position.
+func (l *Linter) Check(stmt query.Statement) []Finding {
+	var findings []Finding
+	for _, rule := range l.Rules {
+		for _, f := range rule.Check(stmt) {
+			if f.Rule == "" {
+				f.Rule = rule.Name()
+			}
+			if f.Severity == 0 {
+				f.Severity = rule.Severity()
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Pos.Offset < findings[j].Pos.Offset
+	})
+	return findings
+}
+
+// ignores maps a line to the rules ignored on it. A nil list ignores all rules.
+type ignores map[int][]string
+
+const ignoreDirective = "lint:ignore"
+
+// parseIgnores returns the lines ignored by the lint:ignore comments of src.
+// A comment ignores the lines it spans, and the line after it if nothing
+// else is on its lines.
+func parseIgnores(src string, comments []query.Comment) ignores {
+	m := make(ignores)
+	for _, c := range comments {
+		text := strings.TrimPrefix(c.Text, "--")
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		text, ok := strings.CutPrefix(strings.TrimSpace(text), ignoreDirective)
+		if !ok || (text != "" && !strings.ContainsAny(text[:1], " \t,")) {
+			continue
+		}
+
+		rules := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(rules) == 0 {
+			rules = nil
+		}
+
+		last := c.Pos.Line + strings.Count(c.Text, "\n")
+		if aloneOnLine(src, c) {
+			last++
+		}
+		for line := c.Pos.Line; line <= last; line++ {
+			m.add(line, rules)
+		}
+	}
+	return m
+}
+
+// aloneOnLine returns true if only whitespace surrounds c on its lines.
+func aloneOnLine(src string, c query.Comment) bool {
+	start, end := c.Pos.Offset, c.Pos.Offset+len(c.Text)
+	if start < 0 || end > len(src) {
+		return false
+	}
+	before := src[strings.LastIndexByte(src[:start], '\n')+1 : start]
+	after, _, _ := strings.Cut(src[end:], "\n")
+	return strings.TrimSpace(before) == "" && strings.TrimSpace(after) == ""
+}
+
+func (m ignores) add(line int, rules []string) {
+	if existing, ok := m[line]; ok && (existing == nil || rules == nil) {
+		m[line] = nil
+		return
+	}
+	m[line] = append(m[line], rules...)
+}
+
+func (m ignores) ignored(f Finding) bool {
+	rules, ok := m[f.Pos.Line]
+	if !ok {
+		return false
+	} else if rules == nil {
+		return true
+	}
+
+	for _, rule := range rules {
+		if rule == f.Rule {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query/lint"
+)
+
+func TestRules(t *testing.T) {
+	t.Run("SelectStarInsert", func(t *testing.T) {
+		AssertLint(t, lint.SelectStarInsert{}, `INSERT INTO t SELECT * FROM s UNION ALL SELECT s.* FROM s`,
+			`1:22: warning: SELECT * in INSERT; list the columns explicitly (select-star-insert)`,
+			`1:48: warning: SELECT * in INSERT; list the columns explicitly (select-star-insert)`)
+		AssertLint(t, lint.SelectStarInsert{}, `INSERT INTO t SELECT a FROM (SELECT * FROM s)`)
+		AssertLint(t, lint.SelectStarInsert{}, `SELECT * FROM s`)
+	})
+
+	t.Run("DeleteWithoutWhere", func(t *testing.T) {
+		AssertLint(t, lint.DeleteWithoutWhere{}, `DELETE FROM t`,
+			`1:1: error: DELETE without WHERE removes every row (delete-without-where)`)
+		AssertLint(t, lint.DeleteWithoutWhere{}, `DELETE FROM t WHERE a = 1`)
+	})
+
+	t.Run("ImplicitCrossJoin", func(t *testing.T) {
+		AssertLint(t, lint.ImplicitCrossJoin{}, `SELECT * FROM a, b JOIN c`,
+			`1:16: warning: implicit cross join; use JOIN ... ON or CROSS JOIN (implicit-cross-join)`,
+			`1:20: warning: JOIN without ON or USING is a cross join; use CROSS JOIN (implicit-cross-join)`)
+		AssertLint(t, lint.ImplicitCrossJoin{}, `SELECT * FROM a CROSS JOIN b JOIN c ON b.id = c.id LEFT JOIN d USING (id)`)
+	})
+
+	t.Run("UnionDistinct", func(t *testing.T) {
+		AssertLint(t, lint.UnionDistinct{}, `SELECT a FROM t UNION SELECT a FROM u`,
+			`1:17: info: UNION removes duplicate rows; use UNION ALL, or UNION DISTINCT if intended (union-distinct)`)
+		AssertLint(t, lint.UnionDistinct{}, `SELECT a FROM t UNION ALL SELECT a FROM u UNION DISTINCT SELECT a FROM v`)
+		AssertLint(t, lint.UnionDistinct{}, `SELECT a FROM t INTERSECT SELECT a FROM u EXCEPT SELECT a FROM v`)
+	})
+
+	t.Run("NondeterministicPartitionFilter", func(t *testing.T) {
+		rule := lint.NondeterministicPartitionFilter{Columns: lint.DefaultPartitionColumns}
+		AssertLint(t, rule, `SELECT a FROM t WHERE PT = DATE(NOW()) AND b > RAND()`,
+			`1:33: warning: non-deterministic NOW() in filter on partition column PT (nondeterministic-partition-filter)`)
+		AssertLint(t, rule, `DELETE FROM t WHERE current_date BETWEEN x AND dt`,
+			`1:21: warning: non-deterministic CURRENT_DATE in filter on partition column dt (nondeterministic-partition-filter)`)
+		AssertLint(t, rule, `SELECT a FROM t WHERE pt = '{{ .DSTART }}' AND b IN (SELECT c FROM u WHERE ds = current_date())`,
+			`1:81: warning: non-deterministic CURRENT_DATE() in filter on partition column ds (nondeterministic-partition-filter)`)
+		AssertLint(t, lint.NondeterministicPartitionFilter{Columns: []string{"day"}}, `SELECT a FROM t WHERE pt = now()`)
+	})
+
+	t.Run("UnusedCTE", func(t *testing.T) {
+		AssertLint(t, lint.UnusedCTE{}, `WITH a AS (SELECT 1), b AS (SELECT * FROM a), c AS (SELECT * FROM c) SELECT * FROM b`,
+			`1:47: warning: CTE c is never used (unused-cte)`)
+		AssertLint(t, lint.UnusedCTE{}, `INSERT INTO t WITH a AS (SELECT 1) SELECT * FROM x WHERE EXISTS (SELECT 1 FROM A)`)
+	})
+
+	t.Run("UnaliasedCTASColumn", func(t *testing.T) {
+		AssertLint(t, lint.UnaliasedCTASColumn{}, `CREATE TABLE t AS SELECT a, s.b, count(*), c + 1 AS d FROM s`,
+			`1:34: warning: expression count(*) in CREATE TABLE AS has no alias (unaliased-ctas-column)`)
+		AssertLint(t, lint.UnaliasedCTASColumn{}, `SELECT count(*) FROM s`)
+	})
+
+	t.Run("SubqueryOrderWithoutLimit", func(t *testing.T) {
+		AssertLint(t, lint.SubqueryOrderWithoutLimit{}, `WITH a AS (SELECT x FROM s ORDER BY x) SELECT * FROM (SELECT x FROM a ORDER BY x LIMIT 1) ORDER BY x`,
+			`1:28: info: ORDER BY without LIMIT in a subquery has no effect (subquery-order-without-limit)`)
+		AssertLint(t, lint.SubqueryOrderWithoutLimit{}, `INSERT INTO t SELECT x FROM s ORDER BY x`)
+	})
+}
+
+func TestLinter_Lint(t *testing.T) {
+	t.Run("Default", func(t *testing.T) {
+		AssertLint(t, nil, "DELETE FROM t;\nWITH a AS (SELECT 1) SELECT * FROM t, u",
+			`1:1: error: DELETE without WHERE removes every row (delete-without-where)`,
+			`2:6: warning: CTE a is never used (unused-cte)`,
+			`2:37: warning: implicit cross join; use JOIN ... ON or CROSS JOIN (implicit-cross-join)`)
+	})
+
+	t.Run("Ignore", func(t *testing.T) {
+		AssertLint(t, nil, "-- lint:ignore\nDELETE FROM t;\nDELETE FROM u; -- lint:ignore delete-without-where\nDELETE FROM v;\nDELETE FROM w;",
+			`4:1: error: DELETE without WHERE removes every row (delete-without-where)`,
+			`5:1: error: DELETE without WHERE removes every row (delete-without-where)`)
+		AssertLint(t, nil, "DELETE FROM t; /* lint:ignore\n*/ DELETE FROM u;\nDELETE FROM v;\n  /* lint:ignore */  \nDELETE FROM w;",
+			`3:1: error: DELETE without WHERE removes every row (delete-without-where)`)
+		AssertLint(t, nil, "/* lint:ignore unused-cte, implicit-cross-join */\nINSERT INTO x WITH a AS (SELECT 1) SELECT * FROM t, u",
+			`2:43: warning: SELECT * in INSERT; list the columns explicitly (select-star-insert)`)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := lint.New().Lint(`SELECT FROM`)
+		assert.Error(t, err)
+	})
+}
+
+// AssertLint asserts that linting src with rule, or the default rules if rule
+// is nil, reports want.
+func AssertLint(tb testing.TB, rule lint.Rule, src string, want ...string) {
+	tb.Helper()
+
+	l := lint.New()
+	if rule != nil {
+		l = lint.New(rule)
+	}
+
+	findings, err := l.Lint(src)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.String())
+	}
+	assert.Equal(tb, want, got)
+}
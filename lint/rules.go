@@ -0,0 +1,309 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbchaos/query"
+	"github.com/sbchaos/query/analysis"
+)
+
+// SelectStarInsert reports SELECT * in the query of an INSERT, which breaks
+// silently when the columns of the source or target table change.
+type SelectStarInsert struct{}
+
+func (SelectStarInsert) Name() string       { return "select-star-insert" }
+func (SelectStarInsert) Severity() Severity { return Warning }
+
+func (SelectStarInsert) Check(stmt query.Statement) []Finding {
+	insert, ok := stmt.(*query.InsertStatement)
+	if !ok || insert.Select == nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, sel := range insert.Select.Operands() {
+		for _, col := range sel.Columns {
+			pos := col.Star
+			if ref, ok := col.Expr.(*query.QualifiedRef); ok && ref.Star.IsValid() {
+				pos = query.NodePos(ref)
+			}
+			if pos.IsValid() {
+				findings = append(findings, Finding{Pos: pos, Message: "SELECT * in INSERT; list the columns explicitly"})
+			}
+		}
+	}
+	return findings
+}
+
+// DeleteWithoutWhere reports a DELETE that removes every row of its table.
+type DeleteWithoutWhere struct{}
+
+func (DeleteWithoutWhere) Name() string       { return "delete-without-where" }
+func (DeleteWithoutWhere) Severity() Severity { return Error }
+
+func (DeleteWithoutWhere) Check(stmt query.Statement) []Finding {
+	if stmt, ok := stmt.(*query.DeleteStatement); ok && stmt.WhereExpr == nil {
+		return []Finding{{Pos: stmt.Delete, Message: "DELETE without WHERE removes every row"}}
+	}
+	return nil
+}
+
+// ImplicitCrossJoin reports comma joins and joins without a constraint that
+// are not written as CROSS JOIN. Comma joins with a table function, such as
+// UNNEST, are allowed.
+type ImplicitCrossJoin struct{}
+
+func (ImplicitCrossJoin) Name() string       { return "implicit-cross-join" }
+func (ImplicitCrossJoin) Severity() Severity { return Warning }
+
+func (ImplicitCrossJoin) Check(stmt query.Statement) []Finding {
+	var findings []Finding
+	query.Inspect(stmt, func(n query.Node) bool {
+		join, ok := n.(*query.JoinClause)
+		if !ok || join.Operator == nil {
+			return true
+		}
+		if _, ok := join.Y.(*query.QualifiedTableFunctionName); ok {
+			return true
+		}
+
+		switch op := join.Operator; {
+		case op.Comma.IsValid():
+			findings = append(findings, Finding{Pos: op.Comma, Message: "implicit cross join; use JOIN ... ON or CROSS JOIN"})
+		case join.Constraint == nil && !op.Cross.IsValid() && !op.Natural.IsValid():
+			findings = append(findings, Finding{Pos: query.NodePos(op), Message: "JOIN without ON or USING is a cross join; use CROSS JOIN"})
+		}
+		return true
+	})
+	return findings
+}
+
+// UnionDistinct reports UNION without ALL or DISTINCT. Removing duplicates
+// requires sorting or hashing every row, which is rarely intended.
+type UnionDistinct struct{}
+
+func (UnionDistinct) Name() string       { return "union-distinct" }
+func (UnionDistinct) Severity() Severity { return Info }
+
+func (UnionDistinct) Check(stmt query.Statement) []Finding {
+	var findings []Finding
+	query.Inspect(stmt, func(n query.Node) bool {
+		if op, ok := n.(*query.SetOperation); ok && op.Union.IsValid() && !op.All.IsValid() && !op.Distinct.IsValid() {
+			findings = append(findings, Finding{Pos: op.Union, Message: "UNION removes duplicate rows; use UNION ALL, or UNION DISTINCT if intended"})
+		}
+		return true
+	})
+	return findings
+}
+
+// DefaultPartitionColumns are the partition columns checked by the default
+// NondeterministicPartitionFilter rule.
+var DefaultPartitionColumns = []string{"pt", "dt", "ds", "_partitiontime", "_partitiondate"}
+
+// nondeterministicFuncs are functions whose result depends on when or where
+// the query runs.
+var nondeterministicFuncs = map[string]bool{
+	"CURRENT_DATE":      true,
+	"CURRENT_DATETIME":  true,
+	"CURRENT_TIME":      true,
+	"CURRENT_TIMESTAMP": true,
+	"GETDATE":           true,
+	"GENERATE_UUID":     true,
+	"NOW":               true,
+	"RAND":              true,
+	"RANDOM":            true,
+	"SYSDATE":           true,
+	"UNIX_TIMESTAMP":    true,
+	"UUID":              true,
+}
+
+// NondeterministicPartitionFilter reports non-deterministic functions, such
+// as NOW(), compared with a partition column in a WHERE clause. The selected
+// partitions then depend on when the query runs, so reruns and backfills
+// read different data.
+type NondeterministicPartitionFilter struct {
+	// Columns are the names of partition columns, compared case-insensitively.
+	Columns []string
+}
+
+func (NondeterministicPartitionFilter) Name() string       { return "nondeterministic-partition-filter" }
+func (NondeterministicPartitionFilter) Severity() Severity { return Warning }
+
+func (r NondeterministicPartitionFilter) Check(stmt query.Statement) []Finding {
+	var findings []Finding
+	query.Inspect(stmt, func(n query.Node) bool {
+		var where query.Expr
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			where = n.WhereExpr
+		case *query.DeleteStatement:
+			where = n.WhereExpr
+		}
+		if where != nil {
+			findings = append(findings, r.checkFilter(where)...)
+		}
+		return true
+	})
+	return findings
+}
+
+func (r NondeterministicPartitionFilter) checkFilter(where query.Expr) []Finding {
+	var findings []Finding
+	query.Inspect(where, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			// Checked separately.
+			return false
+		case *query.BinaryExpr:
+			if n.Op == query.AND || n.Op == query.OR {
+				return true
+			}
+			if col := r.partitionColumn(n.X); col != "" {
+				findings = append(findings, nondeterministicCalls(n.Y, col)...)
+			}
+			if col := r.partitionColumn(n.Y); col != "" {
+				findings = append(findings, nondeterministicCalls(n.X, col)...)
+			}
+			return false
+		}
+		return true
+	})
+	return findings
+}
+
+// partitionColumn returns the name of the first partition column referenced
+// by expr, or a blank string.
+func (r NondeterministicPartitionFilter) partitionColumn(expr query.Expr) (name string) {
+	query.Inspect(expr, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			return false
+		case *query.Call:
+			for _, arg := range n.Args {
+				if name == "" {
+					name = r.partitionColumn(arg.X)
+				}
+			}
+			return false
+		case *query.MultiPartIdent:
+			for _, col := range r.Columns {
+				if name == "" && n.Name != nil && strings.EqualFold(n.Name.Name, col) {
+					name = n.Name.Name
+				}
+			}
+			return false
+		}
+		return name == ""
+	})
+	return name
+}
+
+// nondeterministicCalls returns a finding for each non-deterministic function
+// called by expr.
+func nondeterministicCalls(expr query.Expr, col string) []Finding {
+	var findings []Finding
+	report := func(pos query.Pos, name string) {
+		findings = append(findings, Finding{
+			Pos:     pos,
+			Message: fmt.Sprintf("non-deterministic %s in filter on partition column %s", strings.ToUpper(name), col),
+		})
+	}
+
+	query.Inspect(expr, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			return false
+		case *query.Call:
+			if n.Name != nil && n.Name.First == nil && nondeterministicFuncs[strings.ToUpper(n.Name.Name.Name)] {
+				report(query.NodePos(n), n.Name.Name.Name+"()")
+				return false
+			}
+		case *query.MultiPartIdent:
+			// CURRENT_DATE and friends can be written without parentheses.
+			switch ident := n.Name; {
+			case n.First != nil || ident == nil:
+			case ident.Tok == query.CURRENT_DATE, ident.Tok == query.CURRENT_TIME, ident.Tok == query.CURRENT_TIMESTAMP:
+				report(ident.NamePos, ident.Name)
+			}
+			return false
+		}
+		return true
+	})
+	return findings
+}
+
+// UnusedCTE reports common table expressions that are never referenced by
+// the query or by later CTEs.
+type UnusedCTE struct{}
+
+func (UnusedCTE) Name() string       { return "unused-cte" }
+func (UnusedCTE) Severity() Severity { return Warning }
+
+func (UnusedCTE) Check(stmt query.Statement) []Finding {
+	var findings []Finding
+	for _, issue := range analysis.CheckCTEs(stmt, nil) {
+		if issue.Kind == analysis.UnusedCTE {
+			findings = append(findings, Finding{Pos: issue.Pos, Message: issue.Message})
+		}
+	}
+	return findings
+}
+
+// UnaliasedCTASColumn reports expressions without an alias in the query of
+// CREATE TABLE ... AS SELECT, where the engine would pick the column name.
+type UnaliasedCTASColumn struct{}
+
+func (UnaliasedCTASColumn) Name() string       { return "unaliased-ctas-column" }
+func (UnaliasedCTASColumn) Severity() Severity { return Warning }
+
+func (UnaliasedCTASColumn) Check(stmt query.Statement) []Finding {
+	create, ok := stmt.(*query.CreateTableStatement)
+	if !ok || create.Select == nil {
+		return nil
+	}
+
+	var findings []Finding
+	for _, col := range create.Select.Operands()[0].Columns {
+		switch col.Expr.(type) {
+		case nil, *query.Ident, *query.MultiPartIdent, *query.QualifiedRef:
+			continue
+		}
+		if col.Alias == nil {
+			findings = append(findings, Finding{
+				Pos:     query.NodePos(col.Expr),
+				Message: fmt.Sprintf("expression %s in CREATE TABLE AS has no alias", col.Expr),
+			})
+		}
+	}
+	return findings
+}
+
+// SubqueryOrderWithoutLimit reports ORDER BY in a subquery or CTE without a
+// LIMIT, where the order is not guaranteed to be kept.
+type SubqueryOrderWithoutLimit struct{}
+
+func (SubqueryOrderWithoutLimit) Name() string       { return "subquery-order-without-limit" }
+func (SubqueryOrderWithoutLimit) Severity() Severity { return Info }
+
+func (SubqueryOrderWithoutLimit) Check(stmt query.Statement) []Finding {
+	// The query of the statement itself may be ordered.
+	var root *query.SelectStatement
+	switch stmt := stmt.(type) {
+	case *query.SelectStatement:
+		root = stmt
+	case *query.InsertStatement:
+		root = stmt.Select
+	case *query.CreateTableStatement:
+		root = stmt.Select
+	}
+
+	var findings []Finding
+	query.Inspect(stmt, func(n query.Node) bool {
+		if sel, ok := n.(*query.SelectStatement); ok && sel != root && sel.Order.IsValid() && !sel.Limit.IsValid() {
+			findings = append(findings, Finding{Pos: sel.Order, Message: "ORDER BY without LIMIT in a subquery has no effect"})
+		}
+		return true
+	})
+	return findings
+}
@@ -1,5 +1,7 @@
 package query
 
+import "strings"
+
 func (*BoolLit) node()      {}
 func (*IntervalLit) node()  {}
 func (*NullLit) node()      {}
@@ -71,7 +73,10 @@ func (lit *StringLit) String() string {
 	if lit.Quote == 0 {
 		return lit.Value
 	}
-	return string(lit.Quote) + lit.Value + string(endQuote(lit.Quote))
+	end := endQuote(lit.Quote)
+	value := strings.ReplaceAll(lit.Value, `\`, `\\`)
+	value = strings.ReplaceAll(value, string(end), `\`+string(end))
+	return string(lit.Quote) + value + string(end)
 }
 
 type TimestampLit struct {
@@ -10,6 +10,7 @@ import (
 
 func TestStringLit_String(t *testing.T) {
 	AssertExprStringer(t, &query.StringLit{Value: "foo"}, `foo`)
+	AssertExprStringer(t, &query.StringLit{Value: "it's", Quote: '\''}, `'it\'s'`)
 }
 
 func TestNumberLit_String(t *testing.T) {
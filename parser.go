@@ -13,6 +13,29 @@ type Parser struct {
 	tok  Token  // current token
 	lit  string // current literal value
 	full bool   // buffer full
+
+	// Tokens after the buffered one, scanned by peekAt.
+	ahead []token
+
+	comments []Comment // comments skipped so far
+}
+
+type token struct {
+	pos Pos
+	tok Token
+	lit string
+}
+
+// Comment represents a comment skipped by the parser.
+type Comment struct {
+	Pos  Pos    `json:"pos"`
+	Text string `json:"text"` // including the -- or /* */ delimiters
+}
+
+// Comments returns the comments skipped by the parser so far, in the order
+// they appear in the input.
+func (p *Parser) Comments() []Comment {
+	return p.comments
 }
 
 // NewParser returns a new instance of Parser that reads from r.
@@ -45,16 +68,29 @@ func (p *Parser) scan() (Pos, Token, string) {
 		return p.pos, p.tok, p.lit
 	}
 
-	// Continue scanning until we find a non-comment token.
+	if len(p.ahead) != 0 {
+		next := p.ahead[0]
+		p.ahead = p.ahead[1:]
+		p.pos, p.tok, p.lit = next.pos, next.tok, next.lit
+		return p.pos, p.tok, p.lit
+	}
+	p.pos, p.tok, p.lit = p.scanToken()
+	return p.pos, p.tok, p.lit
+}
+
+// scanToken scans the next non-comment token from the scanner.
+func (p *Parser) scanToken() (Pos, Token, string) {
 	for {
-		if pos, tok, lit := p.s.Scan(); tok != COMMENT {
-			p.pos, p.tok, p.lit = pos, tok, lit
-			return p.pos, p.tok, p.lit
+		pos, tok, lit := p.s.Scan()
+		if tok != COMMENT {
+			return pos, tok, lit
 		}
+		p.comments = append(p.comments, Comment{Pos: pos, Text: lit})
 	}
 }
 
 func (p *Parser) scanUntil(cond Condition, escape rune) (Pos, string, error) {
+	assert(len(p.ahead) == 0)
 	pos, str, err := p.s.ScanUntil(cond, escape)
 	if err != nil {
 		return pos, str, err
@@ -126,6 +162,50 @@ func (p *Parser) peekScan() (Pos, Token, string) { // nolint
 	return p.pos, p.tok, p.lit
 }
 
+// peek2 returns the token after the next one, for keywords whose meaning
+// depends on the token that follows them.
+func (p *Parser) peek2() Token {
+	return p.peekAt(2)
+}
+
+// peekAt returns the nth token ahead without consuming it, where peekAt(1)
+// is the next token.
+func (p *Parser) peekAt(n int) Token {
+	p.peek()
+	if n == 1 {
+		return p.tok
+	}
+	for len(p.ahead) < n-1 {
+		pos, tok, lit := p.scanToken()
+		p.ahead = append(p.ahead, token{pos: pos, tok: tok, lit: lit})
+		if tok == EOF {
+			break
+		}
+	}
+	if n-2 >= len(p.ahead) {
+		return EOF
+	}
+	return p.ahead[n-2].tok
+}
+
+// peekWord returns true if the next token is the unreserved keyword word,
+// which the scanner returns as an identifier.
+func (p *Parser) peekWord(word string) bool {
+	_, tok, lit := p.peekScan()
+	return tok == IDENT && strings.EqualFold(lit, word)
+}
+
+// peekWordAt returns true if the nth token ahead is the unreserved keyword
+// word, where peekWordAt(1, word) is peekWord(word).
+func (p *Parser) peekWordAt(n int, word string) bool {
+	if n == 1 {
+		return p.peekWord(word)
+	} else if p.peekAt(n) != IDENT {
+		return false
+	}
+	return strings.EqualFold(p.ahead[n-2].lit, word)
+}
+
 func (p *Parser) unscan() {
 	assert(!p.full)
 	p.full = true
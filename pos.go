@@ -1,6 +1,9 @@
 package query
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+)
 
 type Pos struct {
 	Offset int `json:"offset"`
@@ -25,6 +28,42 @@ func (p Pos) IsValid() bool {
 	return p != Pos{}
 }
 
+// NodePos returns the position of the first token of n, or the zero position
+// if n holds no positions.
+func NodePos(n Node) Pos {
+	return firstPos(valueOf(n))
+}
+
+func firstPos(v reflect.Value) Pos {
+	if isNilValue(v) {
+		return Pos{}
+	}
+
+	v = elem(v)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == posType {
+			return v.Interface().(Pos)
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if pos := firstPos(v.Field(i)); pos.IsValid() {
+				return pos
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if pos := firstPos(v.Index(i)); pos.IsValid() {
+				return pos
+			}
+		}
+	}
+	return Pos{}
+}
+
 func assert(condition bool) {
 	if !condition {
 		panic("assert failed")
@@ -9,13 +9,41 @@ func (*JoinClause) node()                 {}
 func (*ParenSource) node()                {}
 func (*QualifiedTableName) node()         {}
 func (*QualifiedTableFunctionName) node() {}
+func (*PivotSource) node()                {}
+func (*UnpivotSource) node()              {}
+func (*PivotExpr) node()                  {}
+func (*UnnestSource) node()               {}
+func (*SampleSource) node()               {}
 func (*SelectStatement) node()            {}
+func (*SetOperation) node()               {}
 func (*OnConstraint) node()               {}
 func (*UsingConstraint) node()            {}
+func (*WithClause) node()                 {}
+func (*CTE) node()                        {}
+func (*Within) node()                     {}
+func (*ResultColumn) node()               {}
+func (*LateralView) node()                {}
+func (*JoinOperator) node()               {}
+func (*OverClause) node()                 {}
+func (*OrderingTerm) node()               {}
+func (*Window) node()                     {}
+func (*WindowDefinition) node()           {}
+func (*FrameSpec) node()                  {}
+func (*FrameBound) node()                 {}
+func (*GroupingSets) node()               {}
+func (*Rollup) node()                     {}
+func (*Cube) node()                       {}
+
+// Grouping elements are expressions so they can be listed with the plain
+// expressions of a GROUP BY.
+func (*GroupingSets) expr() {}
+func (*Rollup) expr()       {}
+func (*Cube) expr()         {}
 
 func (*SelectStatement) stmt() {}
 
 type SelectStatement struct {
+	Lparen     Pos         `json:"lparen"` // set on parenthesised operands of a SetOperation
 	WithClause *WithClause `json:"with_clause"`
 
 	Values     Pos         `json:"values"`
@@ -32,47 +60,124 @@ type SelectStatement struct {
 	Where     Pos  `json:"where"`
 	WhereExpr Expr `json:"where_expr"`
 
+	// GroupByExprs holds the grouping elements: expressions, Rollup, Cube
+	// and GroupingSets.
 	Group        Pos    `json:"group"`
 	GroupBy      Pos    `json:"group_by"`
 	GroupByAll   Pos    `json:"group_by_all"`
 	GroupByExprs []Expr `json:"group_by_exprs"`
-	Grouping     Pos    `json:"grouping"`
-	GroupingSet  Pos    `json:"grouping_set"`
-	GroupingExpr Expr   `json:"grouping_expr"`
-	Having       Pos    `json:"having"`
-	HavingExpr   Expr   `json:"having_expr"`
-	Qualify      Pos    `json:"qualify"`
-	QualifyExpr  Expr   `json:"qualify_expr"`
+
+	// GroupingSets, WITH ROLLUP or WITH CUBE follow GroupByExprs in the Hive
+	// forms "GROUP BY a, b GROUPING SETS (...)" and "GROUP BY a, b WITH
+	// ROLLUP", which group by sets of those expressions.
+	GroupingSets *GroupingSets `json:"grouping_sets"`
+	GroupByWith  Pos           `json:"group_by_with"`
+	WithRollup   Pos           `json:"with_rollup"`
+	WithCube     Pos           `json:"with_cube"`
+
+	Having      Pos  `json:"having"`
+	HavingExpr  Expr `json:"having_expr"`
+	Qualify     Pos  `json:"qualify"`
+	QualifyExpr Expr `json:"qualify_expr"`
 
 	Window  Pos       `json:"window"`
 	Windows []*Window `json:"windows"`
 
-	Union     Pos              `json:"union"`
-	UnionDist Pos              `json:"union_dist"`
-	UnionAll  Pos              `json:"union_all"`
-	Intersect Pos              `json:"intersect"`
-	Compound  *SelectStatement `json:"compound"` // Also used for Not Exists
+	// Compound is set if the statement combines queries with a set
+	// operation, in which case the clauses above are unused.
+	Compound *SetOperation `json:"compound"`
 
 	Order         Pos             `json:"order"`
 	OrderBy       Pos             `json:"order_by"`
 	OrderingTerms []*OrderingTerm `json:"ordering_terms"`
 
+	// CLUSTER BY, or DISTRIBUTE BY and SORT BY, as in Hive and Spark.
+	Cluster         Pos             `json:"cluster"`
+	ClusterBy       Pos             `json:"cluster_by"`
+	ClusterExprs    []Expr          `json:"cluster_exprs"`
+	Distribute      Pos             `json:"distribute"`
+	DistributeBy    Pos             `json:"distribute_by"`
+	DistributeExprs []Expr          `json:"distribute_exprs"`
+	Sort            Pos             `json:"sort"`
+	SortBy          Pos             `json:"sort_by"`
+	SortTerms       []*OrderingTerm `json:"sort_terms"`
+
 	Limit       Pos  `json:"limit"`
 	LimitExpr   Expr `json:"limit_expr"`
 	Offset      Pos  `json:"offset"`
 	OffsetComma Pos  `json:"offset_comma"`
 	OffsetExpr  Expr `json:"offset_expr"`
+
+	Rparen Pos `json:"rparen"`
+}
+
+// Operands returns the queries combined by the set operations of s, from
+// left to right, including those of parenthesised operands. It returns s
+// itself if s is not compound.
+func (s *SelectStatement) Operands() []*SelectStatement {
+	if s.Compound == nil {
+		return []*SelectStatement{s}
+	}
+	return append(s.Compound.X.Operands(), s.Compound.Y.Operands()...)
+}
+
+// GroupingExprs returns the expressions grouped by s, with those of its
+// ROLLUP, CUBE and GROUPING SETS elements, in the order they first appear.
+// Repeated expressions are returned once; identifiers are compared
+// case-insensitively.
+func (s *SelectStatement) GroupingExprs() []Expr {
+	var exprs []Expr
+	var add func(Expr)
+	add = func(expr Expr) {
+		switch expr := expr.(type) {
+		case *Rollup:
+			for _, x := range expr.Exprs {
+				add(x)
+			}
+		case *Cube:
+			for _, x := range expr.Exprs {
+				add(x)
+			}
+		case *GroupingSets:
+			for _, x := range expr.Exprs {
+				add(x)
+			}
+		case *ExprList:
+			for _, x := range expr.Exprs {
+				add(x)
+			}
+		default:
+			for _, x := range exprs {
+				if Equal(x, expr, IgnorePositions(), IgnoreIdentCase()) {
+					return
+				}
+			}
+			exprs = append(exprs, expr)
+		}
+	}
+	for _, expr := range s.GroupByExprs {
+		add(expr)
+	}
+	if s.GroupingSets != nil {
+		add(s.GroupingSets)
+	}
+	return exprs
 }
 
 // String returns the string representation of the statement.
 func (s *SelectStatement) String() string {
 	var buf bytes.Buffer
+	if s.Lparen.IsValid() {
+		buf.WriteString("(")
+	}
 	if s.WithClause != nil {
 		buf.WriteString(s.WithClause.String())
 		buf.WriteString(" ")
 	}
 
-	if len(s.ValueLists) > 0 {
+	if s.Compound != nil {
+		buf.WriteString(s.Compound.String())
+	} else if len(s.ValueLists) > 0 {
 		buf.WriteString("VALUES ")
 		for i, exprs := range s.ValueLists {
 			if i != 0 {
@@ -113,11 +218,8 @@ func (s *SelectStatement) String() string {
 
 		if len(s.GroupByExprs) != 0 || s.GroupByAll.IsValid() {
 			buf.WriteString(" GROUP BY ")
-			if s.GroupBy.IsValid() {
+			if s.GroupByAll.IsValid() {
 				buf.WriteString("ALL")
-			} else if s.Grouping.IsValid() {
-				buf.WriteString(" GROUPING SETS ")
-				buf.WriteString(s.GroupingSet.String())
 			} else {
 				for i, expr := range s.GroupByExprs {
 					if i != 0 {
@@ -127,12 +229,20 @@ func (s *SelectStatement) String() string {
 				}
 			}
 
+			if s.GroupingSets != nil {
+				fmt.Fprintf(&buf, " %s", s.GroupingSets.String())
+			} else if s.WithRollup.IsValid() {
+				buf.WriteString(" WITH ROLLUP")
+			} else if s.WithCube.IsValid() {
+				buf.WriteString(" WITH CUBE")
+			}
+
 			if s.HavingExpr != nil {
 				fmt.Fprintf(&buf, " HAVING %s", s.HavingExpr.String())
 			}
 		}
 		if s.QualifyExpr != nil {
-			fmt.Fprintf(&buf, " QUALIFY %s", s.HavingExpr.String())
+			fmt.Fprintf(&buf, " QUALIFY %s", s.QualifyExpr.String())
 		}
 
 		if len(s.Windows) != 0 {
@@ -146,24 +256,6 @@ func (s *SelectStatement) String() string {
 		}
 	}
 
-	// Write compound operator.
-	if s.Compound != nil {
-		switch {
-		case s.Union.IsValid():
-			buf.WriteString(" UNION")
-			if s.UnionAll.IsValid() {
-				buf.WriteString(" ALL")
-			}
-			if s.UnionDist.IsValid() {
-				buf.WriteString(" DISTINCT ")
-			}
-		case s.Intersect.IsValid():
-			buf.WriteString(" INTERSECT")
-		}
-
-		fmt.Fprintf(&buf, " %s", s.Compound.String())
-	}
-
 	// Write ORDER BY.
 	if len(s.OrderingTerms) != 0 {
 		buf.WriteString(" ORDER BY ")
@@ -175,6 +267,35 @@ func (s *SelectStatement) String() string {
 		}
 	}
 
+	// Write CLUSTER BY, DISTRIBUTE BY and SORT BY.
+	if len(s.ClusterExprs) != 0 {
+		buf.WriteString(" CLUSTER BY ")
+		for i, expr := range s.ClusterExprs {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(expr.String())
+		}
+	}
+	if len(s.DistributeExprs) != 0 {
+		buf.WriteString(" DISTRIBUTE BY ")
+		for i, expr := range s.DistributeExprs {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(expr.String())
+		}
+	}
+	if len(s.SortTerms) != 0 {
+		buf.WriteString(" SORT BY ")
+		for i, term := range s.SortTerms {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(term.String())
+		}
+	}
+
 	// Write LIMIT/OFFSET.
 	if s.LimitExpr != nil {
 		fmt.Fprintf(&buf, " LIMIT %s", s.LimitExpr.String())
@@ -183,9 +304,87 @@ func (s *SelectStatement) String() string {
 		}
 	}
 
+	if s.Lparen.IsValid() {
+		buf.WriteString(")")
+	}
 	return buf.String()
 }
 
+// SetOperation combines the rows of two queries with UNION, INTERSECT,
+// EXCEPT or MINUS. INTERSECT binds tighter than the other operators, and
+// operators of the same precedence are evaluated left to right, so
+// "a UNION b INTERSECT c EXCEPT d" is parsed as "(a UNION (b INTERSECT c))
+// EXCEPT d". Parenthesised operands may have their own ORDER BY and LIMIT.
+type SetOperation struct {
+	X         *SelectStatement `json:"x"`
+	Union     Pos              `json:"union"`
+	Intersect Pos              `json:"intersect"`
+	Except    Pos              `json:"except"`
+	Minus     Pos              `json:"minus"`
+	All       Pos              `json:"all"`
+	Distinct  Pos              `json:"distinct"`
+	Y         *SelectStatement `json:"y"`
+}
+
+// Op returns the operator keyword, e.g. "UNION", and its position.
+func (op *SetOperation) Op() (Pos, string) {
+	switch {
+	case op.Intersect.IsValid():
+		return op.Intersect, "INTERSECT"
+	case op.Except.IsValid():
+		return op.Except, "EXCEPT"
+	case op.Minus.IsValid():
+		return op.Minus, "MINUS"
+	default:
+		return op.Union, "UNION"
+	}
+}
+
+// precedence returns the binding strength of the operator.
+func (op *SetOperation) precedence() int {
+	if op.Intersect.IsValid() {
+		return 2
+	}
+	return 1
+}
+
+// String returns the string representation of the operation.
+func (op *SetOperation) String() string {
+	var buf bytes.Buffer
+	buf.WriteString(op.operandString(op.X, false))
+
+	_, name := op.Op()
+	fmt.Fprintf(&buf, " %s", name)
+	if op.All.IsValid() {
+		buf.WriteString(" ALL")
+	} else if op.Distinct.IsValid() {
+		buf.WriteString(" DISTINCT")
+	}
+
+	fmt.Fprintf(&buf, " %s", op.operandString(op.Y, true))
+	return buf.String()
+}
+
+// operandString returns the string representation of the operand x,
+// parenthesised where required to keep its meaning.
+func (op *SetOperation) operandString(x *SelectStatement, right bool) string {
+	if x.Lparen.IsValid() {
+		return x.String()
+	}
+
+	paren := x.WithClause != nil || len(x.OrderingTerms) != 0 || x.LimitExpr != nil ||
+		len(x.ClusterExprs) != 0 || len(x.DistributeExprs) != 0 || len(x.SortTerms) != 0
+	if x.Compound != nil {
+		if prec := x.Compound.precedence(); prec < op.precedence() || (right && prec == op.precedence()) {
+			paren = true
+		}
+	}
+	if paren {
+		return "(" + x.String() + ")"
+	}
+	return x.String()
+}
+
 type WithClause struct {
 	With      Pos    `json:"with"`
 	Recursive Pos    `json:"recursive"`
@@ -264,7 +463,7 @@ func (wi *Within) String() string {
 	buf.WriteString(wi.OrderingTerm.String())
 	if wi.GroupLimit.IsValid() {
 		buf.WriteString(" LIMIT ")
-		buf.WriteString(wi.GroupLimit.String())
+		buf.WriteString(wi.GroupLimitExpr.String())
 	}
 	buf.WriteString(")")
 	if wi.Index != nil {
@@ -282,8 +481,6 @@ type ResultColumn struct {
 
 	Except    Pos  `json:"except"`
 	ExceptCol Expr `json:"except_col"`
-
-	Within *Within `json:"within"`
 }
 
 // String returns the string representation of the column.
@@ -302,10 +499,6 @@ func (c *ResultColumn) String() string {
 		return exp + " EXCEPT " + c.ExceptCol.String()
 	}
 
-	if c.Within != nil {
-		return exp + " " + c.Within.String()
-	}
-
 	return exp
 }
 
@@ -319,10 +512,16 @@ func (*JoinClause) source()                 {}
 func (*ParenSource) source()                {}
 func (*QualifiedTableName) source()         {}
 func (*QualifiedTableFunctionName) source() {}
+func (*PivotSource) source()                {}
+func (*UnpivotSource) source()              {}
+func (*UnnestSource) source()               {}
+func (*SampleSource) source()               {}
+func (*LateralView) source()                {}
 func (*SelectStatement) source()            {}
 
 // SourceName returns the name of the source.
-// Only returns for QualifiedTableName & ParenSource.
+// Only returns for QualifiedTableName, ParenSource, pivots, UNNEST, samples
+// and lateral views.
 func SourceName(src Source) string {
 	switch src := src.(type) {
 	case *JoinClause, *SelectStatement:
@@ -331,6 +530,25 @@ func SourceName(src Source) string {
 		return IdentName(src.Alias)
 	case *QualifiedTableName:
 		return src.TableName()
+	case *PivotSource:
+		if src.Alias != nil {
+			return IdentName(src.Alias)
+		}
+		return SourceName(src.X)
+	case *UnpivotSource:
+		if src.Alias != nil {
+			return IdentName(src.Alias)
+		}
+		return SourceName(src.X)
+	case *UnnestSource:
+		return IdentName(src.Alias)
+	case *SampleSource:
+		if src.Alias != nil {
+			return IdentName(src.Alias)
+		}
+		return SourceName(src.X)
+	case *LateralView:
+		return IdentName(src.TableAlias)
 	default:
 		return ""
 	}
@@ -368,6 +586,10 @@ func forEachSource(src Source, fn func(Source) bool) bool {
 		if !forEachSource(src.Source, fn) {
 			return false
 		}
+	case *LateralView:
+		if !forEachSource(src.X, fn) {
+			return false
+		}
 	}
 	return true
 }
@@ -386,18 +608,25 @@ func ResolveSource(root Source, name string) Source {
 			if src.TableName() == name {
 				ret = src
 			}
+		case *PivotSource, *UnpivotSource, *UnnestSource, *SampleSource, *LateralView:
+			if SourceName(src) == name {
+				ret = src
+			}
 		}
 		return ret == nil // continue until we find the matching source
 	})
 	return ret
 }
 
-// LateralView can be used to create a view from array or map column
-// Defined in maxcompute like
+// LateralView joins each row of X with the rows generated from it by a
+// table function, defined in Hive, Spark and MaxCompute like
+//
+//	<source> LATERAL VIEW [OUTER] <udtf_name>(<expression>) <table_alias> AS <columnAlias> (',' <columnAlias>)
 //
-//	LATERALVIEW: LATERAL VIEW [OUTER] <udtf_name>(<expression>) <table_alias> AS <columnAlias> (',' <columnAlias>)
-//	fromClause: FROM <baseTable> (LATERALVIEW) [(LATERALVIEW) ...]
+// Lateral views may follow a table, a subquery or a join, and may be
+// chained.
 type LateralView struct {
+	X          Source   `json:"x"`
 	Lateral    Pos      `json:"lateral"`
 	View       Pos      `json:"view"`
 	Outer      Pos      `json:"outer"`
@@ -407,12 +636,17 @@ type LateralView struct {
 	ColAlias   []*Ident `json:"col_alias"`
 }
 
+// String returns the string representation of the source.
 func (l *LateralView) String() string {
 	var buf bytes.Buffer
+	if l.X != nil {
+		buf.WriteString(l.X.String())
+		buf.WriteString(" ")
+	}
 
-	buf.WriteString("Lateral View ")
+	buf.WriteString("LATERAL VIEW ")
 	if l.Outer.IsValid() {
-		buf.WriteString("Outer ")
+		buf.WriteString("OUTER ")
 	}
 
 	if l.Udtf != nil {
@@ -424,7 +658,7 @@ func (l *LateralView) String() string {
 		buf.WriteString(" ")
 	}
 	if l.As.IsValid() {
-		buf.WriteString("As ")
+		buf.WriteString("AS ")
 	}
 	for i, col := range l.ColAlias {
 		if i != 0 {
@@ -440,8 +674,6 @@ type QualifiedTableName struct {
 	Name  *MultiPartIdent `json:"name"`
 	As    Pos             `json:"as"`
 	Alias *Ident          `json:"alias"`
-
-	LateralViews []*LateralView `json:"lateral_views"`
 }
 
 // TableName returns the name used to identify n.
@@ -460,11 +692,6 @@ func (n *QualifiedTableName) String() string {
 	if n.Alias != nil {
 		fmt.Fprintf(&buf, " AS %s", n.Alias.String())
 	}
-
-	for _, lv := range n.LateralViews {
-		fmt.Fprintf(&buf, " %s", lv.String())
-	}
-
 	return buf.String()
 }
 
@@ -484,6 +711,235 @@ func (s *ParenSource) String() string {
 	return fmt.Sprintf("(%s)", s.X.String())
 }
 
+// PivotSource rotates the rows of X into columns, defined in BigQuery,
+// Spark and MaxCompute like
+//
+//	FROM <source> PIVOT (<aggregate> [AS alias], ... FOR <column> IN (<value> [AS alias], ...)) [AS alias]
+//
+// Each value generates a column per aggregate, named by ColumnName. The
+// columns of X other than the aggregated and pivot columns group the rows.
+type PivotSource struct {
+	X          Source       `json:"x"`
+	Pivot      Pos          `json:"pivot"`
+	Lparen     Pos          `json:"lparen"`
+	Aggregates []*PivotExpr `json:"aggregates"`
+	For        Pos          `json:"for"`
+	Column     Expr         `json:"column"` // column, or ExprList of columns
+	In         Pos          `json:"in"`
+	InLparen   Pos          `json:"in_lparen"`
+	Values     []*PivotExpr `json:"values"`
+	InRparen   Pos          `json:"in_rparen"`
+	Rparen     Pos          `json:"rparen"`
+	As         Pos          `json:"as"`
+	Alias      *Ident       `json:"alias"`
+}
+
+// ColumnName returns the name of the column generated for value and
+// aggregate. Names follow Spark and MaxCompute: the name of the value,
+// suffixed with the name of the aggregate if there are several.
+func (s *PivotSource) ColumnName(value, aggregate *PivotExpr) string {
+	if len(s.Aggregates) < 2 {
+		return value.Name()
+	}
+	return value.Name() + "_" + aggregate.Name()
+}
+
+// String returns the string representation of the source.
+func (s *PivotSource) String() string {
+	var buf bytes.Buffer
+	buf.WriteString(s.X.String())
+	buf.WriteString(" PIVOT (")
+	for i, agg := range s.Aggregates {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(agg.String())
+	}
+	fmt.Fprintf(&buf, " FOR %s IN (", s.Column.String())
+	for i, value := range s.Values {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(value.String())
+	}
+	buf.WriteString("))")
+	if s.Alias != nil {
+		fmt.Fprintf(&buf, " AS %s", s.Alias.String())
+	}
+	return buf.String()
+}
+
+// UnpivotSource rotates columns of X into rows, defined in BigQuery, Spark
+// and MaxCompute like
+//
+//	FROM <source> UNPIVOT [INCLUDE NULLS | EXCLUDE NULLS] (<value column> FOR <name column> IN (<column> [AS alias], ...)) [AS alias]
+//
+// Each listed column generates a row holding its value in Value and its
+// name in Name. Value and the listed columns may be lists of columns.
+type UnpivotSource struct {
+	X        Source       `json:"x"`
+	Unpivot  Pos          `json:"unpivot"`
+	Include  Pos          `json:"include"`
+	Exclude  Pos          `json:"exclude"`
+	Nulls    Pos          `json:"nulls"`
+	Lparen   Pos          `json:"lparen"`
+	Value    Expr         `json:"value"` // column, or ExprList of columns
+	For      Pos          `json:"for"`
+	Name     *Ident       `json:"name"`
+	In       Pos          `json:"in"`
+	InLparen Pos          `json:"in_lparen"`
+	Columns  []*PivotExpr `json:"columns"`
+	InRparen Pos          `json:"in_rparen"`
+	Rparen   Pos          `json:"rparen"`
+	As       Pos          `json:"as"`
+	Alias    *Ident       `json:"alias"`
+}
+
+// String returns the string representation of the source.
+func (s *UnpivotSource) String() string {
+	var buf bytes.Buffer
+	buf.WriteString(s.X.String())
+	buf.WriteString(" UNPIVOT")
+	if s.Include.IsValid() {
+		buf.WriteString(" INCLUDE NULLS")
+	} else if s.Exclude.IsValid() {
+		buf.WriteString(" EXCLUDE NULLS")
+	}
+	fmt.Fprintf(&buf, " (%s FOR %s IN (", s.Value.String(), s.Name.String())
+	for i, col := range s.Columns {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(col.String())
+	}
+	buf.WriteString("))")
+	if s.Alias != nil {
+		fmt.Fprintf(&buf, " AS %s", s.Alias.String())
+	}
+	return buf.String()
+}
+
+// PivotExpr is an aggregate or value of a PIVOT, or a column of an UNPIVOT,
+// with an optional alias.
+type PivotExpr struct {
+	X     Expr   `json:"x"`
+	As    Pos    `json:"as"`
+	Alias *Ident `json:"alias"`
+}
+
+// Name returns the alias of e, the value of a string literal, or the text
+// of the expression.
+func (e *PivotExpr) Name() string {
+	if e.Alias != nil {
+		return e.Alias.Name
+	}
+	if lit, ok := e.X.(*StringLit); ok {
+		return lit.Value
+	}
+	return e.X.String()
+}
+
+// String returns the string representation of the expression.
+func (e *PivotExpr) String() string {
+	if e.Alias != nil {
+		return fmt.Sprintf("%s AS %s", e.X.String(), e.Alias.String())
+	}
+	return e.X.String()
+}
+
+// UnnestSource produces a row for each element of an array, defined in
+// BigQuery like
+//
+//	UNNEST(<array>) [[AS] alias] [WITH OFFSET [[AS] alias]]
+//
+// The alias names the element column. WITH OFFSET adds a column holding
+// the index of the element, named "offset" unless it has an alias.
+type UnnestSource struct {
+	Unnest      Pos    `json:"unnest"`
+	Lparen      Pos    `json:"lparen"`
+	X           Expr   `json:"x"`
+	Rparen      Pos    `json:"rparen"`
+	As          Pos    `json:"as"`
+	Alias       *Ident `json:"alias"`
+	With        Pos    `json:"with"`
+	Offset      Pos    `json:"offset"`
+	OffsetAs    Pos    `json:"offset_as"`
+	OffsetAlias *Ident `json:"offset_alias"`
+}
+
+// String returns the string representation of the source.
+func (s *UnnestSource) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "UNNEST(%s)", s.X.String())
+	if s.Alias != nil {
+		fmt.Fprintf(&buf, " AS %s", s.Alias.String())
+	}
+	if s.With.IsValid() {
+		buf.WriteString(" WITH OFFSET")
+		if s.OffsetAlias != nil {
+			fmt.Fprintf(&buf, " AS %s", s.OffsetAlias.String())
+		}
+	}
+	return buf.String()
+}
+
+// SampleSource reads a sample of the rows of X, defined in BigQuery, Spark
+// and Hive like
+//
+//	<source> TABLESAMPLE [SYSTEM | BERNOULLI] (<size> [PERCENT | ROWS]) [[AS] alias]
+//	<source> TABLESAMPLE (BUCKET <n> OUT OF <m> [ON <expr>]) [[AS] alias]
+type SampleSource struct {
+	X           Source `json:"x"`
+	Tablesample Pos    `json:"tablesample"`
+	Method      *Ident `json:"method"` // SYSTEM, BERNOULLI, or nil
+	Lparen      Pos    `json:"lparen"`
+
+	Size    Expr `json:"size"`
+	Percent Pos  `json:"percent"`
+	Rows    Pos  `json:"rows"`
+
+	Bucket     Pos  `json:"bucket"`
+	BucketExpr Expr `json:"bucket_expr"`
+	Out        Pos  `json:"out"`
+	Of         Pos  `json:"of"`
+	Buckets    Expr `json:"buckets"`
+	On         Pos  `json:"on"`
+	OnExpr     Expr `json:"on_expr"`
+
+	Rparen Pos    `json:"rparen"`
+	As     Pos    `json:"as"`
+	Alias  *Ident `json:"alias"`
+}
+
+// String returns the string representation of the source.
+func (s *SampleSource) String() string {
+	var buf bytes.Buffer
+	buf.WriteString(s.X.String())
+	buf.WriteString(" TABLESAMPLE ")
+	if s.Method != nil {
+		fmt.Fprintf(&buf, "%s ", s.Method.String())
+	}
+	buf.WriteString("(")
+	if s.Bucket.IsValid() {
+		fmt.Fprintf(&buf, "BUCKET %s OUT OF %s", s.BucketExpr.String(), s.Buckets.String())
+		if s.OnExpr != nil {
+			fmt.Fprintf(&buf, " ON %s", s.OnExpr.String())
+		}
+	} else {
+		buf.WriteString(s.Size.String())
+		if s.Percent.IsValid() {
+			buf.WriteString(" PERCENT")
+		} else if s.Rows.IsValid() {
+			buf.WriteString(" ROWS")
+		}
+	}
+	buf.WriteString(")")
+	if s.Alias != nil {
+		fmt.Fprintf(&buf, " AS %s", s.Alias.String())
+	}
+	return buf.String()
+}
+
 type JoinClause struct {
 	X          Source         `json:"x"`
 	Operator   *JoinOperator  `json:"operator"`
@@ -617,13 +1073,23 @@ type JoinOperator struct {
 	Comma   Pos `json:"comma"`
 	Natural Pos `json:"natural"`
 	Left    Pos `json:"left"`
+	Right   Pos `json:"right"`
 	Outer   Pos `json:"outer"`
 	Full    Pos `json:"full"`
 	Inner   Pos `json:"inner"`
 	Cross   Pos `json:"cross"`
+	Semi    Pos `json:"semi"`
+	Anti    Pos `json:"anti"`
 	Join    Pos `json:"join"`
 }
 
+// IsFilter returns true for semi and anti joins, which filter the rows of
+// the left side by whether they match the right side. The columns of the
+// right side are not part of the result.
+func (op *JoinOperator) IsFilter() bool {
+	return op.Semi.IsValid() || op.Anti.IsValid()
+}
+
 // String returns the string representation of the operator.
 func (op *JoinOperator) String() string {
 	if op.Comma.IsValid() {
@@ -636,18 +1102,21 @@ func (op *JoinOperator) String() string {
 	}
 	if op.Left.IsValid() {
 		buf.WriteString(" LEFT")
-		if op.Outer.IsValid() {
-			buf.WriteString(" OUTER")
-		}
+	} else if op.Right.IsValid() {
+		buf.WriteString(" RIGHT")
 	} else if op.Inner.IsValid() {
 		buf.WriteString(" INNER")
 	} else if op.Cross.IsValid() {
 		buf.WriteString(" CROSS")
 	} else if op.Full.IsValid() {
 		buf.WriteString(" FULL")
-		if op.Outer.IsValid() {
-			buf.WriteString(" OUTER")
-		}
+	}
+	if op.Outer.IsValid() {
+		buf.WriteString(" OUTER")
+	} else if op.Semi.IsValid() {
+		buf.WriteString(" SEMI")
+	} else if op.Anti.IsValid() {
+		buf.WriteString(" ANTI")
 	}
 	buf.WriteString(" JOIN ")
 
@@ -736,10 +1205,63 @@ func (t *OrderingTerm) String() string {
 	return buf.String()
 }
 
+// GroupingSets is a "GROUPING SETS (...)" element of a GROUP BY. Each set
+// is an ExprList, which is empty for the grand total "()", or a single
+// expression, Rollup or Cube.
+type GroupingSets struct {
+	Grouping Pos    `json:"grouping"`
+	Sets     Pos    `json:"sets"`
+	Lparen   Pos    `json:"lparen"`
+	Exprs    []Expr `json:"exprs"`
+	Rparen   Pos    `json:"rparen"`
+}
+
+// String returns the string representation of the element.
+func (g *GroupingSets) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("GROUPING SETS (")
+	for i, expr := range g.Exprs {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(expr.String())
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// Rollup is a "ROLLUP (a, b)" element of a GROUP BY, which groups by each
+// prefix of its expressions: (a, b), (a) and ().
+type Rollup struct {
+	Rollup Pos    `json:"rollup"`
+	Lparen Pos    `json:"lparen"`
+	Exprs  []Expr `json:"exprs"`
+	Rparen Pos    `json:"rparen"`
+}
+
+// String returns the string representation of the element.
+func (r *Rollup) String() string {
+	return "ROLLUP " + (&ExprList{Exprs: r.Exprs}).String()
+}
+
+// Cube is a "CUBE (a, b)" element of a GROUP BY, which groups by every
+// subset of its expressions.
+type Cube struct {
+	Cube   Pos    `json:"cube"`
+	Lparen Pos    `json:"lparen"`
+	Exprs  []Expr `json:"exprs"`
+	Rparen Pos    `json:"rparen"`
+}
+
+// String returns the string representation of the element.
+func (c *Cube) String() string {
+	return "CUBE " + (&ExprList{Exprs: c.Exprs}).String()
+}
+
 type Window struct {
-	Name       *Ident
-	As         Pos
-	Definition *WindowDefinition
+	Name       *Ident            `json:"name"`
+	As         Pos               `json:"as"`
+	Definition *WindowDefinition `json:"definition"`
 }
 
 // String returns the string representation of the window.
@@ -756,6 +1278,7 @@ type WindowDefinition struct {
 	Order         Pos             `json:"order"`
 	OrderBy       Pos             `json:"order_by"`
 	OrderingTerms []*OrderingTerm `json:"ordering_terms"`
+	Frame         *FrameSpec      `json:"frame"`
 	Rparen        Pos             `json:"rparen"`
 }
 
@@ -795,7 +1318,101 @@ func (d *WindowDefinition) String() string {
 		}
 	}
 
+	if d.Frame != nil {
+		if buf.Len() > 1 {
+			buf.WriteString(" ")
+		}
+		buf.WriteString(d.Frame.String())
+	}
+
 	buf.WriteString(")")
 
 	return buf.String()
 }
+
+// FrameSpec is the frame clause of a window definition, e.g.
+// "ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW". End is nil unless the
+// frame has a BETWEEN.
+type FrameSpec struct {
+	Rows              Pos         `json:"rows"`
+	Range             Pos         `json:"range"`
+	Groups            Pos         `json:"groups"`
+	Between           Pos         `json:"between"`
+	Start             *FrameBound `json:"start"`
+	And               Pos         `json:"and"`
+	End               *FrameBound `json:"end"`
+	Exclude           Pos         `json:"exclude"`
+	ExcludeCurrentRow Pos         `json:"exclude_current_row"`
+	ExcludeGroup      Pos         `json:"exclude_group"`
+	ExcludeTies       Pos         `json:"exclude_ties"`
+	ExcludeNoOthers   Pos         `json:"exclude_no_others"`
+}
+
+// Unit returns the frame unit: "ROWS", "RANGE" or "GROUPS".
+func (f *FrameSpec) Unit() string {
+	switch {
+	case f.Rows.IsValid():
+		return "ROWS"
+	case f.Groups.IsValid():
+		return "GROUPS"
+	default:
+		return "RANGE"
+	}
+}
+
+// String returns the string representation of the frame.
+func (f *FrameSpec) String() string {
+	var buf bytes.Buffer
+	buf.WriteString(f.Unit())
+
+	if f.End != nil {
+		fmt.Fprintf(&buf, " BETWEEN %s AND %s", f.Start.String(), f.End.String())
+	} else if f.Start != nil {
+		fmt.Fprintf(&buf, " %s", f.Start.String())
+	}
+
+	switch {
+	case f.ExcludeCurrentRow.IsValid():
+		buf.WriteString(" EXCLUDE CURRENT ROW")
+	case f.ExcludeGroup.IsValid():
+		buf.WriteString(" EXCLUDE GROUP")
+	case f.ExcludeTies.IsValid():
+		buf.WriteString(" EXCLUDE TIES")
+	case f.ExcludeNoOthers.IsValid():
+		buf.WriteString(" EXCLUDE NO OTHERS")
+	}
+
+	return buf.String()
+}
+
+// FrameBound is one end of a window frame: "UNBOUNDED PRECEDING",
+// "CURRENT ROW" or "expr PRECEDING/FOLLOWING".
+type FrameBound struct {
+	Unbounded  Pos  `json:"unbounded"`
+	CurrentRow Pos  `json:"current_row"`
+	X          Expr `json:"x"`
+	Preceding  Pos  `json:"preceding"`
+	Following  Pos  `json:"following"`
+}
+
+// String returns the string representation of the frame bound.
+func (b *FrameBound) String() string {
+	if b.CurrentRow.IsValid() {
+		return "CURRENT ROW"
+	}
+
+	var buf bytes.Buffer
+	if b.Unbounded.IsValid() {
+		buf.WriteString("UNBOUNDED")
+	} else if b.X != nil {
+		buf.WriteString(b.X.String())
+	}
+
+	if b.Preceding.IsValid() {
+		buf.WriteString(" PRECEDING")
+	} else if b.Following.IsValid() {
+		buf.WriteString(" FOLLOWING")
+	}
+
+	return buf.String()
+}
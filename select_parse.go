@@ -1,20 +1,272 @@
 package query
 
-// parseSelectStatement parses a SELECT statement.
-// If compounded is true, WITH, ORDER BY, & LIMIT/OFFSET are skipped.
-func (p *Parser) parseSelectStatement(compounded bool, withClause *WithClause) (_ *SelectStatement, err error) {
-	var stmt SelectStatement
-	stmt.WithClause = withClause
+import "strings"
 
+// parseSelectStatement parses a SELECT or VALUES statement, including its
+// set operations, ORDER BY and LIMIT/OFFSET.
+func (p *Parser) parseSelectStatement(withClause *WithClause) (_ *SelectStatement, err error) {
 	// Parse optional "WITH [RECURSIVE} cte, cte..."
 	// This is only called here if this method is called directly. Generic
 	// statement parsing will parse the WITH clause and pass it in instead.
-	if !compounded && stmt.WithClause == nil && p.peek() == WITH {
-		if stmt.WithClause, err = p.parseWithClause(); err != nil {
-			return &stmt, err
+	if withClause == nil && p.peek() == WITH {
+		if withClause, err = p.parseWithClause(); err != nil {
+			return &SelectStatement{WithClause: withClause}, err
+		}
+	}
+
+	stmt, err := p.parseCompoundSelect(1)
+	if err != nil {
+		return stmt, err
+	}
+	if withClause != nil {
+		if stmt.WithClause != nil {
+			return stmt, &Error{Pos: stmt.WithClause.With, Msg: "unexpected WITH clause"}
+		}
+		stmt.WithClause = withClause
+	}
+
+	// Parse ORDER BY clause.
+	if len(stmt.OrderingTerms) == 0 && p.peek() == ORDER {
+		stmt.Order, _, _ = p.scan()
+		if p.peek() != BY {
+			return stmt, p.errorExpected(p.pos, p.tok, "BY")
+		}
+		stmt.OrderBy, _, _ = p.scan()
+
+		for {
+			term, err := p.parseOrderingTerm()
+			if err != nil {
+				return stmt, err
+			}
+			stmt.OrderingTerms = append(stmt.OrderingTerms, term)
+
+			if p.peek() != COMMA {
+				break
+			}
+			p.scan()
+		}
+	}
+
+	// Parse "CLUSTER BY expr, ..." or "DISTRIBUTE BY expr, ... SORT BY term, ...".
+	if len(stmt.ClusterExprs) == 0 && len(stmt.DistributeExprs) == 0 && len(stmt.SortTerms) == 0 {
+		if err := p.parseClusterClauses(stmt); err != nil {
+			return stmt, err
+		}
+	}
+
+	// Parse LIMIT/OFFSET clause.
+	// The offset is optional. Can be specified with COMMA or OFFSET.
+	// e.g. "LIMIT 1 OFFSET 2" or "LIMIT 1, 2"
+	if stmt.LimitExpr == nil && p.peek() == LIMIT {
+		stmt.Limit, _, _ = p.scan()
+		if stmt.LimitExpr, err = p.ParseExpr(); err != nil {
+			return stmt, err
+		}
+
+		if tok := p.peek(); tok == OFFSET || tok == COMMA {
+			if tok == OFFSET {
+				stmt.Offset, _, _ = p.scan()
+			} else {
+				stmt.OffsetComma, _, _ = p.scan()
+			}
+			if stmt.OffsetExpr, err = p.ParseExpr(); err != nil {
+				return stmt, err
+			}
+		}
+	}
+
+	return stmt, nil
+}
+
+// parseClusterClauses parses the Hive clauses that control how rows are
+// distributed to and sorted within reducers: either CLUSTER BY, or
+// DISTRIBUTE BY and SORT BY, each optional.
+func (p *Parser) parseClusterClauses(stmt *SelectStatement) (err error) {
+	if p.peek() == CLUSTER {
+		stmt.Cluster, _, _ = p.scan()
+		if p.peek() != BY {
+			return p.errorExpected(p.pos, p.tok, "BY")
+		}
+		stmt.ClusterBy, _, _ = p.scan()
+		stmt.ClusterExprs, err = p.parseExprs()
+		return err
+	}
+
+	if p.peek() == DISTRIBUTE {
+		stmt.Distribute, _, _ = p.scan()
+		if p.peek() != BY {
+			return p.errorExpected(p.pos, p.tok, "BY")
+		}
+		stmt.DistributeBy, _, _ = p.scan()
+		if stmt.DistributeExprs, err = p.parseExprs(); err != nil {
+			return err
 		}
 	}
 
+	if p.peek() == SORT {
+		stmt.Sort, _, _ = p.scan()
+		if p.peek() != BY {
+			return p.errorExpected(p.pos, p.tok, "BY")
+		}
+		stmt.SortBy, _, _ = p.scan()
+
+		for {
+			term, err := p.parseOrderingTerm()
+			if err != nil {
+				return err
+			}
+			stmt.SortTerms = append(stmt.SortTerms, term)
+
+			if p.peek() != COMMA {
+				break
+			}
+			p.scan()
+		}
+	}
+	return nil
+}
+
+// parseExprs parses a comma-separated list of one or more expressions.
+func (p *Parser) parseExprs() ([]Expr, error) {
+	var exprs []Expr
+	for {
+		expr, err := p.ParseExpr()
+		if err != nil {
+			return exprs, err
+		}
+		exprs = append(exprs, expr)
+
+		if p.peek() != COMMA {
+			return exprs, nil
+		}
+		p.scan()
+	}
+}
+
+// parseCompoundSelect parses queries combined by set operators that bind at
+// least as tightly as prec. INTERSECT binds tighter than UNION, EXCEPT and
+// MINUS, and operators of the same precedence associate to the left.
+func (p *Parser) parseCompoundSelect(prec int) (_ *SelectStatement, err error) {
+	x, err := p.parseSelectOperand()
+	if err != nil {
+		return x, err
+	}
+
+	for {
+		opPrec := setPrecedence(p.peek())
+		if opPrec == 0 || opPrec < prec {
+			return x, nil
+		}
+
+		op := SetOperation{X: x}
+		switch pos, tok, _ := p.scan(); tok {
+		case UNION:
+			op.Union = pos
+		case INTERSECT:
+			op.Intersect = pos
+		case EXCEPT:
+			op.Except = pos
+		case SETMINUS:
+			op.Minus = pos
+		}
+
+		// Parse optional "ALL" or "DISTINCT".
+		if tok := p.peek(); tok == ALL {
+			op.All, _, _ = p.scan()
+		} else if tok == DISTINCT {
+			op.Distinct, _, _ = p.scan()
+		}
+
+		x = &SelectStatement{Compound: &op}
+		if op.Y, err = p.parseCompoundSelect(opPrec + 1); err != nil {
+			return x, err
+		}
+	}
+}
+
+// setPrecedence returns the precedence of the set operator tok, or 0 if tok
+// is not a set operator.
+func setPrecedence(tok Token) int {
+	switch tok {
+	case INTERSECT:
+		return 2
+	case UNION, EXCEPT, SETMINUS:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// parseSelectOperand parses a SELECT, VALUES or parenthesised query. A
+// parenthesised query may have its own WITH, ORDER BY and LIMIT clauses.
+func (p *Parser) parseSelectOperand() (_ *SelectStatement, err error) {
+	if p.peek() != LP {
+		return p.parseSelectCore()
+	}
+
+	lparen, _, _ := p.scan()
+	stmt, err := p.parseSelectStatement(nil)
+	if err != nil {
+		return stmt, err
+	}
+	stmt.Lparen = lparen
+
+	if p.peek() != RP {
+		return stmt, p.errorExpected(p.pos, p.tok, "right paren")
+	}
+	stmt.Rparen, _, _ = p.scan()
+	return stmt, nil
+}
+
+// peekSetOperand returns true if the next tokens are a parenthesised query
+// followed by a set operator, ORDER BY or LIMIT, such as "(SELECT 1) UNION
+// (SELECT 2)". Such a query cannot be told apart from a parenthesised
+// source or expression until its closing paren.
+func (p *Parser) peekSetOperand() bool {
+	if p.peek() != LP || !p.queryAt(2) {
+		return false
+	}
+
+	tok := p.peekAfterParen()
+	return setPrecedence(tok) != 0 || tok == ORDER || tok == LIMIT
+}
+
+// peekAfterParen returns the token after the right paren matching the next
+// token, a left paren, or EOF if there is none.
+func (p *Parser) peekAfterParen() Token {
+	depth := 0
+	for i := 1; ; i++ {
+		switch p.peekAt(i) {
+		case LP:
+			depth++
+		case RP:
+			if depth--; depth == 0 {
+				return p.peekAt(i + 1)
+			}
+		case EOF:
+			return EOF
+		}
+	}
+}
+
+// queryAt returns true if the nth token ahead starts a query, possibly
+// parenthesised.
+func (p *Parser) queryAt(n int) bool {
+	switch p.peekAt(n) {
+	case SELECT, VALUES, WITH:
+		return true
+	case LP:
+		return p.queryAt(n + 1)
+	default:
+		return false
+	}
+}
+
+// parseSelectCore parses a single SELECT or VALUES, without set operations,
+// ORDER BY or LIMIT.
+func (p *Parser) parseSelectCore() (_ *SelectStatement, err error) {
+	var stmt SelectStatement
+
 	switch p.peek() {
 	case VALUES:
 		stmt.Values, _, _ = p.scan()
@@ -104,21 +356,9 @@ func (p *Parser) parseSelectStatement(compounded bool, withClause *WithClause) (
 
 			if p.peek() == ALL {
 				stmt.GroupByAll, _, _ = p.scan()
-			} else if p.peek() == GROUPING {
-				stmt.Grouping, _, _ = p.scan()
-				if p.peek() != SETS {
-					return &stmt, p.errorExpected(p.pos, p.tok, "SETS")
-				}
-				stmt.GroupingSet, _, _ = p.scan()
-
-				expr, err := p.ParseExpr()
-				if err != nil {
-					return &stmt, err
-				}
-				stmt.GroupingExpr = expr
 			} else {
 				for {
-					expr, err := p.ParseExpr()
+					expr, err := p.parseGroupingElement()
 					if err != nil {
 						return &stmt, err
 					}
@@ -129,6 +369,24 @@ func (p *Parser) parseSelectStatement(compounded bool, withClause *WithClause) (
 					}
 					p.scan()
 				}
+
+				// Parse the Hive forms "GROUPING SETS (...)" and "WITH
+				// ROLLUP|CUBE" after the grouping expressions.
+				if p.peek() == GROUPING {
+					if stmt.GroupingSets, err = p.parseGroupingSets(); err != nil {
+						return &stmt, err
+					}
+				} else if p.peek() == WITH {
+					stmt.GroupByWith, _, _ = p.scan()
+					switch {
+					case p.peekWord("ROLLUP"):
+						stmt.WithRollup, _, _ = p.scan()
+					case p.peekWord("CUBE"):
+						stmt.WithCube, _, _ = p.scan()
+					default:
+						return &stmt, p.errorExpected(p.pos, p.tok, "ROLLUP or CUBE")
+					}
+				}
 			}
 
 			// Parse optional HAVING clause.
@@ -179,69 +437,6 @@ func (p *Parser) parseSelectStatement(compounded bool, withClause *WithClause) (
 		return &stmt, p.errorExpected(p.pos, p.tok, "SELECT or VALUES")
 	}
 
-	// Optionally compound additional SELECT/VALUES.
-	switch tok := p.peek(); tok {
-	case UNION, INTERSECT:
-		if tok == UNION {
-			stmt.Union, _, _ = p.scan()
-			if p.peek() == ALL {
-				stmt.UnionAll, _, _ = p.scan()
-			}
-			if p.peek() == DISTINCT {
-				stmt.UnionDist, _, _ = p.scan()
-			}
-		} else if tok == INTERSECT {
-			stmt.Intersect, _, _ = p.scan()
-		}
-
-		if stmt.Compound, err = p.parseSelectStatement(true, nil); err != nil {
-			return &stmt, err
-		}
-	}
-
-	// Parse ORDER BY clause.
-	if !compounded && p.peek() == ORDER {
-		stmt.Order, _, _ = p.scan()
-		if p.peek() != BY {
-			return &stmt, p.errorExpected(p.pos, p.tok, "BY")
-		}
-		stmt.OrderBy, _, _ = p.scan()
-
-		for {
-			term, err := p.parseOrderingTerm()
-			if err != nil {
-				return &stmt, err
-			}
-			stmt.OrderingTerms = append(stmt.OrderingTerms, term)
-
-			if p.peek() != COMMA {
-				break
-			}
-			p.scan()
-		}
-	}
-
-	// Parse LIMIT/OFFSET clause.
-	// The offset is optional. Can be specified with COMMA or OFFSET.
-	// e.g. "LIMIT 1 OFFSET 2" or "LIMIT 1, 2"
-	if !compounded && p.peek() == LIMIT {
-		stmt.Limit, _, _ = p.scan()
-		if stmt.LimitExpr, err = p.ParseExpr(); err != nil {
-			return &stmt, err
-		}
-
-		if tok := p.peek(); tok == OFFSET || tok == COMMA {
-			if tok == OFFSET {
-				stmt.Offset, _, _ = p.scan()
-			} else {
-				stmt.OffsetComma, _, _ = p.scan()
-			}
-			if stmt.OffsetExpr, err = p.ParseExpr(); err != nil {
-				return &stmt, err
-			}
-		}
-	}
-
 	return &stmt, nil
 }
 
@@ -284,14 +479,6 @@ func (p *Parser) parseResultColumn() (_ *ResultColumn, err error) {
 		return &col, nil
 	}
 
-	if p.peek() == WITHIN {
-		within, err := p.parseWithinClause()
-		if err != nil {
-			return &col, err
-		}
-		col.Within = within
-	}
-
 	// If "AS" is next, the alias must follow.
 	// Otherwise it can optionally be an IDENT alias.
 	if p.peek() == AS {
@@ -309,7 +496,7 @@ func (p *Parser) parseResultColumn() (_ *ResultColumn, err error) {
 		} else {
 			return &col, p.errorExpected(p.pos, p.tok, "column alias")
 		}
-	} else if isIdentToken(p.peek()) {
+	} else if p.peekAlias() {
 		col.Alias, _ = p.parseIdent("column alias")
 	}
 
@@ -413,23 +600,13 @@ func (p *Parser) parseSource() (source Source, err error) {
 	for {
 		// Exit immediately if not part of a join operator.
 		switch p.peek() {
-		case COMMA, NATURAL, FULL, LEFT, INNER, CROSS, JOIN:
+		case COMMA, NATURAL, FULL, LEFT, RIGHT, INNER, CROSS, SEMIJOIN, ANTI, JOIN:
 		case LATERAL:
-			view, err := p.parseLateralView()
-			if err != nil {
+			// Lateral views apply to every source before them.
+			if source, err = p.parseLateralView(source); err != nil {
 				return source, err
 			}
-
-			j1, ok := source.(*JoinClause)
-			if ok {
-				x1, ok := j1.X.(*QualifiedTableName)
-				if ok {
-					x1.LateralViews = append(x1.LateralViews, view)
-				}
-			} else {
-				return source, p.errorExpected(p.pos, p.tok, "join clause Lateral View")
-			}
-			return source, nil
+			continue
 
 		default:
 			return source, nil
@@ -444,6 +621,18 @@ func (p *Parser) parseSource() (source Source, err error) {
 		if err != nil {
 			return source, err
 		}
+
+		// Lateral views between a joined source and its constraint, as Hive
+		// allows, apply to the join like those after it.
+		var views []*LateralView
+		for p.peek() == LATERAL {
+			view, err := p.parseLateralView(nil)
+			if err != nil {
+				return source, err
+			}
+			views = append(views, view)
+		}
+
 		constraint, err := p.parseJoinConstraint()
 		if err != nil {
 			return source, err
@@ -465,6 +654,10 @@ func (p *Parser) parseSource() (source Source, err error) {
 		} else {
 			source = &JoinClause{X: source, Operator: operator, Y: y, Constraint: constraint}
 		}
+
+		for _, view := range views {
+			view.X, source = source, view
+		}
 	}
 }
 
@@ -472,14 +665,287 @@ func (p *Parser) parseSource() (source Source, err error) {
 func (p *Parser) parseUnarySource() (source Source, err error) {
 	switch p.peek() {
 	case LP:
-		return p.parseParenSource()
+		source, err = p.parseParenSource()
 	case IDENT, QIDENT, TSTRING, BIND, TMPL:
-		return p.parseQualifiedTable(true)
+		source, err = p.parseQualifiedTable(true)
 	case VALUES:
-		return p.parseSelectStatement(false, nil)
+		return p.parseSelectStatement(nil)
 	default:
 		return nil, p.errorExpected(p.pos, p.tok, "table name or left paren")
 	}
+	if err != nil {
+		return source, err
+	}
+
+	// Parse PIVOT, UNPIVOT and TABLESAMPLE operators applied to the source.
+	// Lateral views are parsed by parseSource as they apply to joins too.
+	for {
+		switch p.peek() {
+		case PIVOT:
+			source, err = p.parsePivotSource(source)
+		case UNPIVOT:
+			source, err = p.parseUnpivotSource(source)
+		case TABLESAMPLE:
+			source, err = p.parseSampleSource(source)
+		default:
+			return source, nil
+		}
+		if err != nil {
+			return source, err
+		}
+	}
+}
+
+func (p *Parser) parsePivotSource(x Source) (_ *PivotSource, err error) {
+	assert(p.peek() == PIVOT)
+
+	src := PivotSource{X: x}
+	src.Pivot, _, _ = p.scan()
+
+	if p.peek() != LP {
+		return &src, p.errorExpected(p.pos, p.tok, "left paren")
+	}
+	src.Lparen, _, _ = p.scan()
+
+	for {
+		agg, err := p.parsePivotExpr(false)
+		if err != nil {
+			return &src, err
+		}
+		src.Aggregates = append(src.Aggregates, agg)
+
+		if p.peek() != COMMA {
+			break
+		}
+		p.scan()
+	}
+
+	if !p.peekWord("FOR") {
+		return &src, p.errorExpected(p.pos, p.tok, "FOR")
+	}
+	src.For, _, _ = p.scan()
+
+	if src.Column, err = p.parseOperand(); err != nil {
+		return &src, err
+	}
+
+	if src.In, src.InLparen, src.Values, src.InRparen, err = p.parsePivotIn(false); err != nil {
+		return &src, err
+	}
+
+	if p.peek() != RP {
+		return &src, p.errorExpected(p.pos, p.tok, "right paren")
+	}
+	src.Rparen, _, _ = p.scan()
+
+	if src.As, src.Alias, err = p.parseSourceAlias(); err != nil {
+		return &src, err
+	}
+	return &src, nil
+}
+
+func (p *Parser) parseUnpivotSource(x Source) (_ *UnpivotSource, err error) {
+	assert(p.peek() == UNPIVOT)
+
+	src := UnpivotSource{X: x}
+	src.Unpivot, _, _ = p.scan()
+
+	if p.peekWord("INCLUDE") || p.peekWord("EXCLUDE") {
+		pos, _, lit := p.scan()
+		if strings.EqualFold(lit, "INCLUDE") {
+			src.Include = pos
+		} else {
+			src.Exclude = pos
+		}
+		if p.peek() != NULLS {
+			return &src, p.errorExpected(p.pos, p.tok, "NULLS")
+		}
+		src.Nulls, _, _ = p.scan()
+	}
+
+	if p.peek() != LP {
+		return &src, p.errorExpected(p.pos, p.tok, "left paren")
+	}
+	src.Lparen, _, _ = p.scan()
+
+	if src.Value, err = p.parseOperand(); err != nil {
+		return &src, err
+	}
+
+	if !p.peekWord("FOR") {
+		return &src, p.errorExpected(p.pos, p.tok, "FOR")
+	}
+	src.For, _, _ = p.scan()
+
+	if src.Name, err = p.parseIdent("name column"); err != nil {
+		return &src, err
+	}
+
+	if src.In, src.InLparen, src.Columns, src.InRparen, err = p.parsePivotIn(true); err != nil {
+		return &src, err
+	}
+
+	if p.peek() != RP {
+		return &src, p.errorExpected(p.pos, p.tok, "right paren")
+	}
+	src.Rparen, _, _ = p.scan()
+
+	if src.As, src.Alias, err = p.parseSourceAlias(); err != nil {
+		return &src, err
+	}
+	return &src, nil
+}
+
+func (p *Parser) parseSampleSource(x Source) (_ *SampleSource, err error) {
+	assert(p.peek() == TABLESAMPLE)
+
+	src := SampleSource{X: x}
+	src.Tablesample, _, _ = p.scan()
+
+	if isIdentToken(p.peek()) {
+		if src.Method, err = p.parseIdent("sampling method"); err != nil {
+			return &src, err
+		}
+	}
+
+	if p.peek() != LP {
+		return &src, p.errorExpected(p.pos, p.tok, "left paren")
+	}
+	src.Lparen, _, _ = p.scan()
+
+	if p.peekWord("BUCKET") {
+		src.Bucket, _, _ = p.scan()
+		if src.BucketExpr, err = p.ParseExpr(); err != nil {
+			return &src, err
+		}
+		if !p.peekWord("OUT") {
+			return &src, p.errorExpected(p.pos, p.tok, "OUT")
+		}
+		src.Out, _, _ = p.scan()
+		if !p.peekWord("OF") {
+			return &src, p.errorExpected(p.pos, p.tok, "OF")
+		}
+		src.Of, _, _ = p.scan()
+		if src.Buckets, err = p.ParseExpr(); err != nil {
+			return &src, err
+		}
+		if p.peek() == ON {
+			src.On, _, _ = p.scan()
+			if src.OnExpr, err = p.ParseExpr(); err != nil {
+				return &src, err
+			}
+		}
+	} else {
+		if src.Size, err = p.ParseExpr(); err != nil {
+			return &src, err
+		}
+		if p.peekWord("PERCENT") {
+			src.Percent, _, _ = p.scan()
+		} else if p.peekWord("ROWS") {
+			src.Rows, _, _ = p.scan()
+		}
+	}
+
+	if p.peek() != RP {
+		return &src, p.errorExpected(p.pos, p.tok, "right paren")
+	}
+	src.Rparen, _, _ = p.scan()
+
+	if src.As, src.Alias, err = p.parseSourceAlias(); err != nil {
+		return &src, err
+	}
+	return &src, nil
+}
+
+// parsePivotIn parses the IN list of a PIVOT or UNPIVOT. The labels of an
+// UNPIVOT may be string literals.
+func (p *Parser) parsePivotIn(labels bool) (in, lparen Pos, exprs []*PivotExpr, rparen Pos, err error) {
+	if p.peek() != IN {
+		return in, lparen, exprs, rparen, p.errorExpected(p.pos, p.tok, "IN")
+	}
+	in, _, _ = p.scan()
+
+	if p.peek() != LP {
+		return in, lparen, exprs, rparen, p.errorExpected(p.pos, p.tok, "left paren")
+	}
+	lparen, _, _ = p.scan()
+
+	for {
+		expr, err := p.parsePivotExpr(labels)
+		if err != nil {
+			return in, lparen, exprs, rparen, err
+		}
+		exprs = append(exprs, expr)
+
+		if p.peek() == RP {
+			break
+		} else if p.peek() != COMMA {
+			return in, lparen, exprs, rparen, p.errorExpected(p.pos, p.tok, "comma or right paren")
+		}
+		p.scan()
+	}
+	rparen, _, _ = p.scan()
+
+	return in, lparen, exprs, rparen, nil
+}
+
+// parsePivotExpr parses an expression with an optional alias. FOR is not
+// taken as an alias as it ends the aggregates of a PIVOT. If labels is
+// true, a string literal after AS is also an alias, as in a AS 'x'.
+func (p *Parser) parsePivotExpr(labels bool) (_ *PivotExpr, err error) {
+	var expr PivotExpr
+	if expr.X, err = p.ParseExpr(); err != nil {
+		return &expr, err
+	}
+
+	if p.peek() == AS || (isIdentToken(p.peek()) && !p.peekWord("FOR")) {
+		if p.peek() == AS {
+			expr.As, _, _ = p.scan()
+		}
+		if labels && expr.As.IsValid() && p.peek() == STRING {
+			pos, tok, lit := p.scan()
+			expr.Alias = &Ident{NamePos: pos, Name: lit, Tok: tok}
+		} else if expr.Alias, err = p.parseIdent("alias"); err != nil {
+			return &expr, err
+		}
+	}
+	return &expr, nil
+}
+
+// parseSourceAlias parses the optional alias of a PIVOT, UNPIVOT or
+// TABLESAMPLE.
+func (p *Parser) parseSourceAlias() (as Pos, alias *Ident, err error) {
+	if p.peek() == AS || p.peekAlias() {
+		if p.peek() == AS {
+			as, _, _ = p.scan()
+		}
+		alias, err = p.parseIdent("table alias")
+	}
+	return as, alias, err
+}
+
+// peekAlias returns true if the next token is an alias given without AS.
+// Keywords that are also common names are aliases unless the token after
+// them shows they continue the query.
+func (p *Parser) peekAlias() bool {
+	switch tok := p.peek(); tok {
+	case SEMIJOIN, ANTI:
+		return p.peek2() != JOIN
+	case SETMINUS:
+		tok := p.peek2()
+		return tok != ALL && tok != DISTINCT && !p.queryAt(2)
+	case CLUSTER, DISTRIBUTE, SORT:
+		return p.peek2() != BY
+	case PIVOT:
+		return p.peek2() != LP
+	case UNPIVOT:
+		return p.peek2() != LP && !p.peekWordAt(2, "INCLUDE") && !p.peekWordAt(2, "EXCLUDE")
+	case TABLESAMPLE:
+		tok := p.peek2()
+		return tok != LP && !isIdentToken(tok)
+	default:
+		return isIdentToken(tok)
+	}
 }
 
 func (p *Parser) parseJoinOperator() (*JoinOperator, error) {
@@ -495,13 +961,28 @@ func (p *Parser) parseJoinOperator() (*JoinOperator, error) {
 		op.Natural, _, _ = p.scan()
 	}
 
-	// Parse "LEFT", "LEFT OUTER", "INNER", or "CROSS"
+	// Parse "LEFT", "LEFT OUTER", "LEFT SEMI", "LEFT ANTI", "RIGHT",
+	// "RIGHT OUTER", "INNER", "CROSS", "FULL", "FULL OUTER", "SEMI" or "ANTI".
 	switch p.peek() {
 	case LEFT:
 		op.Left, _, _ = p.scan()
+		switch p.peek() {
+		case OUTER:
+			op.Outer, _, _ = p.scan()
+		case SEMIJOIN:
+			op.Semi, _, _ = p.scan()
+		case ANTI:
+			op.Anti, _, _ = p.scan()
+		}
+	case RIGHT:
+		op.Right, _, _ = p.scan()
 		if p.peek() == OUTER {
 			op.Outer, _, _ = p.scan()
 		}
+	case SEMIJOIN:
+		op.Semi, _, _ = p.scan()
+	case ANTI:
+		op.Anti, _, _ = p.scan()
 	case INNER:
 		op.Inner, _, _ = p.scan()
 	case CROSS:
@@ -587,8 +1068,8 @@ func (p *Parser) parseParenSource() (_ *ParenSource, err error) {
 			return nil, err
 		}
 	}
-	if p.peek() == SELECT {
-		if source.X, err = p.parseSelectStatement(false, withClause); err != nil {
+	if p.peek() == SELECT || p.peekSetOperand() {
+		if source.X, err = p.parseSelectStatement(withClause); err != nil {
 			return &source, err
 		}
 	} else {
@@ -605,7 +1086,7 @@ func (p *Parser) parseParenSource() (_ *ParenSource, err error) {
 	}
 	source.Rparen, _, _ = p.scan()
 
-	if p.peek() == AS || isIdentToken(p.peek()) {
+	if p.peek() == AS || p.peekAlias() {
 		if p.peek() == AS {
 			source.As, _, _ = p.scan()
 		}
@@ -623,6 +1104,9 @@ func (p *Parser) parseQualifiedTable(aliasOK bool) (_ Source, err error) {
 	}
 	ident, _ := p.parseIdent("table name")
 	if p.peek() == LP {
+		if ident.Tok == IDENT && strings.EqualFold(ident.Name, "UNNEST") {
+			return p.parseUnnestSource(ident)
+		}
 		return p.parseQualifiedTableFunctionName(ident)
 	}
 	return p.parseQualifiedTableName(ident, aliasOK)
@@ -637,7 +1121,7 @@ func (p *Parser) parseQualifiedTableName(ident *Ident, aliasOK bool) (_ *Qualifi
 	tbl.Name = mIdent
 
 	// Parse optional table alias ("AS alias" or just "alias").
-	if tok := p.peek(); tok == AS || isIdentToken(tok) {
+	if p.peek() == AS || p.peekAlias() {
 		if !aliasOK {
 			return &tbl, p.errorExpected(p.pos, p.tok, "unqualified table name")
 		}
@@ -649,27 +1133,19 @@ func (p *Parser) parseQualifiedTableName(ident *Ident, aliasOK bool) (_ *Qualifi
 		}
 	}
 
-	for p.peek() == LATERAL {
-		view, err := p.parseLateralView()
-		if err != nil {
-			return &tbl, err
-		}
-		tbl.LateralViews = append(tbl.LateralViews, view)
-	}
-
 	return &tbl, nil
 }
 
-func (p *Parser) parseLateralView() (*LateralView, error) {
-	var lv LateralView
-	p1, _, _ := p.scan()
-	lv.Lateral = p1
+func (p *Parser) parseLateralView(x Source) (_ *LateralView, err error) {
+	assert(p.peek() == LATERAL)
 
-	p2, t2, _ := p.scan()
-	if t2 != VIEW {
-		return &lv, p.errorExpected(p.pos, p.tok, "lateral view")
+	lv := LateralView{X: x}
+	lv.Lateral, _, _ = p.scan()
+
+	if p.peek() != VIEW {
+		return &lv, p.errorExpected(p.pos, p.tok, "VIEW")
 	}
-	lv.View = p2
+	lv.View, _, _ = p.scan()
 
 	if p.peek() == OUTER {
 		lv.Outer, _, _ = p.scan()
@@ -679,43 +1155,75 @@ func (p *Parser) parseLateralView() (*LateralView, error) {
 	if err != nil {
 		return &lv, err
 	}
-
-	c1, ok := expr.(*Call)
+	udtf, ok := expr.(*Call)
 	if !ok {
-		return &lv, p.errorExpected(p.pos, p.tok, "lateral view udf call")
+		return &lv, p.errorExpected(NodePos(expr), p.tok, "table function call")
 	}
-	lv.Udtf = c1
+	lv.Udtf = udtf
 
 	if !isExprIdentToken(p.peek()) {
-		return &lv, p.errorExpected(p.pos, p.tok, "lateral view TableAlias")
+		return &lv, p.errorExpected(p.pos, p.tok, "table alias")
 	}
-	p2, t2, lit2 := p.scan()
-	lv.TableAlias = &Ident{Name: lit2, NamePos: p2, Tok: t2}
+	pos, tok, lit := p.scan()
+	lv.TableAlias = &Ident{Name: lit, NamePos: pos, Tok: tok}
 
 	if p.peek() != AS {
-		return &lv, p.errorExpected(p.pos, p.tok, "lateral view AS")
+		return &lv, p.errorExpected(p.pos, p.tok, "AS")
 	}
 	lv.As, _, _ = p.scan()
 
 	for {
-		var idents []*Ident
 		if !isExprIdentToken(p.peek()) {
-			return &lv, p.errorExpected(p.pos, p.tok, "lateral view TableAlias")
+			return &lv, p.errorExpected(p.pos, p.tok, "column alias")
 		}
-		p3, t3, lit3 := p.scan()
-		idents = append(idents, &Ident{Name: lit3, NamePos: p3, Tok: t3})
+		pos, tok, lit := p.scan()
+		lv.ColAlias = append(lv.ColAlias, &Ident{Name: lit, NamePos: pos, Tok: tok})
 
-		if p.peek() == COMMA {
-			p.scan()
-		} else {
-			lv.ColAlias = idents
+		if p.peek() != COMMA {
 			break
 		}
+		p.scan()
 	}
 
 	return &lv, nil
 }
 
+func (p *Parser) parseUnnestSource(ident *Ident) (_ *UnnestSource, err error) {
+	assert(p.peek() == LP)
+
+	src := UnnestSource{Unnest: ident.NamePos}
+	src.Lparen, _, _ = p.scan()
+	if src.X, err = p.ParseExpr(); err != nil {
+		return &src, err
+	}
+	if p.peek() != RP {
+		return &src, p.errorExpected(p.pos, p.tok, "right paren")
+	}
+	src.Rparen, _, _ = p.scan()
+
+	if src.As, src.Alias, err = p.parseSourceAlias(); err != nil {
+		return &src, err
+	}
+
+	if p.peek() == WITH {
+		src.With, _, _ = p.scan()
+		if p.peek() != OFFSET {
+			return &src, p.errorExpected(p.pos, p.tok, "OFFSET")
+		}
+		src.Offset, _, _ = p.scan()
+
+		if p.peek() == AS || p.peekAlias() {
+			if p.peek() == AS {
+				src.OffsetAs, _, _ = p.scan()
+			}
+			if src.OffsetAlias, err = p.parseIdent("offset alias"); err != nil {
+				return &src, err
+			}
+		}
+	}
+	return &src, nil
+}
+
 func (p *Parser) parseQualifiedTableFunctionName(ident *Ident) (_ *QualifiedTableFunctionName, err error) {
 	assert(p.peek() == LP)
 
@@ -740,7 +1248,7 @@ func (p *Parser) parseQualifiedTableFunctionName(ident *Ident) (_ *QualifiedTabl
 	tbl.Rparen, _, _ = p.scan()
 
 	// Parse optional table alias ("AS alias" or just "alias").
-	if tok := p.peek(); tok == AS || isIdentToken(tok) {
+	if p.peek() == AS || p.peekAlias() {
 		if p.peek() == AS {
 			tbl.As, _, _ = p.scan()
 		}
@@ -815,7 +1323,7 @@ func (p *Parser) parseCTE() (_ *CTE, err error) {
 	}
 	cte.SelectLparen, _, _ = p.scan()
 
-	if cte.Select, err = p.parseSelectStatement(false, nil); err != nil {
+	if cte.Select, err = p.parseSelectStatement(nil); err != nil {
 		return &cte, err
 	}
 
@@ -856,7 +1364,7 @@ func (p *Parser) parseWindowDefinition() (_ *WindowDefinition, err error) {
 	def.Lparen, _, _ = p.scan()
 
 	// Read base window name.
-	if isIdentToken(p.peek()) {
+	if isIdentToken(p.peek()) && !p.peekFrameUnit() {
 		pos, tok, lit := p.scan()
 		def.Base = &Ident{Name: lit, NamePos: pos, Tok: tok}
 	}
@@ -905,6 +1413,13 @@ func (p *Parser) parseWindowDefinition() (_ *WindowDefinition, err error) {
 		}
 	}
 
+	// Parse "ROWS|RANGE|GROUPS frame-extent [EXCLUDE ...]"
+	if p.peekFrameUnit() {
+		if def.Frame, err = p.parseFrameSpec(); err != nil {
+			return &def, err
+		}
+	}
+
 	// Parse final rparen.
 	if p.peek() != RP {
 		return &def, p.errorExpected(p.pos, p.tok, "right paren")
@@ -914,6 +1429,212 @@ func (p *Parser) parseWindowDefinition() (_ *WindowDefinition, err error) {
 	return &def, nil
 }
 
+// peekFrameUnit returns true if the next token starts a frame clause.
+func (p *Parser) peekFrameUnit() bool {
+	return p.peekWord("ROWS") || p.peekWord("RANGE") || p.peekWord("GROUPS")
+}
+
+func (p *Parser) parseFrameSpec() (_ *FrameSpec, err error) {
+	var frame FrameSpec
+
+	switch {
+	case p.peekWord("ROWS"):
+		frame.Rows, _, _ = p.scan()
+	case p.peekWord("RANGE"):
+		frame.Range, _, _ = p.scan()
+	default:
+		frame.Groups, _, _ = p.scan()
+	}
+
+	if p.peek() == BETWEEN {
+		frame.Between, _, _ = p.scan()
+		if frame.Start, err = p.parseFrameBound(); err != nil {
+			return &frame, err
+		}
+		if p.peek() != AND {
+			return &frame, p.errorExpected(p.pos, p.tok, "AND")
+		}
+		frame.And, _, _ = p.scan()
+		if frame.End, err = p.parseFrameBound(); err != nil {
+			return &frame, err
+		}
+	} else if frame.Start, err = p.parseFrameBound(); err != nil {
+		return &frame, err
+	}
+
+	// Parse "EXCLUDE CURRENT ROW|GROUP|TIES|NO OTHERS"
+	if p.peekWord("EXCLUDE") {
+		frame.Exclude, _, _ = p.scan()
+		switch {
+		case p.peekWord("CURRENT"):
+			frame.ExcludeCurrentRow, _, _ = p.scan()
+			if !p.peekWord("ROW") {
+				return &frame, p.errorExpected(p.pos, p.tok, "ROW")
+			}
+			p.scan()
+		case p.peek() == GROUP:
+			frame.ExcludeGroup, _, _ = p.scan()
+		case p.peekWord("TIES"):
+			frame.ExcludeTies, _, _ = p.scan()
+		case p.peekWord("NO"):
+			frame.ExcludeNoOthers, _, _ = p.scan()
+			if !p.peekWord("OTHERS") {
+				return &frame, p.errorExpected(p.pos, p.tok, "OTHERS")
+			}
+			p.scan()
+		default:
+			return &frame, p.errorExpected(p.pos, p.tok, "CURRENT ROW, GROUP, TIES, or NO OTHERS")
+		}
+	}
+
+	return &frame, nil
+}
+
+func (p *Parser) parseFrameBound() (_ *FrameBound, err error) {
+	var bound FrameBound
+
+	switch {
+	case p.peekWord("CURRENT"):
+		bound.CurrentRow, _, _ = p.scan()
+		if !p.peekWord("ROW") {
+			return &bound, p.errorExpected(p.pos, p.tok, "ROW")
+		}
+		p.scan()
+		return &bound, nil
+	case p.peekWord("UNBOUNDED"):
+		bound.Unbounded, _, _ = p.scan()
+	default:
+		if bound.X, err = p.ParseExpr(); err != nil {
+			return &bound, err
+		}
+	}
+
+	switch {
+	case p.peekWord("PRECEDING"):
+		bound.Preceding, _, _ = p.scan()
+	case p.peekWord("FOLLOWING"):
+		bound.Following, _, _ = p.scan()
+	default:
+		return &bound, p.errorExpected(p.pos, p.tok, "PRECEDING or FOLLOWING")
+	}
+
+	return &bound, nil
+}
+
+// parseGroupingElement parses an element of GROUP BY: an expression,
+// "ROLLUP (...)", "CUBE (...)" or "GROUPING SETS (...)".
+func (p *Parser) parseGroupingElement() (Expr, error) {
+	if p.peek() == GROUPING {
+		return p.parseGroupingSets()
+	}
+
+	expr, err := p.ParseExpr()
+	if err != nil {
+		return expr, err
+	}
+
+	// ROLLUP and CUBE are not keywords, so they parse as calls.
+	if call, ok := expr.(*Call); ok && isGroupingCall(call) {
+		exprs := make([]Expr, len(call.Args))
+		for i, arg := range call.Args {
+			exprs[i] = arg.X
+		}
+		if strings.EqualFold(call.Name.Name.Name, "ROLLUP") {
+			return &Rollup{Rollup: call.Name.Name.NamePos, Lparen: call.Lparen, Exprs: exprs, Rparen: call.Rparen}, nil
+		}
+		return &Cube{Cube: call.Name.Name.NamePos, Lparen: call.Lparen, Exprs: exprs, Rparen: call.Rparen}, nil
+	}
+	return expr, nil
+}
+
+// isGroupingCall returns true if call is a plain call to ROLLUP or CUBE.
+func isGroupingCall(call *Call) bool {
+	name := call.Name
+	if name.First != nil || name.Name == nil || name.Name.Tok != IDENT {
+		return false
+	}
+	if !strings.EqualFold(name.Name.Name, "ROLLUP") && !strings.EqualFold(name.Name.Name, "CUBE") {
+		return false
+	}
+	if call.Star.IsValid() || call.Distinct.IsValid() || call.Over != nil || call.Within != nil || call.FilterExpr != nil {
+		return false
+	}
+	for _, arg := range call.Args {
+		if arg.As.IsValid() {
+			return false
+		}
+	}
+	return len(call.Args) != 0
+}
+
+func (p *Parser) parseGroupingSets() (_ *GroupingSets, err error) {
+	assert(p.peek() == GROUPING)
+
+	var sets GroupingSets
+	sets.Grouping, _, _ = p.scan()
+	if p.peek() != SETS {
+		return &sets, p.errorExpected(p.pos, p.tok, "SETS")
+	}
+	sets.Sets, _, _ = p.scan()
+
+	if p.peek() != LP {
+		return &sets, p.errorExpected(p.pos, p.tok, "left paren")
+	}
+	sets.Lparen, _, _ = p.scan()
+
+	for {
+		set, err := p.parseGroupingSet()
+		if err != nil {
+			return &sets, err
+		}
+		sets.Exprs = append(sets.Exprs, set)
+
+		if p.peek() != COMMA {
+			break
+		}
+		p.scan()
+	}
+
+	if p.peek() != RP {
+		return &sets, p.errorExpected(p.pos, p.tok, "comma or right paren")
+	}
+	sets.Rparen, _, _ = p.scan()
+	return &sets, nil
+}
+
+// parseGroupingSet parses a set of GROUPING SETS: a parenthesised list of
+// expressions, which may be empty, or a single grouping element. A left
+// paren starts a set only if the set ends at its matching right paren;
+// otherwise it starts an expression, as in "(a + b) * 2".
+func (p *Parser) parseGroupingSet() (Expr, error) {
+	if p.peek() != LP {
+		return p.parseGroupingElement()
+	} else if tok := p.peekAfterParen(); tok != COMMA && tok != RP && tok != EOF {
+		return p.parseGroupingElement()
+	}
+
+	var list ExprList
+	list.Lparen, _, _ = p.scan()
+	for p.peek() != RP {
+		expr, err := p.ParseExpr()
+		if err != nil {
+			return &list, err
+		}
+		list.Exprs = append(list.Exprs, expr)
+
+		if p.peek() != COMMA {
+			break
+		}
+		p.scan()
+	}
+
+	if p.peek() != RP {
+		return &list, p.errorExpected(p.pos, p.tok, "comma or right paren")
+	}
+	list.Rparen, _, _ = p.scan()
+	return &list, nil
+}
+
 // parseWithStatement is called only from parseNonExplainStatement as we don't
 // know what kind of statement we'll have after the CTEs (e.g. SELECT, INSERT, etc).
 func (p *Parser) parseWithStatement() (Statement, error) {
@@ -923,8 +1644,8 @@ func (p *Parser) parseWithStatement() (Statement, error) {
 	}
 
 	switch p.peek() {
-	case SELECT, VALUES:
-		return p.parseSelectStatement(false, withClause)
+	case SELECT, VALUES, LP:
+		return p.parseSelectStatement(withClause)
 	case INSERT, REPLACE:
 		return p.parseInsertStatement(withClause)
 	case DELETE:
@@ -147,7 +147,7 @@ func TestParser_ParseStatement(t *testing.T) {
 						Lparen: pos(23),
 						Rparen: pos(43),
 						Args: []*query.Params{
-							{X: &query.StringLit{ValuePos: pos(24), Value: "'"}},
+							{X: &query.StringLit{ValuePos: pos(24), Value: "'", Quote: '\''}},
 							{X: &query.Call{
 								Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(30), Name: "NVL", Tok: query.IDENT}},
 								Lparen: pos(33),
@@ -222,7 +222,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				{Expr: &query.MultiPartIdent{
 					Name: &query.Ident{NamePos: pos(7), Name: "name", Tok: query.IDENT},
 				}},
-				{Expr: &query.StringLit{ValuePos: pos(13), Value: "m"},
+				{Expr: &query.StringLit{ValuePos: pos(13), Value: "m", Quote: '\''},
 					As:    pos(17),
 					Alias: &query.Ident{NamePos: pos(20), Name: "period_type", Tok: query.IDENT}},
 				{Expr: &query.Call{
@@ -479,6 +479,54 @@ func TestParser_ParseStatement(t *testing.T) {
 				},
 			},
 		})
+		AssertParseStatement(t, `SELECT * FROM foo RIGHT OUTER JOIN bar`, &query.SelectStatement{
+			Select: pos(0),
+			Columns: []*query.ResultColumn{
+				{Star: pos(7)},
+			},
+			From: pos(9),
+			Source: &query.JoinClause{
+				X: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "foo", Tok: query.IDENT}},
+				},
+				Operator: &query.JoinOperator{Right: pos(18), Outer: pos(24), Join: pos(30)},
+				Y: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(35), Name: "bar", Tok: query.IDENT}},
+				},
+			},
+		})
+		AssertParseStatement(t, `SELECT * FROM foo LEFT SEMI JOIN bar`, &query.SelectStatement{
+			Select: pos(0),
+			Columns: []*query.ResultColumn{
+				{Star: pos(7)},
+			},
+			From: pos(9),
+			Source: &query.JoinClause{
+				X: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "foo", Tok: query.IDENT}},
+				},
+				Operator: &query.JoinOperator{Left: pos(18), Semi: pos(23), Join: pos(28)},
+				Y: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(33), Name: "bar", Tok: query.IDENT}},
+				},
+			},
+		})
+		AssertParseStatement(t, `SELECT * FROM foo ANTI JOIN bar`, &query.SelectStatement{
+			Select: pos(0),
+			Columns: []*query.ResultColumn{
+				{Star: pos(7)},
+			},
+			From: pos(9),
+			Source: &query.JoinClause{
+				X: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "foo", Tok: query.IDENT}},
+				},
+				Operator: &query.JoinOperator{Anti: pos(18), Join: pos(23)},
+				Y: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(28), Name: "bar", Tok: query.IDENT}},
+				},
+			},
+		})
 
 		AssertParseStatement(t, `WITH cte (foo, bar) AS (SELECT baz), xxx AS (SELECT yyy) SELECT bat`, &query.SelectStatement{
 			WithClause: &query.WithClause{
@@ -591,7 +639,7 @@ func TestParser_ParseStatement(t *testing.T) {
 							Args: []*query.Params{
 								{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(52), Name: "@end_date", Tok: query.BIND}}},
 								{X: &query.UnaryExpr{OpPos: pos(63), Op: query.MINUS, X: &query.NumberLit{ValuePos: pos(64), Value: "13"}}},
-								{X: &query.StringLit{ValuePos: pos(68), Value: "dd"}},
+								{X: &query.StringLit{ValuePos: pos(68), Value: "dd", Quote: '\''}},
 							},
 						},
 						And: pos(74),
@@ -619,7 +667,7 @@ func TestParser_ParseStatement(t *testing.T) {
 								Op:    query.MINUS,
 								X:     &query.NumberLit{ValuePos: pos(125), Value: "1"},
 							}},
-							{X: &query.StringLit{ValuePos: pos(128), Value: "yyyy"}},
+							{X: &query.StringLit{ValuePos: pos(128), Value: "yyyy", Quote: '\''}},
 						},
 					},
 				},
@@ -645,7 +693,7 @@ func TestParser_ParseStatement(t *testing.T) {
 							Rparen: pos(64),
 							Args: []*query.Params{
 								{
-									X: &query.StringLit{Value: "2025-06-01", ValuePos: pos(52)},
+									X: &query.StringLit{Value: "2025-06-01", ValuePos: pos(52), Quote: '\''},
 								},
 							},
 						},
@@ -674,7 +722,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				X:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(25), Name: "name", Tok: query.IDENT}},
 				Op:    query.RLIKE,
 				OpPos: pos(30),
-				Y:     &query.StringLit{Value: "done", ValuePos: pos(36)},
+				Y:     &query.StringLit{Value: "done", ValuePos: pos(36), Quote: '\''},
 			},
 		})
 		AssertParseStatement(t, `SELECT * FROM dt WHERE true AND effective_timestamp <= CAST(dstart AS TIMESTAMP)`, &query.SelectStatement{
@@ -726,49 +774,47 @@ func TestParser_ParseStatement(t *testing.T) {
 			Select:  pos(0),
 			Columns: []*query.ResultColumn{{Star: pos(7)}},
 			From:    pos(9),
-			Source: &query.QualifiedTableName{
-				Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "abc", Tok: query.IDENT}},
-				LateralViews: []*query.LateralView{
-					{
-						Lateral: pos(18),
-						View:    pos(26),
-						Udtf: &query.Call{
-							Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(31), Name: "EXPLODE", Tok: query.IDENT}},
-							Lparen: pos(38),
-							Rparen: pos(46),
-							Args: []*query.Params{
-								{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(39), Name: "filters", Tok: query.IDENT}}},
-							},
-						},
-						TableAlias: &query.Ident{NamePos: pos(48), Name: "_T2", Tok: query.IDENT},
-						As:         pos(52),
-						ColAlias: []*query.Ident{
-							{NamePos: pos(55), Name: "f", Tok: query.IDENT},
-						},
+			Source: &query.LateralView{
+				X: &query.LateralView{
+					X: &query.QualifiedTableName{
+						Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "abc", Tok: query.IDENT}},
 					},
-					{
-						Lateral: pos(57),
-						View:    pos(65),
-						Udtf: &query.Call{
-							Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(70), Name: "EXPLODE", Tok: query.IDENT}},
-							Lparen: pos(77),
-							Rparen: pos(91),
-							Args: []*query.Params{
-								{X: &query.MultiPartIdent{
-									First:  &query.Ident{NamePos: pos(78), Name: "_T2", Tok: query.IDENT},
-									Dot1:   pos(81),
-									Second: &query.Ident{NamePos: pos(82), Name: "f", Tok: query.IDENT},
-									Dot2:   pos(83),
-									Name:   &query.Ident{NamePos: pos(84), Name: "actions", Tok: query.IDENT},
-								}},
-							},
-						},
-						TableAlias: &query.Ident{NamePos: pos(93), Name: "_T3", Tok: query.IDENT},
-						As:         pos(97),
-						ColAlias: []*query.Ident{
-							{NamePos: pos(100), Name: "ap", Tok: query.IDENT},
+					Lateral: pos(18),
+					View:    pos(26),
+					Udtf: &query.Call{
+						Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(31), Name: "EXPLODE", Tok: query.IDENT}},
+						Lparen: pos(38),
+						Rparen: pos(46),
+						Args: []*query.Params{
+							{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(39), Name: "filters", Tok: query.IDENT}}},
 						},
 					},
+					TableAlias: &query.Ident{NamePos: pos(48), Name: "_T2", Tok: query.IDENT},
+					As:         pos(52),
+					ColAlias: []*query.Ident{
+						{NamePos: pos(55), Name: "f", Tok: query.IDENT},
+					},
+				},
+				Lateral: pos(57),
+				View:    pos(65),
+				Udtf: &query.Call{
+					Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(70), Name: "EXPLODE", Tok: query.IDENT}},
+					Lparen: pos(77),
+					Rparen: pos(91),
+					Args: []*query.Params{
+						{X: &query.MultiPartIdent{
+							First:  &query.Ident{NamePos: pos(78), Name: "_T2", Tok: query.IDENT},
+							Dot1:   pos(81),
+							Second: &query.Ident{NamePos: pos(82), Name: "f", Tok: query.IDENT},
+							Dot2:   pos(83),
+							Name:   &query.Ident{NamePos: pos(84), Name: "actions", Tok: query.IDENT},
+						}},
+					},
+				},
+				TableAlias: &query.Ident{NamePos: pos(93), Name: "_T3", Tok: query.IDENT},
+				As:         pos(97),
+				ColAlias: []*query.Ident{
+					{NamePos: pos(100), Name: "ap", Tok: query.IDENT},
 				},
 			},
 		})
@@ -822,74 +868,73 @@ func TestParser_ParseStatement(t *testing.T) {
 				{Expr: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(7), Name: "a", Tok: query.IDENT}}},
 			},
 			From: pos(10),
-			Source: &query.JoinClause{
-				X: &query.QualifiedTableName{
-					Name:  &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(15), Name: "tbl1", Tok: query.IDENT}},
-					Alias: &query.Ident{NamePos: pos(20), Name: "c1", Tok: query.IDENT},
-					LateralViews: []*query.LateralView{
-						{
-							Lateral: pos(75),
-							View:    pos(83),
-							Udtf: &query.Call{
-								Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(88), Name: "JSON_EXPLODE", Tok: query.IDENT}},
-								Lparen: pos(100),
-								Args: []*query.Params{
-									{X: &query.Call{
-										Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(101), Name: "JSON_PARSE", Tok: query.IDENT}},
-										Lparen: pos(111),
-										Rparen: pos(119),
-										Args: []*query.Params{
-											{X: &query.MultiPartIdent{
-												First: &query.Ident{NamePos: pos(112), Name: "c1", Tok: query.IDENT},
-												Dot1:  pos(114),
-												Name:  &query.Ident{NamePos: pos(115), Name: "json", Tok: query.IDENT}},
-											},
-										},
-									}},
-								},
-								Rparen: pos(120),
+			Source: &query.LateralView{
+				X: &query.JoinClause{
+					X: &query.QualifiedTableName{
+						Name:  &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(15), Name: "tbl1", Tok: query.IDENT}},
+						Alias: &query.Ident{NamePos: pos(20), Name: "c1", Tok: query.IDENT},
+					},
+					Operator: &query.JoinOperator{
+						Left:  pos(23),
+						Outer: pos(28),
+						Join:  pos(34),
+					},
+					Y: &query.QualifiedTableName{
+						Name:  &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(39), Name: "tbl2", Tok: query.IDENT}},
+						Alias: &query.Ident{NamePos: pos(44), Name: "c2", Tok: query.IDENT},
+					},
+					Constraint: &query.OnConstraint{
+						On: pos(47),
+						X: &query.BinaryExpr{
+							X: &query.BinaryExpr{
+								X: &query.MultiPartIdent{
+									First: &query.Ident{NamePos: pos(50), Name: "c1", Tok: query.IDENT},
+									Dot1:  pos(52),
+									Name:  &query.Ident{NamePos: pos(53), Name: "id", Tok: query.IDENT}},
+								OpPos: pos(56),
+								Op:    query.EQ,
+								Y: &query.MultiPartIdent{
+									First: &query.Ident{NamePos: pos(58), Name: "c2", Tok: query.IDENT},
+									Dot1:  pos(60),
+									Name:  &query.Ident{NamePos: pos(61), Name: "id", Tok: query.IDENT}},
 							},
-							TableAlias: &query.Ident{NamePos: pos(122), Name: "_T1", Tok: query.IDENT},
-							As:         pos(126),
-							ColAlias: []*query.Ident{
-								{NamePos: pos(135), Name: "raw_metadata", Tok: query.IDENT},
+							Op:    query.AND,
+							OpPos: pos(64),
+							Y: &query.BinaryExpr{
+								X:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(68), Name: "b", Tok: query.IDENT}},
+								Op:    query.LT,
+								OpPos: pos(70),
+								Y:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(72), Name: "b1", Tok: query.IDENT}},
 							},
 						},
 					},
 				},
-				Operator: &query.JoinOperator{
-					Left:  pos(23),
-					Outer: pos(28),
-					Join:  pos(34),
-				},
-				Y: &query.QualifiedTableName{
-					Name:  &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(39), Name: "tbl2", Tok: query.IDENT}},
-					Alias: &query.Ident{NamePos: pos(44), Name: "c2", Tok: query.IDENT},
-				},
-				Constraint: &query.OnConstraint{
-					On: pos(47),
-					X: &query.BinaryExpr{
-						X: &query.BinaryExpr{
-							X: &query.MultiPartIdent{
-								First: &query.Ident{NamePos: pos(50), Name: "c1", Tok: query.IDENT},
-								Dot1:  pos(52),
-								Name:  &query.Ident{NamePos: pos(53), Name: "id", Tok: query.IDENT}},
-							OpPos: pos(56),
-							Op:    query.EQ,
-							Y: &query.MultiPartIdent{
-								First: &query.Ident{NamePos: pos(58), Name: "c2", Tok: query.IDENT},
-								Dot1:  pos(60),
-								Name:  &query.Ident{NamePos: pos(61), Name: "id", Tok: query.IDENT}},
-						},
-						Op:    query.AND,
-						OpPos: pos(64),
-						Y: &query.BinaryExpr{
-							X:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(68), Name: "b", Tok: query.IDENT}},
-							Op:    query.LT,
-							OpPos: pos(70),
-							Y:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(72), Name: "b1", Tok: query.IDENT}},
-						},
+				Lateral: pos(75),
+				View:    pos(83),
+				Udtf: &query.Call{
+					Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(88), Name: "JSON_EXPLODE", Tok: query.IDENT}},
+					Lparen: pos(100),
+					Args: []*query.Params{
+						{X: &query.Call{
+							Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(101), Name: "JSON_PARSE", Tok: query.IDENT}},
+							Lparen: pos(111),
+							Rparen: pos(119),
+							Args: []*query.Params{
+								{X: &query.MultiPartIdent{
+									First: &query.Ident{NamePos: pos(112), Name: "c1", Tok: query.IDENT},
+									Dot1:  pos(114),
+									Name:  &query.Ident{NamePos: pos(115), Name: "json", Tok: query.IDENT}},
+								},
+							},
+						}},
 					},
+					Rparen: pos(120),
+				},
+				TableAlias: &query.Ident{NamePos: pos(122), Name: "_T1", Tok: query.IDENT},
+				As:         pos(126),
+				ColAlias: []*query.Ident{
+					{NamePos: pos(129), Name: "elem", Tok: query.IDENT},
+					{NamePos: pos(135), Name: "raw_metadata", Tok: query.IDENT},
 				},
 			},
 		})
@@ -911,17 +956,17 @@ func TestParser_ParseStatement(t *testing.T) {
 			GroupByAll: pos(18),
 		})
 		AssertParseStatement(t, `Select * FROM cols GROUP BY GROUPING SETS ((a, b, a.c), (a, b, d))`, &query.SelectStatement{
-			Select:      pos(0),
-			Columns:     []*query.ResultColumn{{Star: pos(7)}},
-			From:        pos(9),
-			Source:      &query.QualifiedTableName{Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "cols", Tok: query.IDENT}}},
-			Group:       pos(19),
-			GroupBy:     pos(25),
-			Grouping:    pos(28),
-			GroupingSet: pos(37),
-			GroupingExpr: &query.ExprList{
-				Lparen: pos(42),
-				Rparen: pos(65),
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			From:    pos(9),
+			Source:  &query.QualifiedTableName{Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "cols", Tok: query.IDENT}}},
+			Group:   pos(19),
+			GroupBy: pos(25),
+			GroupByExprs: []query.Expr{&query.GroupingSets{
+				Grouping: pos(28),
+				Sets:     pos(37),
+				Lparen:   pos(42),
+				Rparen:   pos(65),
 				Exprs: []query.Expr{
 					&query.ExprList{
 						Lparen: pos(43),
@@ -946,8 +991,77 @@ func TestParser_ParseStatement(t *testing.T) {
 						},
 					},
 				},
+			}},
+		})
+		AssertParseStatement(t, `SELECT a GROUP BY a, rollup(b, (c, d)), CUBE (e), GROUPING SETS (f, (), ROLLUP(g))`, &query.SelectStatement{
+			Select: pos(0),
+			Columns: []*query.ResultColumn{
+				{Expr: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(7), Name: "a", Tok: query.IDENT}}},
 			},
+			Group:   pos(9),
+			GroupBy: pos(15),
+			GroupByExprs: []query.Expr{
+				&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(18), Name: "a", Tok: query.IDENT}},
+				&query.Rollup{
+					Rollup: pos(21),
+					Lparen: pos(27),
+					Exprs: []query.Expr{
+						&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(28), Name: "b", Tok: query.IDENT}},
+						&query.ExprList{
+							Lparen: pos(31),
+							Exprs: []query.Expr{
+								&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(32), Name: "c", Tok: query.IDENT}},
+								&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(35), Name: "d", Tok: query.IDENT}},
+							},
+							Rparen: pos(36),
+						},
+					},
+					Rparen: pos(37),
+				},
+				&query.Cube{
+					Cube:   pos(40),
+					Lparen: pos(45),
+					Exprs: []query.Expr{
+						&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(46), Name: "e", Tok: query.IDENT}},
+					},
+					Rparen: pos(47),
+				},
+				&query.GroupingSets{
+					Grouping: pos(50),
+					Sets:     pos(59),
+					Lparen:   pos(64),
+					Exprs: []query.Expr{
+						&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(65), Name: "f", Tok: query.IDENT}},
+						&query.ExprList{Lparen: pos(68), Rparen: pos(69)},
+						&query.Rollup{
+							Rollup: pos(72),
+							Lparen: pos(78),
+							Exprs: []query.Expr{
+								&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(79), Name: "g", Tok: query.IDENT}},
+							},
+							Rparen: pos(80),
+						},
+					},
+					Rparen: pos(81),
+				},
+			},
+		})
+		AssertParseStatement(t, `SELECT * GROUP BY a WITH ROLLUP`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			Group:   pos(9),
+			GroupBy: pos(15),
+			GroupByExprs: []query.Expr{
+				&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(18), Name: "a", Tok: query.IDENT}},
+			},
+			GroupByWith: pos(20),
+			WithRollup:  pos(25),
 		})
+		AssertParseStatementString(t, `SELECT a, b GROUP BY a, b GROUPING SETS ((a, b), a) HAVING a > 1`,
+			`SELECT a, b GROUP BY a, b GROUPING SETS ((a, b), a) HAVING a > 1`)
+		AssertParseStatementString(t, `SELECT a GROUP BY a, b with cube`, `SELECT a GROUP BY a, b WITH CUBE`)
+		AssertParseStatementString(t, `SELECT a GROUP BY GROUPING SETS ((a + b) * 2, ((a)), (a) || b, (a))`,
+			`SELECT a GROUP BY GROUPING SETS ((a + b) * 2, ((a)), (a) || b, (a))`)
 		AssertParseStatement(t, `SELECT * GROUP BY foo HAVING true`, &query.SelectStatement{
 			Select:  pos(0),
 			Columns: []*query.ResultColumn{{Star: pos(7)}},
@@ -983,6 +1097,54 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		})
 
+		AssertParseStatement(t, `SELECT * WINDOW w AS (rows BETWEEN 1 PRECEDING AND CURRENT ROW EXCLUDE TIES)`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			Window:  pos(9),
+			Windows: []*query.Window{
+				{
+					Name: &query.Ident{NamePos: pos(16), Name: "w", Tok: query.IDENT},
+					As:   pos(18),
+					Definition: &query.WindowDefinition{
+						Lparen: pos(21),
+						Frame: &query.FrameSpec{
+							Rows:    pos(22),
+							Between: pos(27),
+							Start: &query.FrameBound{
+								X:         &query.NumberLit{ValuePos: pos(35), Value: "1"},
+								Preceding: pos(37),
+							},
+							And:         pos(47),
+							End:         &query.FrameBound{CurrentRow: pos(51)},
+							Exclude:     pos(63),
+							ExcludeTies: pos(71),
+						},
+						Rparen: pos(75),
+					},
+				},
+			},
+		})
+		AssertParseStatement(t, `SELECT * WINDOW w AS (base RANGE UNBOUNDED FOLLOWING)`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			Window:  pos(9),
+			Windows: []*query.Window{
+				{
+					Name: &query.Ident{NamePos: pos(16), Name: "w", Tok: query.IDENT},
+					As:   pos(18),
+					Definition: &query.WindowDefinition{
+						Lparen: pos(21),
+						Base:   &query.Ident{NamePos: pos(22), Name: "base", Tok: query.IDENT},
+						Frame: &query.FrameSpec{
+							Range: pos(27),
+							Start: &query.FrameBound{Unbounded: pos(33), Following: pos(43)},
+						},
+						Rparen: pos(52),
+					},
+				},
+			},
+		})
+
 		AssertParseStatement(t, `SELECT * ORDER BY foo ASC, bar DESC`, &query.SelectStatement{
 			Select: pos(0),
 			Columns: []*query.ResultColumn{
@@ -996,6 +1158,45 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		})
 
+		AssertParseStatement(t, `SELECT a FROM t DISTRIBUTE BY a, b SORT BY c DESC LIMIT 5`, &query.SelectStatement{
+			Select: pos(0),
+			Columns: []*query.ResultColumn{
+				{Expr: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(7), Name: "a", Tok: query.IDENT}}},
+			},
+			From: pos(9),
+			Source: &query.QualifiedTableName{
+				Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "t", Tok: query.IDENT}},
+			},
+			Distribute:   pos(16),
+			DistributeBy: pos(27),
+			DistributeExprs: []query.Expr{
+				&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(30), Name: "a", Tok: query.IDENT}},
+				&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(33), Name: "b", Tok: query.IDENT}},
+			},
+			Sort:   pos(35),
+			SortBy: pos(40),
+			SortTerms: []*query.OrderingTerm{
+				{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(43), Name: "c", Tok: query.IDENT}}, Desc: pos(45)},
+			},
+			Limit:     pos(50),
+			LimitExpr: &query.NumberLit{ValuePos: pos(56), Value: "5"},
+		})
+		AssertParseStatement(t, `SELECT sort FROM t CLUSTER BY sort`, &query.SelectStatement{
+			Select: pos(0),
+			Columns: []*query.ResultColumn{
+				{Expr: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(7), Name: "sort", Tok: query.SORT}}},
+			},
+			From: pos(12),
+			Source: &query.QualifiedTableName{
+				Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(17), Name: "t", Tok: query.IDENT}},
+			},
+			Cluster:   pos(19),
+			ClusterBy: pos(27),
+			ClusterExprs: []query.Expr{
+				&query.MultiPartIdent{Name: &query.Ident{NamePos: pos(30), Name: "sort", Tok: query.SORT}},
+			},
+		})
+
 		AssertParseStatement(t, `SELECT * LIMIT 1`, &query.SelectStatement{
 			Select: pos(0),
 			Columns: []*query.ResultColumn{
@@ -1039,7 +1240,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				X:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(56), Name: "sale_date", Tok: query.IDENT}},
 				OpPos: pos(66),
 				Op:    query.EQ,
-				Y:     &query.StringLit{ValuePos: pos(68), Value: "{{ .DSTART | Date }}"},
+				Y:     &query.StringLit{ValuePos: pos(68), Value: "{{ .DSTART | Date }}", Quote: '\''},
 			},
 		})
 		AssertParseStatement(t, `SELECT a, b, c, FROM price`, &query.SelectStatement{
@@ -1081,7 +1282,7 @@ func TestParser_ParseStatement(t *testing.T) {
 									Name:   &query.MultiPartIdent{Name: &query.Ident{Name: "date", NamePos: pos(25), Tok: query.DATE}},
 									Lparen: pos(29),
 									Rparen: pos(42),
-									Args:   []*query.Params{{X: &query.StringLit{ValuePos: pos(30), Value: "2023-03-01"}}},
+									Args:   []*query.Params{{X: &query.StringLit{ValuePos: pos(30), Value: "2023-03-01", Quote: '\''}}},
 								},
 									Alias: &query.Ident{NamePos: pos(44), Name: "ds", Tok: query.IDENT},
 								},
@@ -1205,15 +1406,19 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		})
 		AssertParseStatement(t, `SELECT * UNION SELECT * ORDER BY foo`, &query.SelectStatement{
-			Select: pos(0),
-			Columns: []*query.ResultColumn{
-				{Star: pos(7)},
-			},
-			Union: pos(9),
-			Compound: &query.SelectStatement{
-				Select: pos(15),
-				Columns: []*query.ResultColumn{
-					{Star: pos(22)},
+			Compound: &query.SetOperation{
+				X: &query.SelectStatement{
+					Select: pos(0),
+					Columns: []*query.ResultColumn{
+						{Star: pos(7)},
+					},
+				},
+				Union: pos(9),
+				Y: &query.SelectStatement{
+					Select: pos(15),
+					Columns: []*query.ResultColumn{
+						{Star: pos(22)},
+					},
 				},
 			},
 			Order:   pos(24),
@@ -1223,54 +1428,105 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		})
 		AssertParseStatement(t, `SELECT * UNION ALL SELECT *`, &query.SelectStatement{
-			Select: pos(0),
-			Columns: []*query.ResultColumn{
-				{Star: pos(7)},
-			},
-			Union:    pos(9),
-			UnionAll: pos(15),
-			Compound: &query.SelectStatement{
-				Select: pos(19),
-				Columns: []*query.ResultColumn{
-					{Star: pos(26)},
+			Compound: &query.SetOperation{
+				X: &query.SelectStatement{
+					Select: pos(0),
+					Columns: []*query.ResultColumn{
+						{Star: pos(7)},
+					},
+				},
+				Union: pos(9),
+				All:   pos(15),
+				Y: &query.SelectStatement{
+					Select: pos(19),
+					Columns: []*query.ResultColumn{
+						{Star: pos(26)},
+					},
 				},
 			},
 		})
 		AssertParseStatement(t, `SELECT a FROM abc UNION DISTINCT SELECT DISTINCT b FROM bcd`, &query.SelectStatement{
-			Select: pos(0),
-			From:   pos(9),
-			Columns: []*query.ResultColumn{
-				{Expr: &query.MultiPartIdent{Name: &query.Ident{Name: "a", NamePos: pos(7), Tok: query.IDENT}}},
-			},
-			Source: &query.QualifiedTableName{
-				Name: &query.MultiPartIdent{
-					Name: &query.Ident{NamePos: pos(14), Name: "abc", Tok: query.IDENT},
+			Compound: &query.SetOperation{
+				X: &query.SelectStatement{
+					Select: pos(0),
+					From:   pos(9),
+					Columns: []*query.ResultColumn{
+						{Expr: &query.MultiPartIdent{Name: &query.Ident{Name: "a", NamePos: pos(7), Tok: query.IDENT}}},
+					},
+					Source: &query.QualifiedTableName{
+						Name: &query.MultiPartIdent{
+							Name: &query.Ident{NamePos: pos(14), Name: "abc", Tok: query.IDENT},
+						},
+					},
+				},
+				Union:    pos(18),
+				Distinct: pos(24),
+				Y: &query.SelectStatement{
+					Select:   pos(33),
+					Distinct: pos(40),
+					Columns: []*query.ResultColumn{
+						{Expr: &query.MultiPartIdent{Name: &query.Ident{Name: "b", NamePos: pos(49), Tok: query.IDENT}}},
+					},
+					From: pos(51),
+					Source: &query.QualifiedTableName{
+						Name: &query.MultiPartIdent{Name: &query.Ident{Name: "bcd", NamePos: pos(56), Tok: query.IDENT}},
+					},
 				},
 			},
-			Union:     pos(18),
-			UnionDist: pos(24),
-			Compound: &query.SelectStatement{
-				Select:   pos(33),
-				Distinct: pos(40),
-				Columns: []*query.ResultColumn{
-					{Expr: &query.MultiPartIdent{Name: &query.Ident{Name: "b", NamePos: pos(49), Tok: query.IDENT}}},
+		})
+		AssertParseStatement(t, `SELECT * INTERSECT SELECT *`, &query.SelectStatement{
+			Compound: &query.SetOperation{
+				X: &query.SelectStatement{
+					Select: pos(0),
+					Columns: []*query.ResultColumn{
+						{Star: pos(7)},
+					},
 				},
-				From: pos(51),
-				Source: &query.QualifiedTableName{
-					Name: &query.MultiPartIdent{Name: &query.Ident{Name: "bcd", NamePos: pos(56), Tok: query.IDENT}},
+				Intersect: pos(9),
+				Y: &query.SelectStatement{
+					Select: pos(19),
+					Columns: []*query.ResultColumn{
+						{Star: pos(26)},
+					},
 				},
 			},
 		})
-		AssertParseStatement(t, `SELECT * INTERSECT SELECT *`, &query.SelectStatement{
-			Select: pos(0),
-			Columns: []*query.ResultColumn{
-				{Star: pos(7)},
+		AssertParseStatement(t, `SELECT 1 EXCEPT ALL (SELECT 2 LIMIT 1)`, &query.SelectStatement{
+			Compound: &query.SetOperation{
+				X: &query.SelectStatement{
+					Select: pos(0),
+					Columns: []*query.ResultColumn{
+						{Expr: &query.NumberLit{ValuePos: pos(7), Value: "1"}},
+					},
+				},
+				Except: pos(9),
+				All:    pos(16),
+				Y: &query.SelectStatement{
+					Lparen: pos(20),
+					Select: pos(21),
+					Columns: []*query.ResultColumn{
+						{Expr: &query.NumberLit{ValuePos: pos(28), Value: "2"}},
+					},
+					Limit:     pos(30),
+					LimitExpr: &query.NumberLit{ValuePos: pos(36), Value: "1"},
+					Rparen:    pos(37),
+				},
 			},
-			Intersect: pos(9),
-			Compound: &query.SelectStatement{
-				Select: pos(19),
-				Columns: []*query.ResultColumn{
-					{Star: pos(26)},
+		})
+		AssertParseStatement(t, `SELECT 1 MINUS SELECT 2`, &query.SelectStatement{
+			Compound: &query.SetOperation{
+				X: &query.SelectStatement{
+					Select: pos(0),
+					Columns: []*query.ResultColumn{
+						{Expr: &query.NumberLit{ValuePos: pos(7), Value: "1"}},
+					},
+				},
+				Minus: pos(9),
+				Y: &query.SelectStatement{
+					Select: pos(15),
+					Columns: []*query.ResultColumn{
+						{Expr: &query.NumberLit{ValuePos: pos(22), Value: "2"}},
+					},
 				},
 			},
 		})
@@ -1377,24 +1633,24 @@ func TestParser_ParseStatement(t *testing.T) {
 							Star:   pos(24),
 						}},
 					},
+					Within: &query.Within{
+						Within:       pos(28),
+						Group:        pos(35),
+						GroupLparen:  pos(41),
+						GroupRparen:  pos(66),
+						GroupOrder:   pos(42),
+						GroupOrderBy: pos(48),
+						OrderingTerm: &query.OrderingTerm{
+							X:    &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(51), Name: "a1", Tok: query.IDENT}},
+							Desc: pos(54),
+						},
+						GroupLimit:     pos(59),
+						GroupLimitExpr: &query.NumberLit{ValuePos: pos(65), Value: "1"},
+						Index:          &query.NumberLit{ValuePos: pos(68), Value: "0"},
+					},
 				},
 				As:    pos(71),
 				Alias: &query.Ident{Name: "col1", NamePos: pos(74), Tok: query.IDENT},
-				Within: &query.Within{
-					Within:       pos(28),
-					Group:        pos(35),
-					GroupLparen:  pos(41),
-					GroupRparen:  pos(66),
-					GroupOrder:   pos(42),
-					GroupOrderBy: pos(48),
-					OrderingTerm: &query.OrderingTerm{
-						X:    &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(51), Name: "a1", Tok: query.IDENT}},
-						Desc: pos(54),
-					},
-					GroupLimit:     pos(59),
-					GroupLimitExpr: &query.NumberLit{ValuePos: pos(65), Value: "1"},
-					Index:          &query.NumberLit{ValuePos: pos(68), Value: "0"},
-				},
 			}},
 			Source: &query.QualifiedTableName{
 				Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(84), Name: "tbl1", Tok: query.IDENT}},
@@ -1416,20 +1672,20 @@ func TestParser_ParseStatement(t *testing.T) {
 							{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(31), Name: "a2", Tok: query.IDENT}}},
 							{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(35), Name: ",", Tok: query.QIDENT}}},
 						},
-					},
-					Alias: &query.Ident{Name: "col1", NamePos: pos(71), Tok: query.IDENT},
-					Within: &query.Within{
-						Within:       pos(40),
-						Group:        pos(47),
-						GroupLparen:  pos(53),
-						GroupRparen:  pos(69),
-						GroupOrder:   pos(54),
-						GroupOrderBy: pos(60),
-						OrderingTerm: &query.OrderingTerm{
-							X:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(63), Name: "a2", Tok: query.IDENT}},
-							Asc: pos(66),
+						Within: &query.Within{
+							Within:       pos(40),
+							Group:        pos(47),
+							GroupLparen:  pos(53),
+							GroupRparen:  pos(69),
+							GroupOrder:   pos(54),
+							GroupOrderBy: pos(60),
+							OrderingTerm: &query.OrderingTerm{
+								X:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(63), Name: "a2", Tok: query.IDENT}},
+								Asc: pos(66),
+							},
 						},
 					},
+					Alias: &query.Ident{Name: "col1", NamePos: pos(71), Tok: query.IDENT},
 				}},
 			From: pos(76),
 			Source: &query.QualifiedTableName{
@@ -1566,7 +1822,7 @@ func TestParser_ParseStatement(t *testing.T) {
 							Op:    query.EQ,
 							Y:     &query.NumberLit{ValuePos: pos(29), Value: "1"},
 						}},
-						{X: &query.StringLit{ValuePos: pos(31), Value: "All"}},
+						{X: &query.StringLit{ValuePos: pos(31), Value: "All", Quote: '\''}},
 						{X: &query.MultiPartIdent{
 							First: &query.Ident{NamePos: pos(37), Name: "b", Tok: query.IDENT},
 							Dot1:  pos(38),
@@ -1653,6 +1909,255 @@ func TestParser_ParseStatement(t *testing.T) {
 				Rparen: pos(33),
 			},
 		})
+		AssertParseStatement(t, `SELECT * FROM t PIVOT (sum(x) FOR c IN ('a', 'b' AS b2)) AS p`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			From:    pos(9),
+			Source: &query.PivotSource{
+				X: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "t", Tok: query.IDENT}},
+				},
+				Pivot:  pos(16),
+				Lparen: pos(22),
+				Aggregates: []*query.PivotExpr{
+					{X: &query.Call{
+						Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(23), Name: "sum", Tok: query.IDENT}},
+						Lparen: pos(26),
+						Args: []*query.Params{
+							{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(27), Name: "x", Tok: query.IDENT}}},
+						},
+						Rparen: pos(28),
+					}},
+				},
+				For:      pos(30),
+				Column:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(34), Name: "c", Tok: query.IDENT}},
+				In:       pos(36),
+				InLparen: pos(39),
+				Values: []*query.PivotExpr{
+					{X: &query.StringLit{ValuePos: pos(40), Value: "a", Quote: '\''}},
+					{
+						X:     &query.StringLit{ValuePos: pos(45), Value: "b", Quote: '\''},
+						As:    pos(49),
+						Alias: &query.Ident{NamePos: pos(52), Name: "b2", Tok: query.IDENT},
+					},
+				},
+				InRparen: pos(54),
+				Rparen:   pos(55),
+				As:       pos(57),
+				Alias:    &query.Ident{NamePos: pos(60), Name: "p", Tok: query.IDENT},
+			},
+		})
+
+		AssertParseStatement(t, `SELECT * FROM (SELECT a, b FROM t) s UNPIVOT EXCLUDE NULLS (v FOR n IN (a, b AS bb))`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			From:    pos(9),
+			Source: &query.UnpivotSource{
+				X: &query.ParenSource{
+					Lparen: pos(14),
+					X: &query.SelectStatement{
+						Select: pos(15),
+						Columns: []*query.ResultColumn{
+							{Expr: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(22), Name: "a", Tok: query.IDENT}}},
+							{Expr: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(25), Name: "b", Tok: query.IDENT}}},
+						},
+						From: pos(27),
+						Source: &query.QualifiedTableName{
+							Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(32), Name: "t", Tok: query.IDENT}},
+						},
+					},
+					Rparen: pos(33),
+					Alias:  &query.Ident{NamePos: pos(35), Name: "s", Tok: query.IDENT},
+				},
+				Unpivot:  pos(37),
+				Exclude:  pos(45),
+				Nulls:    pos(53),
+				Lparen:   pos(59),
+				Value:    &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(60), Name: "v", Tok: query.IDENT}},
+				For:      pos(62),
+				Name:     &query.Ident{NamePos: pos(66), Name: "n", Tok: query.IDENT},
+				In:       pos(68),
+				InLparen: pos(71),
+				Columns: []*query.PivotExpr{
+					{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(72), Name: "a", Tok: query.IDENT}}},
+					{
+						X:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(75), Name: "b", Tok: query.IDENT}},
+						As:    pos(77),
+						Alias: &query.Ident{NamePos: pos(80), Name: "bb", Tok: query.IDENT},
+					},
+				},
+				InRparen: pos(82),
+				Rparen:   pos(83),
+			},
+		})
+
+		AssertParseStatement(t, `SELECT * FROM t UNPIVOT (v FOR k IN (a AS 'x', b AS 'y'))`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			From:    pos(9),
+			Source: &query.UnpivotSource{
+				X: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "t", Tok: query.IDENT}},
+				},
+				Unpivot:  pos(16),
+				Lparen:   pos(24),
+				Value:    &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(25), Name: "v", Tok: query.IDENT}},
+				For:      pos(27),
+				Name:     &query.Ident{NamePos: pos(31), Name: "k", Tok: query.IDENT},
+				In:       pos(33),
+				InLparen: pos(36),
+				Columns: []*query.PivotExpr{
+					{
+						X:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(37), Name: "a", Tok: query.IDENT}},
+						As:    pos(39),
+						Alias: &query.Ident{NamePos: pos(42), Name: "x", Tok: query.STRING},
+					},
+					{
+						X:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(47), Name: "b", Tok: query.IDENT}},
+						As:    pos(49),
+						Alias: &query.Ident{NamePos: pos(52), Name: "y", Tok: query.STRING},
+					},
+				},
+				InRparen: pos(55),
+				Rparen:   pos(56),
+			},
+		})
+
+		AssertParseStatement(t, `SELECT * FROM t, UNNEST(t.arr) AS x WITH OFFSET off`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			From:    pos(9),
+			Source: &query.JoinClause{
+				X: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "t", Tok: query.IDENT}},
+				},
+				Operator: &query.JoinOperator{Comma: pos(15)},
+				Y: &query.UnnestSource{
+					Unnest: pos(17),
+					Lparen: pos(23),
+					X: &query.MultiPartIdent{
+						First: &query.Ident{NamePos: pos(24), Name: "t", Tok: query.IDENT},
+						Dot1:  pos(25),
+						Name:  &query.Ident{NamePos: pos(26), Name: "arr", Tok: query.IDENT},
+					},
+					Rparen:      pos(29),
+					As:          pos(31),
+					Alias:       &query.Ident{NamePos: pos(34), Name: "x", Tok: query.IDENT},
+					With:        pos(36),
+					Offset:      pos(41),
+					OffsetAlias: &query.Ident{NamePos: pos(48), Name: "off", Tok: query.IDENT},
+				},
+			},
+		})
+
+		AssertParseStatement(t, `SELECT * FROM (SELECT a FROM t) s LATERAL VIEW explode(a) x AS y`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			From:    pos(9),
+			Source: &query.LateralView{
+				X: &query.ParenSource{
+					Lparen: pos(14),
+					X: &query.SelectStatement{
+						Select: pos(15),
+						Columns: []*query.ResultColumn{
+							{Expr: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(22), Name: "a", Tok: query.IDENT}}},
+						},
+						From: pos(24),
+						Source: &query.QualifiedTableName{
+							Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(29), Name: "t", Tok: query.IDENT}},
+						},
+					},
+					Rparen: pos(30),
+					Alias:  &query.Ident{NamePos: pos(32), Name: "s", Tok: query.IDENT},
+				},
+				Lateral: pos(34),
+				View:    pos(42),
+				Udtf: &query.Call{
+					Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(47), Name: "explode", Tok: query.IDENT}},
+					Lparen: pos(54),
+					Args: []*query.Params{
+						{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(55), Name: "a", Tok: query.IDENT}}},
+					},
+					Rparen: pos(56),
+				},
+				TableAlias: &query.Ident{NamePos: pos(58), Name: "x", Tok: query.IDENT},
+				As:         pos(60),
+				ColAlias:   []*query.Ident{{NamePos: pos(63), Name: "y", Tok: query.IDENT}},
+			},
+		})
+
+		// Lateral views apply to every source before them, wherever they are
+		// in a join.
+		AssertParseStatement(t, `SELECT * FROM t, u LATERAL VIEW explode(a) x AS y`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			From:    pos(9),
+			Source: &query.LateralView{
+				X: &query.JoinClause{
+					X:        &query.QualifiedTableName{Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "t", Tok: query.IDENT}}},
+					Operator: &query.JoinOperator{Comma: pos(15)},
+					Y:        &query.QualifiedTableName{Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(17), Name: "u", Tok: query.IDENT}}},
+				},
+				Lateral: pos(19),
+				View:    pos(27),
+				Udtf: &query.Call{
+					Name:   &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(32), Name: "explode", Tok: query.IDENT}},
+					Lparen: pos(39),
+					Args: []*query.Params{
+						{X: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(40), Name: "a", Tok: query.IDENT}}},
+					},
+					Rparen: pos(41),
+				},
+				TableAlias: &query.Ident{NamePos: pos(43), Name: "x", Tok: query.IDENT},
+				As:         pos(45),
+				ColAlias:   []*query.Ident{{NamePos: pos(48), Name: "y", Tok: query.IDENT}},
+			},
+		})
+		AssertParseStatementString(t, `SELECT * FROM a JOIN b LATERAL VIEW explode(b.arr) x AS c LATERAL VIEW explode(c) y AS d ON a.id = b.id JOIN e`,
+			`SELECT * FROM a JOIN b ON a.id = b.id LATERAL VIEW explode(b.arr) x AS c LATERAL VIEW explode(c) y AS d JOIN e`)
+
+		AssertParseStatement(t, `SELECT * FROM t AS a TABLESAMPLE SYSTEM (10 PERCENT)`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			From:    pos(9),
+			Source: &query.SampleSource{
+				X: &query.QualifiedTableName{
+					Name:  &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "t", Tok: query.IDENT}},
+					As:    pos(16),
+					Alias: &query.Ident{NamePos: pos(19), Name: "a", Tok: query.IDENT},
+				},
+				Tablesample: pos(21),
+				Method:      &query.Ident{NamePos: pos(33), Name: "SYSTEM", Tok: query.IDENT},
+				Lparen:      pos(40),
+				Size:        &query.NumberLit{ValuePos: pos(41), Value: "10"},
+				Percent:     pos(44),
+				Rparen:      pos(51),
+			},
+		})
+
+		AssertParseStatement(t, `SELECT * FROM t TABLESAMPLE (BUCKET 1 OUT OF 4 ON id) AS s`, &query.SelectStatement{
+			Select:  pos(0),
+			Columns: []*query.ResultColumn{{Star: pos(7)}},
+			From:    pos(9),
+			Source: &query.SampleSource{
+				X: &query.QualifiedTableName{
+					Name: &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(14), Name: "t", Tok: query.IDENT}},
+				},
+				Tablesample: pos(16),
+				Lparen:      pos(28),
+				Bucket:      pos(29),
+				BucketExpr:  &query.NumberLit{ValuePos: pos(36), Value: "1"},
+				Out:         pos(38),
+				Of:          pos(42),
+				Buckets:     &query.NumberLit{ValuePos: pos(45), Value: "4"},
+				On:          pos(47),
+				OnExpr:      &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(50), Name: "id", Tok: query.IDENT}},
+				Rparen:      pos(52),
+				As:          pos(54),
+				Alias:       &query.Ident{NamePos: pos(57), Name: "s", Tok: query.IDENT},
+			},
+		})
+
 		//AssertParseStatement(t, `SELECT * FROM (WITH shop AS (SELECT * FROM business))`, &query.SelectStatement{
 		//	Select: pos(0),
 		//	Columns: []*query.ResultColumn{
@@ -1712,19 +2217,56 @@ func TestParser_ParseStatement(t *testing.T) {
 		AssertParseStatementError(t, `SELECT * FROM (tbl`, `1:18: expected right paren, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * FROM (SELECT *) AS`, `1:27: expected table alias, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * FROM foo AS`, `1:20: expected table alias, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo AS f PIVOT`, `1:28: expected left paren, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo PIVOT (sum(x) y z`, `1:35: expected FOR, found z`)
+		AssertParseStatementError(t, `SELECT * FROM foo PIVOT (sum(x) FOR c AND`, `1:39: expected IN, found 'AND'`)
+		AssertParseStatementError(t, `SELECT * FROM foo PIVOT (sum(x) FOR c IN ('a' 'b'`, `1:47: expected comma or right paren, found b`)
+		AssertParseStatementError(t, `SELECT * FROM foo PIVOT (sum(x) FOR c IN ('a')`, `1:46: expected right paren, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo UNPIVOT INCLUDE (`, `1:35: expected NULLS, found '('`)
+		AssertParseStatementError(t, `SELECT * FROM foo UNPIVOT (v FOR`, `1:32: expected name column, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo UNPIVOT (v FOR k IN (a 'x'))`, `1:42: expected comma or right paren, found x`)
+		AssertParseStatementError(t, `SELECT * FROM foo PIVOT (sum(x) FOR c IN ('a' AS 'b'))`, `1:50: expected alias, found b`)
+		AssertParseStatementError(t, `SELECT * FROM UNNEST(a`, `1:22: expected right paren, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM UNNEST(a) x WITH`, `1:30: expected OFFSET, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo LATERAL explode(a)`, `1:27: expected VIEW, found explode`)
+		AssertParseStatementError(t, `SELECT * FROM foo LATERAL VIEW a.b x AS y`, `1:32: expected table function call`)
+		AssertParseStatementError(t, `SELECT * FROM foo LATERAL VIEW explode(a) AS y`, `1:43: expected table alias, found 'AS'`)
+		AssertParseStatementError(t, `SELECT * FROM foo LATERAL VIEW explode(a) x y`, `1:45: expected AS, found y`)
+		AssertParseStatementError(t, `SELECT * FROM foo LATERAL VIEW explode(a) x AS y,`, `1:49: expected column alias, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo AS f TABLESAMPLE 10`, `1:36: expected left paren, found 10`)
+		AssertParseStatementError(t, `SELECT * FROM foo TABLESAMPLE (BUCKET 1 OF 4)`, `1:41: expected OUT, found OF`)
+		AssertParseStatementError(t, `SELECT * FROM foo TABLESAMPLE (BUCKET 1 OUT 4)`, `1:45: expected OF, found 4`)
+		AssertParseStatementError(t, `SELECT * FROM foo TABLESAMPLE (10 PERCENT`, `1:41: expected right paren, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT foo WHERE`, `1:16: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * GROUP`, `1:14: expected BY, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * GROUP BY`, `1:17: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * GROUP BY foo bar`, `1:23: expected semicolon or EOF, found bar`)
 		AssertParseStatementError(t, `SELECT * GROUP BY foo HAVING`, `1:28: expected expression, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * GROUP BY GROUPING`, `1:26: expected SETS, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * GROUP BY GROUPING SETS a`, `1:33: expected left paren, found a`)
+		AssertParseStatementError(t, `SELECT * GROUP BY GROUPING SETS ((a) b`, `1:38: expected comma or right paren, found b`)
+		AssertParseStatementError(t, `SELECT * GROUP BY GROUPING SETS ((a, b`, `1:38: expected comma or right paren, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * GROUP BY a WITH`, `1:24: expected ROLLUP or CUBE, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * GROUP BY a GROUPING SETS a`, `1:35: expected left paren, found a`)
 		AssertParseStatementError(t, `SELECT * WINDOW`, `1:15: expected window name, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * WINDOW win1`, `1:20: expected AS, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * WINDOW win1 AS`, `1:23: expected left paren, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * WINDOW win1 AS (`, `1:25: expected right paren, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * WINDOW win1 AS () win2`, `1:28: expected semicolon or EOF, found win2`)
+		AssertParseStatementError(t, `SELECT * WINDOW w AS (ROWS`, `1:26: expected expression, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * WINDOW w AS (ROWS 1)`, `1:29: expected PRECEDING or FOLLOWING, found ')'`)
+		AssertParseStatementError(t, `SELECT * WINDOW w AS (ROWS CURRENT)`, `1:35: expected ROW, found ')'`)
+		AssertParseStatementError(t, `SELECT * WINDOW w AS (ROWS BETWEEN CURRENT ROW)`, `1:47: expected AND, found ')'`)
+		AssertParseStatementError(t, `SELECT * WINDOW w AS (ROWS CURRENT ROW EXCLUDE)`, `1:47: expected CURRENT ROW, GROUP, TIES, or NO OTHERS, found ')'`)
+		AssertParseStatementError(t, `SELECT * WINDOW w AS (ROWS CURRENT ROW EXCLUDE NO)`, `1:50: expected OTHERS, found ')'`)
 		AssertParseStatementError(t, `SELECT * ORDER`, `1:14: expected BY, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * ORDER BY`, `1:17: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * ORDER BY 1,`, `1:20: expected expression, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * CLUSTER`, `1:16: expected BY, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * CLUSTER BY a SORT BY a`, `1:23: expected semicolon or EOF, found 'SORT'`)
+		AssertParseStatementError(t, `SELECT * DISTRIBUTE BY`, `1:22: expected expression, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * SORT`, `1:13: expected BY, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * SORT BY a DISTRIBUTE BY a`, `1:20: expected semicolon or EOF, found 'DISTRIBUTE'`)
 		AssertParseStatementError(t, `SELECT * LIMIT`, `1:14: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * LIMIT 1,`, `1:17: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * LIMIT 1 OFFSET`, `1:23: expected expression, found 'EOF'`)
@@ -1733,9 +2275,137 @@ func TestParser_ParseStatement(t *testing.T) {
 		AssertParseStatementError(t, `VALUES (1`, `1:9: expected comma or right paren, found 'EOF'`)
 		AssertParseStatementError(t, `VALUES (1,`, `1:10: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * UNION`, `1:14: expected SELECT or VALUES, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * MINUS ALL`, `1:18: expected SELECT or VALUES, found 'EOF'`)
+		AssertParseStatementError(t, `(SELECT 1`, `1:9: expected right paren, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT 1 UNION (SELECT 2 ORDER BY 1`, `1:35: expected right paren, found 'EOF'`)
+	})
+
+	// Keywords that are also common names can still be used as identifiers
+	// and aliases where they do not start a clause.
+	t.Run("KeywordIdentifiers", func(t *testing.T) {
+		AssertParseStatementString(t, `SELECT semi, anti FROM t`, `SELECT semi, anti FROM t`)
+		AssertParseStatementString(t, `SELECT * FROM t semi`, `SELECT * FROM t AS semi`)
+		AssertParseStatementString(t, `SELECT * FROM t AS anti JOIN u ON anti.id = u.id`, `SELECT * FROM t AS anti JOIN u ON anti.id = u.id`)
+		AssertParseStatementString(t, `SELECT a anti FROM t semi JOIN u`, `SELECT a AS anti FROM t SEMI JOIN u`)
+		AssertParseStatementString(t, `SELECT a.minus, minus FROM t minus`, `SELECT a.minus, minus FROM t AS minus`)
+		AssertParseStatementString(t, `SELECT a FROM t minus (SELECT a FROM u)`, `SELECT a FROM t MINUS (SELECT a FROM u)`)
+		AssertParseStatementString(t, `SELECT f(ignore), coalesce(a, respect), f(separator), g(separator, b) FROM t`,
+			`SELECT f(ignore), coalesce(a, respect), f(separator), g(separator, b) FROM t`)
+		AssertParseStatementString(t, `SELECT count(*) filter, max(x) ignore, min(x) respect FROM t`,
+			`SELECT count(*) AS filter, max(x) AS ignore, min(x) AS respect FROM t`)
+		AssertParseStatementString(t, `SELECT a.sort, a.cluster, a.distribute, a.pivot FROM t AS a`,
+			`SELECT a.sort, a.cluster, a.distribute, a.pivot FROM t AS a`)
+		AssertParseStatementString(t, `SELECT db.sort.x FROM db.sort`, `SELECT db.sort.x FROM db.sort`)
+		AssertParseStatementString(t, `SELECT * FROM t sort`, `SELECT * FROM t AS sort`)
+		AssertParseStatementString(t, `SELECT a cluster FROM t distribute`, `SELECT a AS cluster FROM t AS distribute`)
+		AssertParseStatementString(t, `SELECT * FROM t sort SORT BY sort.a`, `SELECT * FROM t AS sort SORT BY sort.a`)
+		AssertParseStatementString(t, `SELECT * FROM t CLUSTER BY a`, `SELECT * FROM t CLUSTER BY a`)
+		AssertParseStatementString(t, `SELECT pivot FROM t pivot`, `SELECT pivot FROM t AS pivot`)
+		AssertParseStatementString(t, `SELECT a unpivot FROM (SELECT a FROM t) unpivot, u pivot`,
+			`SELECT a AS unpivot FROM (SELECT a FROM t) AS unpivot, u AS pivot`)
+		AssertParseStatementString(t, `SELECT * FROM t pivot PIVOT (sum(x) FOR c IN ('a'))`,
+			`SELECT * FROM t AS pivot PIVOT (sum(x) FOR c IN ('a'))`)
+		AssertParseStatementString(t, `SELECT * FROM t UNPIVOT EXCLUDE NULLS (v FOR c IN (a, b))`,
+			`SELECT * FROM t UNPIVOT EXCLUDE NULLS (v FOR c IN (a, b))`)
+		AssertParseStatementString(t, `SELECT * FROM t UNPIVOT (v FOR c IN (a AS 'x', b AS "y", c AS 'it\'s'))`,
+			`SELECT * FROM t UNPIVOT (v FOR c IN (a AS 'x', b AS "y", c AS 'it\'s'))`)
+		AssertParseStatementString(t, `SELECT a tablesample FROM t tablesample, u`, `SELECT a AS tablesample FROM t AS tablesample, u`)
+		AssertParseStatementString(t, `SELECT * FROM t tablesample TABLESAMPLE bernoulli (10 PERCENT)`,
+			`SELECT * FROM t AS tablesample TABLESAMPLE bernoulli (10 PERCENT)`)
+		AssertParseStatementString(t, `SELECT x, offset + 1 FROM UNNEST(a) AS x WITH OFFSET ORDER BY offset LIMIT 1 OFFSET 2`,
+			`SELECT x, offset + 1 FROM UNNEST(a) AS x WITH OFFSET ORDER BY offset LIMIT 1 OFFSET 2`)
 	})
 }
 
+func TestSetOperation(t *testing.T) {
+	t.Run("Precedence", func(t *testing.T) {
+		AssertSetOperation(t, `SELECT 1 UNION SELECT 2 INTERSECT SELECT 3`,
+			`SELECT 1`, "UNION", `SELECT 2 INTERSECT SELECT 3`)
+		AssertSetOperation(t, `SELECT 1 INTERSECT SELECT 2 UNION SELECT 3`,
+			`SELECT 1 INTERSECT SELECT 2`, "UNION", `SELECT 3`)
+		AssertSetOperation(t, `SELECT 1 UNION SELECT 2 EXCEPT SELECT 3 MINUS SELECT 4`,
+			`SELECT 1 UNION SELECT 2 EXCEPT SELECT 3`, "MINUS", `SELECT 4`)
+		AssertSetOperation(t, `(SELECT 1 UNION SELECT 2) INTERSECT SELECT 3`,
+			`(SELECT 1 UNION SELECT 2)`, "INTERSECT", `SELECT 3`)
+		AssertSetOperation(t, `SELECT 1 EXCEPT (SELECT 2 EXCEPT SELECT 3)`,
+			`SELECT 1`, "EXCEPT", `(SELECT 2 EXCEPT SELECT 3)`)
+	})
+
+	// Parenthesised operands are queries wherever a query may appear, not
+	// only at statement level.
+	t.Run("Nested", func(t *testing.T) {
+		AssertParseStatementString(t, `SELECT * FROM ((SELECT a FROM t) UNION (SELECT a FROM u)) s`,
+			`SELECT * FROM ((SELECT a FROM t) UNION (SELECT a FROM u)) AS s`)
+		AssertParseStatementString(t, `SELECT * FROM t WHERE x IN ((SELECT a FROM t) UNION (SELECT b FROM u))`,
+			`SELECT * FROM t WHERE x IN ((SELECT a FROM t) UNION (SELECT b FROM u))`)
+		AssertParseStatementString(t, `SELECT ((SELECT 1) UNION (SELECT 2)) AS x`,
+			`SELECT ((SELECT 1) UNION (SELECT 2)) AS x`)
+		AssertParseStatementString(t, `INSERT INTO x (a) (SELECT a FROM t) UNION ALL (SELECT a FROM u)`,
+			`INSERT INTO x (a) (SELECT a FROM t) UNION ALL (SELECT a FROM u)`)
+		AssertParseStatementString(t, `INSERT INTO x (SELECT a FROM t) MINUS (SELECT a FROM u)`,
+			`INSERT INTO x (SELECT a FROM t) MINUS (SELECT a FROM u)`)
+
+		// A parenthesised subquery may still start a join.
+		AssertParseStatementString(t, `SELECT * FROM ((SELECT a FROM t) s JOIN u ON s.a = u.a)`,
+			`SELECT * FROM ((SELECT a FROM t) AS s JOIN u ON s.a = u.a)`)
+	})
+
+	t.Run("String", func(t *testing.T) {
+		one := &query.SelectStatement{Columns: []*query.ResultColumn{{Expr: &query.NumberLit{Value: "1"}}}}
+		two := &query.SelectStatement{Columns: []*query.ResultColumn{{Expr: &query.NumberLit{Value: "2"}}}}
+		union := &query.SelectStatement{Compound: &query.SetOperation{X: one, Union: pos(1), All: pos(1), Y: two}}
+
+		// Operands are parenthesised where precedence would change their meaning.
+		assert.Equal(t, `(SELECT 1 UNION ALL SELECT 2) INTERSECT SELECT 1`,
+			(&query.SetOperation{X: union, Intersect: pos(1), Y: one}).String())
+		assert.Equal(t, `SELECT 1 UNION ALL SELECT 2 EXCEPT SELECT 1`,
+			(&query.SetOperation{X: union, Except: pos(1), Y: one}).String())
+		assert.Equal(t, `SELECT 1 EXCEPT DISTINCT (SELECT 1 UNION ALL SELECT 2)`,
+			(&query.SetOperation{X: one, Except: pos(1), Distinct: pos(1), Y: union}).String())
+
+		// As are operands with their own ORDER BY, LIMIT or SORT BY.
+		limited := &query.SelectStatement{Columns: two.Columns, LimitExpr: &query.NumberLit{Value: "1"}}
+		assert.Equal(t, `SELECT 1 UNION (SELECT 2 LIMIT 1)`,
+			(&query.SetOperation{X: one, Union: pos(1), Y: limited}).String())
+		sorted := &query.SelectStatement{Columns: two.Columns, SortTerms: []*query.OrderingTerm{{X: &query.NumberLit{Value: "1"}}}}
+		assert.Equal(t, `SELECT 1 UNION (SELECT 2 SORT BY 1)`,
+			(&query.SetOperation{X: one, Union: pos(1), Y: sorted}).String())
+	})
+}
+
+func TestSelectStatement_GroupingExprs(t *testing.T) {
+	stmt, err := query.NewParser(strings.NewReader(`SELECT 1 GROUP BY a, ROLLUP (b, (a, c)), GROUPING SETS ((d), (), CUBE (B))`)).ParseStatement()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var names []string
+	for _, expr := range stmt.(*query.SelectStatement).GroupingExprs() {
+		names = append(names, expr.String())
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d"}, names)
+}
+
+// AssertSetOperation asserts that s parses to a set operation with the given
+// operator and operands.
+func AssertSetOperation(tb testing.TB, s string, x, op, y string) {
+	tb.Helper()
+	stmt, err := query.NewParser(strings.NewReader(s)).ParseStatement()
+	if !assert.NoError(tb, err) {
+		return
+	}
+
+	sel := stmt.(*query.SelectStatement)
+	if !assert.NotNil(tb, sel.Compound) {
+		return
+	}
+	_, name := sel.Compound.Op()
+	assert.Equal(tb, x, sel.Compound.X.String())
+	assert.Equal(tb, op, name)
+	assert.Equal(tb, y, sel.Compound.Y.String())
+	assert.Equal(tb, s, stmt.String())
+}
+
 // AssertParseStatementError asserts s parses to a given error string.
 func AssertParseStatementError(tb testing.TB, s string, want string) {
 	tb.Helper()
@@ -1745,6 +2415,16 @@ func AssertParseStatementError(tb testing.TB, s string, want string) {
 	assert.ErrorContains(tb, err, want)
 }
 
+// AssertParseStatementString asserts that s parses to a statement printed
+// as want.
+func AssertParseStatementString(tb testing.TB, s string, want string) {
+	tb.Helper()
+	stmt, err := query.NewParser(strings.NewReader(s)).ParseStatement()
+	if assert.NoError(tb, err) {
+		assert.Equal(tb, want, stmt.String())
+	}
+}
+
 // AssertParseStatement asserts the value of the first parse of s.
 func AssertParseStatement(tb testing.TB, s string, want query.Statement) {
 	tb.Helper()
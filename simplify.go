@@ -0,0 +1,475 @@
+package query
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Simplify returns a simplified form of expr that evaluates to the same value.
+//
+// Constant subexpressions are folded, redundant parentheses are removed,
+// boolean identities and De Morgan's laws are applied, nested AND/OR are
+// flattened and de-duplicated and comparisons are written with the column
+// on the left and operands in a stable order. Parentheses are only kept
+// where they are required by operator precedence.
+//
+// expr is not modified, although the result may share unmodified subtrees with it.
+func Simplify(expr Expr) Expr {
+	if expr == nil {
+		return nil
+	}
+	return simplify(expr)
+}
+
+func simplify(expr Expr) Expr {
+	switch expr := expr.(type) {
+	case *ParenExpr:
+		return simplify(expr.X)
+	case *UnaryExpr:
+		x := simplify(expr.X)
+		if expr.Op == NOT {
+			return negate(x)
+		}
+		return fold(&UnaryExpr{OpPos: expr.OpPos, Op: expr.Op, X: wrapUnary(x)})
+	case *BinaryExpr:
+		return simplifyBinaryExpr(expr)
+	case *Null:
+		return fold(&Null{X: wrapUnary(simplify(expr.X)), Op: expr.Op, OpPos: expr.OpPos})
+	case *CastExpr:
+		x := simplify(expr.X)
+		if inner, ok := x.(*CastExpr); ok && sameType(inner.Type, expr.Type) {
+			return inner
+		}
+		other := *expr
+		other.X = x
+		return fold(&other)
+	case *CaseExpr:
+		return simplifyCaseExpr(expr)
+	case *Call:
+		other := *expr
+		other.Args = make([]*Params, len(expr.Args))
+		for i, arg := range expr.Args {
+			other.Args[i] = &Params{X: simplify(arg.X), As: arg.As, Type: arg.Type}
+		}
+		return fold(&other)
+	case *ExprList:
+		list := &ExprList{Lparen: expr.Lparen, Rparen: expr.Rparen}
+		for _, x := range expr.Exprs {
+			list.Exprs = append(list.Exprs, simplify(x))
+		}
+		return list
+	case *Range:
+		return &Range{X: wrapRange(simplify(expr.X)), And: expr.And, Y: wrapRange(simplify(expr.Y))}
+	default:
+		return expr
+	}
+}
+
+func simplifyBinaryExpr(expr *BinaryExpr) Expr {
+	if expr.Op == AND || expr.Op == OR {
+		return simplifyLogical(expr.Op, expr.OpPos, simplify(expr.X), simplify(expr.Y))
+	}
+
+	x, y, op := simplify(expr.X), simplify(expr.Y), expr.Op
+
+	// Put comparisons in canonical order: a constant goes on the right,
+	// otherwise operands are ordered by their text.
+	if flipped, ok := flipComparison(op); ok {
+		xc, yc := isConstant(x), isConstant(y)
+		if (xc && !yc) || (xc == yc && x.String() > y.String()) {
+			x, y, op = y, x, flipped
+		}
+	}
+
+	return fold(&BinaryExpr{
+		X:     wrapBinary(op, x, false),
+		OpPos: expr.OpPos,
+		Op:    op,
+		Y:     wrapBinary(op, y, true),
+	})
+}
+
+// simplifyLogical flattens x op y into a list of terms, drops duplicates and
+// identity values and rebuilds the expression from the remaining terms.
+// Terms calling volatile functions are never duplicates, as each call can
+// return a different value.
+func simplifyLogical(op Token, pos Pos, x, y Expr) Expr {
+	absorbing := op == OR // TRUE for OR, FALSE for AND
+
+	var terms []Expr
+	seen := make(map[string]bool)
+	for _, term := range append(logicalTerms(op, x), logicalTerms(op, y)...) {
+		if lit, ok := term.(*BoolLit); ok {
+			if lit.Value == absorbing {
+				return &BoolLit{ValuePos: lit.ValuePos, Value: absorbing}
+			}
+			continue
+		}
+		if isVolatile(term) {
+			terms = append(terms, term)
+		} else if key := term.String(); !seen[key] {
+			seen[key] = true
+			terms = append(terms, term)
+		}
+	}
+
+	if len(terms) == 0 {
+		return &BoolLit{ValuePos: pos, Value: !absorbing}
+	}
+
+	result := terms[0]
+	for _, term := range terms[1:] {
+		result = &BinaryExpr{
+			X:     wrapBinary(op, result, false),
+			OpPos: pos,
+			Op:    op,
+			Y:     wrapBinary(op, term, true),
+		}
+	}
+	return fold(result)
+}
+
+// logicalTerms returns the operands of a chain of op.
+func logicalTerms(op Token, expr Expr) []Expr {
+	if p, ok := expr.(*ParenExpr); ok {
+		expr = p.X
+	}
+	if bin, ok := expr.(*BinaryExpr); ok && bin.Op == op {
+		return append(logicalTerms(op, bin.X), logicalTerms(op, bin.Y)...)
+	}
+	return []Expr{expr}
+}
+
+// negate returns the simplified form of NOT expr.
+func negate(expr Expr) Expr {
+	switch expr := expr.(type) {
+	case *BoolLit:
+		return &BoolLit{ValuePos: expr.ValuePos, Value: !expr.Value}
+	case *NullLit:
+		return expr
+	case *UnaryExpr:
+		if expr.Op == NOT {
+			return expr.X
+		}
+	case *Null:
+		if expr.Op == ISNULL {
+			// Print as "IS NOT NULL" the same way the parser represents it.
+			return &BinaryExpr{X: expr.X, OpPos: expr.OpPos, Op: ISNOT, Y: &NullLit{}}
+		}
+		return &Null{X: expr.X, OpPos: expr.OpPos, Op: ISNULL}
+	case *Exists:
+		other := *expr
+		if other.Not.IsValid() {
+			other.Not = Pos{}
+		} else {
+			other.Not = other.Exists
+		}
+		return &other
+	case *BinaryExpr:
+		switch expr.Op {
+		case AND, OR:
+			// De Morgan's laws.
+			op := AND
+			if expr.Op == AND {
+				op = OR
+			}
+			return simplifyLogical(op, expr.OpPos, negate(unparen(expr.X)), negate(unparen(expr.Y)))
+		case ISNOT:
+			if _, ok := expr.Y.(*NullLit); ok {
+				return &Null{X: expr.X, OpPos: expr.OpPos, Op: ISNULL}
+			}
+		}
+		if op, ok := inverseOp(expr.Op); ok {
+			return &BinaryExpr{X: expr.X, OpPos: expr.OpPos, Op: op, Y: expr.Y}
+		}
+	}
+	return &UnaryExpr{Op: NOT, X: wrapUnary(expr)}
+}
+
+func simplifyCaseExpr(expr *CaseExpr) Expr {
+	other := &CaseExpr{Case: expr.Case, Else: expr.Else, End: expr.End}
+	if expr.Operand != nil {
+		other.Operand = simplify(expr.Operand)
+	}
+
+	for _, blk := range expr.Blocks {
+		cond, body := simplify(blk.Condition), simplify(blk.Body)
+
+		// Only a searched CASE can drop or select branches by their condition.
+		if other.Operand == nil {
+			switch lit := cond.(type) {
+			case *BoolLit:
+				if !lit.Value {
+					continue
+				}
+				if len(other.Blocks) == 0 {
+					return body
+				}
+			case *NullLit:
+				continue
+			}
+		}
+		other.Blocks = append(other.Blocks, &CaseBlock{When: blk.When, Condition: cond, Then: blk.Then, Body: body})
+	}
+
+	if expr.ElseExpr != nil {
+		other.ElseExpr = simplify(expr.ElseExpr)
+	}
+
+	if len(other.Blocks) == 0 {
+		if other.ElseExpr == nil {
+			return &NullLit{Pos: expr.Case}
+		}
+		return other.ElseExpr
+	}
+	return fold(other)
+}
+
+// fold replaces expr with a literal if it only depends on constants.
+// Expressions that fail to evaluate, e.g. on integer overflow, are kept.
+func fold(expr Expr) Expr {
+	if !isConstant(expr) {
+		return expr
+	}
+	switch expr.(type) {
+	case *NullLit, *BoolLit, *NumberLit, *StringLit:
+		return expr
+	}
+
+	v, err := Eval(expr, nil)
+	if err != nil {
+		return expr
+	}
+	if lit := valueExpr(v); lit != nil {
+		return lit
+	}
+	return expr
+}
+
+// isConstant returns true if expr does not reference columns, variables or subqueries.
+func isConstant(expr Expr) bool {
+	switch expr := expr.(type) {
+	case *NullLit, *BoolLit, *NumberLit, *StringLit:
+		return true
+	case *ParenExpr:
+		return isConstant(expr.X)
+	case *UnaryExpr:
+		return isConstant(expr.X)
+	case *BinaryExpr:
+		return isConstant(expr.X) && isConstant(expr.Y)
+	case *Null:
+		return isConstant(expr.X)
+	case *CastExpr:
+		return isConstant(expr.X)
+	case *Range:
+		return isConstant(expr.X) && isConstant(expr.Y)
+	case *ExprList:
+		for _, x := range expr.Exprs {
+			if !isConstant(x) {
+				return false
+			}
+		}
+		return true
+	case *Call:
+		if _, ok := LookupFunc(MIdentName(expr.Name)); !ok || expr.Over != nil {
+			return false
+		}
+		for _, arg := range expr.Args {
+			if !isConstant(arg.X) {
+				return false
+			}
+		}
+		return true
+	case *CaseExpr:
+		if expr.Operand != nil && !isConstant(expr.Operand) {
+			return false
+		}
+		if expr.ElseExpr != nil && !isConstant(expr.ElseExpr) {
+			return false
+		}
+		for _, blk := range expr.Blocks {
+			if !isConstant(blk.Condition) || !isConstant(blk.Body) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// volatileFuncs are functions that can return a different value on each
+// call.
+var volatileFuncs = map[string]bool{
+	"GENERATE_UUID": true,
+	"NEWID":         true,
+	"RAND":          true,
+	"RANDOM":        true,
+	"UUID":          true,
+}
+
+// isVolatile returns true if expr calls a volatile function.
+func isVolatile(expr Expr) bool {
+	volatile := false
+	Inspect(expr, func(n Node) bool {
+		if c, ok := n.(*Call); ok && volatileFuncs[strings.ToUpper(MIdentName(c.Name))] {
+			volatile = true
+		}
+		return !volatile
+	})
+	return volatile
+}
+
+// valueExpr returns the literal for v. Returns nil if v has no literal form.
+func valueExpr(v Value) Expr {
+	switch v := v.(type) {
+	case nil:
+		return &NullLit{}
+	case bool:
+		return &BoolLit{Value: v}
+	case int64:
+		return &NumberLit{Value: strconv.FormatInt(v, 10)}
+	case float64:
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return nil
+		}
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return &NumberLit{Value: s}
+	case string:
+		return &StringLit{Value: v, Quote: '\''}
+	default:
+		return nil
+	}
+}
+
+func sameType(a, b *Type) bool {
+	if a == nil || b == nil || a.Name == nil || b.Name == nil {
+		return false
+	}
+	return strings.EqualFold(a.Name.Name, b.Name.Name) &&
+		numberLitValue(a.Precision) == numberLitValue(b.Precision) &&
+		numberLitValue(a.Scale) == numberLitValue(b.Scale)
+}
+
+func numberLitValue(lit *NumberLit) string {
+	if lit == nil {
+		return ""
+	}
+	return lit.Value
+}
+
+// flipComparison returns the operator to use when the operands of op are swapped.
+func flipComparison(op Token) (Token, bool) {
+	switch op {
+	case EQ, NE, EQN:
+		return op, true
+	case LT:
+		return GT, true
+	case LE:
+		return GE, true
+	case GT:
+		return LT, true
+	case GE:
+		return LE, true
+	default:
+		return op, false
+	}
+}
+
+// inverseOp returns the operator that computes NOT (x op y).
+func inverseOp(op Token) (Token, bool) {
+	switch op {
+	case EQ:
+		return NE, true
+	case NE:
+		return EQ, true
+	case LT:
+		return GE, true
+	case LE:
+		return GT, true
+	case GT:
+		return LE, true
+	case GE:
+		return LT, true
+	case IS:
+		return ISNOT, true
+	case ISNOT:
+		return IS, true
+	}
+	for pos, neg := range negatedOps {
+		switch op {
+		case pos:
+			return neg, true
+		case neg:
+			return pos, true
+		}
+	}
+	return op, false
+}
+
+// negatedOps maps operators to their "NOT" form.
+var negatedOps = map[Token]Token{
+	IN:      NOTIN,
+	LIKE:    NOTLIKE,
+	GLOB:    NOTGLOB,
+	REGEXP:  NOTREGEXP,
+	MATCH:   NOTMATCH,
+	BETWEEN: NOTBETWEEN,
+}
+
+// binaryPrec returns the precedence of a binary operator, including the
+// combined "NOT" and "IS NOT" operators produced by the parser.
+func binaryPrec(op Token) int {
+	if op == ISNOT {
+		return IS.Precedence()
+	}
+	for pos, neg := range negatedOps {
+		if op == neg {
+			return pos.Precedence()
+		}
+	}
+	return op.Precedence()
+}
+
+func unparen(expr Expr) Expr {
+	for {
+		p, ok := expr.(*ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = p.X
+	}
+}
+
+// wrapBinary parenthesizes an operand of op if it binds less tightly than op.
+func wrapBinary(op Token, x Expr, right bool) Expr {
+	bin, ok := x.(*BinaryExpr)
+	if !ok {
+		return x
+	}
+	if p, q := binaryPrec(bin.Op), binaryPrec(op); p < q || (right && p == q) {
+		return &ParenExpr{X: x}
+	}
+	return x
+}
+
+// wrapUnary parenthesizes the operand of a unary operator or NULL check.
+func wrapUnary(x Expr) Expr {
+	switch x.(type) {
+	case *BinaryExpr, *Null:
+		return &ParenExpr{X: x}
+	}
+	return x
+}
+
+// wrapRange parenthesizes a BETWEEN bound that is not a single operand.
+func wrapRange(x Expr) Expr {
+	if _, ok := x.(*BinaryExpr); ok {
+		return &ParenExpr{X: x}
+	}
+	return x
+}
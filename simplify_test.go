@@ -0,0 +1,100 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+)
+
+func TestSimplify(t *testing.T) {
+	t.Run("Parens", func(t *testing.T) {
+		AssertSimplify(t, `((a))`, `a`)
+		AssertSimplify(t, `(a + b) * c`, `(a + b) * c`)
+		AssertSimplify(t, `(a * b) + c`, `a * b + c`)
+		AssertSimplify(t, `a - (b - c)`, `a - (b - c)`)
+		AssertSimplify(t, `(a OR b) AND c`, `(a OR b) AND c`)
+	})
+
+	t.Run("Constants", func(t *testing.T) {
+		AssertSimplify(t, `1 + 2 * 3`, `7`)
+		AssertSimplify(t, `'a' || 'b'`, `'ab'`)
+		AssertSimplify(t, `x > 1 + 1`, `x > 2`)
+		AssertSimplify(t, `x = concat('a', 'b')`, `x = 'ab'`)
+		AssertSimplify(t, `6 / 2`, `3.0`)
+		AssertSimplify(t, `rand() > 1 + 1`, `rand() > 2`)
+		AssertSimplify(t, `9223372036854775807 + 1`, `9223372036854775807 + 1`)
+		AssertSimplify(t, `x > 4611686018427387904 * 2 - 1`, `x > 4611686018427387904 * 2 - 1`)
+	})
+
+	t.Run("BooleanIdentities", func(t *testing.T) {
+		AssertSimplify(t, `1 = 1 AND x > 5`, `x > 5`)
+		AssertSimplify(t, `x > 5 AND 1 = 2`, `FALSE`)
+		AssertSimplify(t, `x > 5 OR TRUE`, `TRUE`)
+		AssertSimplify(t, `x > 5 OR FALSE`, `x > 5`)
+		AssertSimplify(t, `x AND x`, `x`)
+		AssertSimplify(t, `NOT NOT x`, `x`)
+		AssertSimplify(t, `NOT TRUE`, `FALSE`)
+		AssertSimplify(t, `x AND NULL`, `x AND NULL`)
+	})
+
+	t.Run("DeMorgan", func(t *testing.T) {
+		AssertSimplify(t, `NOT (a AND b)`, `NOT a OR NOT b`)
+		AssertSimplify(t, `NOT (a = 1 OR b < 2)`, `a != 1 AND b >= 2`)
+		AssertSimplify(t, `NOT (x IN (1, 2))`, `x NOT IN (1, 2)`)
+		AssertSimplify(t, `NOT (x IS NULL)`, `x IS NOT NULL`)
+		AssertSimplify(t, `NOT (x IS NOT NULL)`, `x IS NULL`)
+		AssertSimplify(t, `NOT (x BETWEEN 1 AND 2)`, `x NOT BETWEEN 1 AND 2`)
+		AssertSimplify(t, `NOT (a AND (b OR c))`, `NOT a OR NOT b AND NOT c`)
+		AssertSimplify(t, `NOT EXISTS (SELECT 1)`, `NOT EXISTS (SELECT 1)`)
+	})
+
+	t.Run("Flatten", func(t *testing.T) {
+		AssertSimplify(t, `(a AND b) AND (c AND a)`, `a AND b AND c`)
+		AssertSimplify(t, `a OR (b OR (c OR b))`, `a OR b OR c`)
+		AssertSimplify(t, `rand() > 0.5 AND rand() > 0.5`, `rand() > 0.5 AND rand() > 0.5`)
+		AssertSimplify(t, `x = uuid() OR x = uuid() OR a OR a`, `uuid() = x OR uuid() = x OR a`)
+		AssertSimplify(t, `a AND (b OR c)`, `a AND (b OR c)`)
+	})
+
+	t.Run("CanonicalComparison", func(t *testing.T) {
+		AssertSimplify(t, `5 < x`, `x > 5`)
+		AssertSimplify(t, `'2024' = dt`, `dt = '2024'`)
+		AssertSimplify(t, `b = a`, `a = b`)
+		AssertSimplify(t, `b >= a`, `a <= b`)
+		AssertSimplify(t, `x + 1 = y`, `x + 1 = y`)
+	})
+
+	t.Run("Cast", func(t *testing.T) {
+		AssertSimplify(t, `CAST(CAST(x AS STRING) AS STRING)`, `CAST(x AS STRING)`)
+		AssertSimplify(t, `CAST(CAST(x AS STRING) AS BIGINT)`, `CAST(CAST(x AS STRING) AS BIGINT)`)
+		AssertSimplify(t, `CAST('12' AS BIGINT)`, `12`)
+	})
+
+	t.Run("Case", func(t *testing.T) {
+		AssertSimplify(t, `CASE WHEN 1 = 2 THEN a ELSE b END`, `b`)
+		AssertSimplify(t, `CASE WHEN x THEN a WHEN 1 = 2 THEN b END`, `CASE WHEN x THEN a END`)
+		AssertSimplify(t, `CASE WHEN 1 = 1 THEN a WHEN x THEN b END`, `a`)
+		AssertSimplify(t, `CASE WHEN FALSE THEN a END`, `NULL`)
+	})
+
+	t.Run("Unchanged", func(t *testing.T) {
+		expr := query.MustParseExprString(`(a) AND 1 = 1`)
+		s := expr.String()
+		query.Simplify(expr)
+		assert.Equal(t, s, expr.String())
+	})
+}
+
+// AssertSimplify asserts that s simplifies to want.
+func AssertSimplify(tb testing.TB, s string, want string) {
+	tb.Helper()
+
+	expr := query.Simplify(query.MustParseExprString(s))
+	assert.Equal(tb, want, expr.String())
+
+	// The simplified expression must parse back to itself.
+	again := query.Simplify(query.MustParseExprString(expr.String()))
+	assert.Equal(tb, want, again.String())
+}
@@ -8,12 +8,20 @@ import (
 func (*DeclarationStatement) node() {}
 func (*DeleteStatement) node()      {}
 func (*InsertStatement) node()      {}
+func (*PartitionSpec) node()        {}
+func (*PartitionColumn) node()      {}
 func (*SetStatement) node()         {}
 func (*CreateTableStatement) node() {}
 func (*DropTableStatement) node()   {}
 func (*MergeStatement) node()       {}
 func (*FunctionStatement) node()    {}
 func (*TruncateStatement) node()    {}
+func (*UpsertClause) node()         {}
+func (*ReturningClause) node()      {}
+func (*Assignment) node()           {}
+func (*IndexedColumn) node()        {}
+func (*ColumnDefinition) node()     {}
+func (*MatchedCondition) node()     {}
 
 type Statement interface {
 	Node
@@ -70,6 +78,8 @@ type InsertStatement struct {
 	As    Pos             `json:"as"`
 	Alias *Ident          `json:"alias"`
 
+	Partition *PartitionSpec `json:"partition"`
+
 	ColumnsLparen Pos      `json:"columns_lparen"`
 	Columns       []*Ident `json:"columns"`
 	ColumnsRparen Pos      `json:"columns_rparen"`
@@ -101,15 +111,22 @@ func (s *InsertStatement) String() string {
 	} else {
 		buf.WriteString("INSERT")
 	}
-	buf.WriteString(" INTO")
+	if s.Overwrite.IsValid() {
+		buf.WriteString(" OVERWRITE")
+	} else {
+		buf.WriteString(" INTO")
+	}
 	if s.TablePos.IsValid() {
-		buf.WriteString(" TABLE ")
+		buf.WriteString(" TABLE")
 	}
 
-	buf.WriteString(s.Table.String())
+	fmt.Fprintf(&buf, " %s", s.Table.String())
 	if s.Alias != nil {
 		fmt.Fprintf(&buf, " AS %s", s.Alias.String())
 	}
+	if s.Partition != nil {
+		fmt.Fprintf(&buf, " %s", s.Partition.String())
+	}
 
 	if len(s.Columns) != 0 {
 		buf.WriteString(" (")
@@ -153,6 +170,51 @@ func (s *InsertStatement) String() string {
 	return buf.String()
 }
 
+// PartitionSpec is the PARTITION clause of an INSERT, which names the
+// partition written, e.g. PARTITION (pt = '2024-01-01', region).
+type PartitionSpec struct {
+	Partition Pos                `json:"partition"`
+	Lparen    Pos                `json:"lparen"`
+	Columns   []*PartitionColumn `json:"columns"`
+	Rparen    Pos                `json:"rparen"`
+}
+
+// String returns the string representation of the clause.
+func (s *PartitionSpec) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("PARTITION (")
+	for i, col := range s.Columns {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(col.String())
+	}
+	buf.WriteString(")")
+	return buf.String()
+}
+
+// PartitionColumn is a partition column of a PartitionSpec. The partition is
+// static if the column is given a value, and dynamic otherwise, in which
+// case the value is taken from the query.
+type PartitionColumn struct {
+	Name  *Ident `json:"name"`
+	Eq    Pos    `json:"eq"`
+	Value Expr   `json:"value"`
+}
+
+// IsDynamic returns true if the column has no value.
+func (c *PartitionColumn) IsDynamic() bool {
+	return c.Value == nil
+}
+
+// String returns the string representation of the column.
+func (c *PartitionColumn) String() string {
+	if c.Value == nil {
+		return c.Name.String()
+	}
+	return fmt.Sprintf("%s = %s", c.Name.String(), c.Value.String())
+}
+
 type UpsertClause struct {
 	On         Pos `json:"on"`
 	OnConflict Pos `json:"on_conflict"`
@@ -446,6 +508,47 @@ type MatchedCondition struct {
 	ValueLists *ExprList `json:"value_lists"`
 }
 
+// String returns the string representation of the condition.
+func (c *MatchedCondition) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("WHEN ")
+	if c.Not.IsValid() {
+		buf.WriteString("NOT ")
+	}
+	buf.WriteString("MATCHED")
+	if c.AndExpr != nil {
+		fmt.Fprintf(&buf, " AND %s", c.AndExpr.String())
+	}
+	buf.WriteString(" THEN ")
+
+	switch {
+	case c.Delete.IsValid():
+		buf.WriteString("DELETE")
+	case c.Update.IsValid():
+		buf.WriteString("UPDATE SET ")
+		for i := range c.Assignments {
+			if i != 0 {
+				buf.WriteString(", ")
+			}
+			buf.WriteString(c.Assignments[i].String())
+		}
+	case c.Insert.IsValid():
+		buf.WriteString("INSERT")
+		if c.Star.IsValid() {
+			buf.WriteString(" *")
+			break
+		}
+		if c.ColList != nil {
+			fmt.Fprintf(&buf, " %s", c.ColList.String())
+		}
+		if c.ValueLists != nil {
+			fmt.Fprintf(&buf, " VALUES %s", c.ValueLists.String())
+		}
+	}
+
+	return buf.String()
+}
+
 type MergeStatement struct {
 	Merge Pos `json:"merge"`
 	Into  Pos `json:"into"`
@@ -460,8 +563,17 @@ type MergeStatement struct {
 	Matched []*MatchedCondition `json:"matched"`
 }
 
+// String returns the string representation of the statement.
 func (s *MergeStatement) String() string {
-	return "MergeStatement"
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "MERGE INTO %s USING %s", s.Target.String(), s.Source.String())
+	if s.OnExpr != nil {
+		fmt.Fprintf(&buf, " ON %s", s.OnExpr.String())
+	}
+	for _, m := range s.Matched {
+		fmt.Fprintf(&buf, " %s", m.String())
+	}
+	return buf.String()
 }
 
 type FunctionStatement struct {
@@ -481,8 +593,31 @@ type FunctionStatement struct {
 	End    Pos  `json:"end"`
 }
 
+// String returns the string representation of the statement.
 func (s *FunctionStatement) String() string {
-	return "FunctionStatement"
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "FUNCTION %s (", s.Name.String())
+	for i, param := range s.Params {
+		if i != 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(param.String())
+	}
+	buf.WriteString(")")
+
+	if s.ReturnParam != nil {
+		fmt.Fprintf(&buf, " RETURNS %s", s.ReturnParam.String())
+	}
+
+	buf.WriteString(" AS ")
+	if s.Begin.IsValid() {
+		buf.WriteString("BEGIN ")
+	}
+	buf.WriteString(s.FnExpr.String())
+	if s.End.IsValid() {
+		buf.WriteString(" END")
+	}
+	return buf.String()
 }
 
 type TruncateStatement struct {
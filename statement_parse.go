@@ -63,8 +63,8 @@ func (p *Parser) parseNonExplainStatement() (Statement, error) {
 		return p.parseCreateStatement()
 	case DROP:
 		return p.parseDropStatement()
-	case SELECT, VALUES:
-		return p.parseSelectStatement(false, nil)
+	case SELECT, VALUES, LP:
+		return p.parseSelectStatement(nil)
 	case INSERT, REPLACE:
 		return p.parseInsertStatement(nil)
 	case DELETE:
@@ -178,8 +178,14 @@ func (p *Parser) parseInsertStatement(withClause *WithClause) (_ *InsertStatemen
 		}
 	}
 
+	if p.peek() == PARTITION {
+		if stmt.Partition, err = p.parsePartitionSpec(); err != nil {
+			return &stmt, err
+		}
+	}
+
 	columnList := false
-	if p.peek() == LP {
+	if p.peek() == LP && !p.peekSetOperand() {
 		lparen, _, _ := p.scan()
 		if p.peek() == SELECT || p.peek() == WITH {
 			stmt.SelLparen = lparen
@@ -240,8 +246,11 @@ func (p *Parser) parseInsertStatement(withClause *WithClause) (_ *InsertStatemen
 			}
 			p.scan()
 		}
-	case SELECT, WITH:
-		if stmt.Select, err = p.parseSelectStatement(false, nil); err != nil {
+	case SELECT, WITH, LP:
+		if p.peek() == LP && !p.queryAt(1) {
+			return &stmt, p.errorExpected(p.pos, p.tok, "VALUES, SELECT, or DEFAULT VALUES")
+		}
+		if stmt.Select, err = p.parseSelectStatement(nil); err != nil {
 			return &stmt, err
 		}
 	default:
@@ -269,6 +278,40 @@ func (p *Parser) parseInsertStatement(withClause *WithClause) (_ *InsertStatemen
 	return &stmt, nil
 }
 
+func (p *Parser) parsePartitionSpec() (_ *PartitionSpec, err error) {
+	assert(p.peek() == PARTITION)
+
+	var spec PartitionSpec
+	spec.Partition, _, _ = p.scan()
+	if p.peek() != LP {
+		return &spec, p.errorExpected(p.pos, p.tok, "left paren")
+	}
+	spec.Lparen, _, _ = p.scan()
+
+	for {
+		var col PartitionColumn
+		if col.Name, err = p.parseIdent("partition column"); err != nil {
+			return &spec, err
+		}
+		if p.peek() == EQ {
+			col.Eq, _, _ = p.scan()
+			if col.Value, err = p.ParseExpr(); err != nil {
+				return &spec, err
+			}
+		}
+		spec.Columns = append(spec.Columns, &col)
+
+		if p.peek() == RP {
+			spec.Rparen, _, _ = p.scan()
+			break
+		} else if p.peek() != COMMA {
+			return &spec, p.errorExpected(p.pos, p.tok, "comma or right paren")
+		}
+		p.scan()
+	}
+	return &spec, nil
+}
+
 func (p *Parser) parseUpsertClause() (_ *UpsertClause, err error) {
 	assert(p.peek() == ON)
 
@@ -595,7 +638,7 @@ func (p *Parser) parseCreateTableStatement(createPos Pos) (_ *CreateTableStateme
 		return &stmt, nil
 	case AS:
 		stmt.As, _, _ = p.scan()
-		if stmt.Select, err = p.parseSelectStatement(false, nil); err != nil {
+		if stmt.Select, err = p.parseSelectStatement(nil); err != nil {
 			return &stmt, err
 		}
 		return &stmt, nil
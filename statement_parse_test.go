@@ -47,7 +47,7 @@ func TestParser_ParseStatement2(t *testing.T) {
 		})
 		AssertParseStatement(t, `@start_date := '{{ .DSTART | Date }}';`, &query.DeclarationStatement{
 			Name:  &query.Ident{Name: "@start_date", NamePos: pos(0), Tok: query.BIND},
-			Value: &query.StringLit{ValuePos: pos(15), Value: "{{ .DSTART | Date }}"},
+			Value: &query.StringLit{ValuePos: pos(15), Value: "{{ .DSTART | Date }}", Quote: '\''},
 		})
 		AssertParseStatement(t, `@start_date := DATE '{{ .DSTART | Date }}';`, &query.DeclarationStatement{
 			Name:  &query.Ident{Name: "@start_date", NamePos: pos(0), Tok: query.BIND},
@@ -60,7 +60,7 @@ func TestParser_ParseStatement2(t *testing.T) {
 				Lparen: pos(22),
 				Rparen: pos(45),
 				Args: []*query.Params{
-					{X: &query.StringLit{ValuePos: pos(23), Value: "{{ .DSTART | Date }}"}},
+					{X: &query.StringLit{ValuePos: pos(23), Value: "{{ .DSTART | Date }}", Quote: '\''}},
 				},
 			},
 		})
@@ -630,6 +630,33 @@ func TestParser_ParseStatement2(t *testing.T) {
 			},
 		})
 
+		AssertParseStatement(t, `INSERT INTO tbl PARTITION (pt = '2024', region) VALUES (1)`, &query.InsertStatement{
+			Insert: pos(0),
+			Into:   pos(7),
+			Table:  &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(12), Name: "tbl", Tok: query.IDENT}},
+			Partition: &query.PartitionSpec{
+				Partition: pos(16),
+				Lparen:    pos(26),
+				Columns: []*query.PartitionColumn{
+					{
+						Name:  &query.Ident{NamePos: pos(27), Name: "pt", Tok: query.IDENT},
+						Eq:    pos(30),
+						Value: &query.StringLit{ValuePos: pos(32), Value: "2024", Quote: '\''},
+					},
+					{Name: &query.Ident{NamePos: pos(40), Name: "region", Tok: query.IDENT}},
+				},
+				Rparen: pos(46),
+			},
+			Values: pos(48),
+			ValueLists: []*query.ExprList{{
+				Lparen: pos(55),
+				Exprs: []query.Expr{
+					&query.NumberLit{ValuePos: pos(56), Value: "1"},
+				},
+				Rparen: pos(57),
+			}},
+		})
+
 		AssertParseStatementError(t, `INSERT`, `1:6: expected INTO or OVERWRITE, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO`, `1:11: expected table name, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl AS`, `1:18: expected alias, found 'EOF'`)
@@ -643,6 +670,10 @@ func TestParser_ParseStatement2(t *testing.T) {
 		AssertParseStatementError(t, `INSERT INTO tbl (x) SELECT`, `1:26: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES (1) RETURNING`, `1:40: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES (1) ON`, `1:33: expected CONFLICT, found 'EOF'`)
+		AssertParseStatementError(t, `INSERT INTO tbl PARTITION`, `1:25: expected left paren, found 'EOF'`)
+		AssertParseStatementError(t, `INSERT INTO tbl PARTITION (`, `1:27: expected partition column, found 'EOF'`)
+		AssertParseStatementError(t, `INSERT INTO tbl PARTITION (pt =`, `1:31: expected expression, found 'EOF'`)
+		AssertParseStatementError(t, `INSERT INTO tbl PARTITION (pt`, `1:29: expected comma or right paren, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES (1) ON CONFLICT (`, `1:44: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES (1) ON CONFLICT (x`, `1:45: expected comma or right paren, found 'EOF'`)
 		AssertParseStatementError(t, `INSERT INTO tbl (x) VALUES (1) ON CONFLICT (x) WHERE`, `1:52: expected expression, found 'EOF'`)
@@ -791,7 +822,7 @@ func TestParser_ParseStatement2(t *testing.T) {
 								X:     &query.MultiPartIdent{Name: &query.Ident{NamePos: pos(61), Name: "foo", Tok: query.IDENT}},
 								OpPos: pos(65),
 								Op:    query.EQ,
-								Y:     &query.StringLit{ValuePos: pos(67), Value: "bar"},
+								Y:     &query.StringLit{ValuePos: pos(67), Value: "bar", Quote: '\''},
 							},
 						},
 					}},
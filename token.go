@@ -8,6 +8,7 @@ import (
 var (
 	keywords      = make(map[string]Token)
 	bareTokensMap = make(map[Token]struct{})
+	tokenNames    = make(map[string]Token)
 )
 
 func init() {
@@ -21,6 +22,12 @@ func init() {
 	for _, tok := range bareTokens {
 		bareTokensMap[tok] = struct{}{}
 	}
+
+	for i, name := range tokens {
+		if name != "" {
+			tokenNames[name] = Token(i)
+		}
+	}
 }
 
 // Token is the set of lexical tokens of the Go programming language.
@@ -84,6 +91,7 @@ const (
 	keyword_beg
 	ALL
 	AND
+	ANTI
 	AS
 	ASC
 	BEGIN
@@ -91,6 +99,7 @@ const (
 	BY
 	CASE
 	CAST
+	CLUSTER
 	COLLATE
 	CONFLICT
 	CREATE
@@ -102,6 +111,7 @@ const (
 	DELETE
 	DESC
 	DISTINCT
+	DISTRIBUTE
 	DO
 	DROP
 	ELSE
@@ -154,6 +164,7 @@ const (
 	OVER
 	OVERWRITE
 	PARTITION
+	PIVOT
 	QUALIFY
 	RECURSIVE
 	REGEXP
@@ -164,13 +175,18 @@ const (
 	RLIKE
 	ROWID
 	SELECT
+	SEMIJOIN // SEMI
 	SET
+	SETMINUS // MINUS
 	SETS
+	SORT
 	TABLE
+	TABLESAMPLE
 	THEN
 	TIMESTAMP
 	TRUNCATE
 	UNION
+	UNPIVOT
 	UPDATE
 	USING
 	VALUES
@@ -194,6 +210,7 @@ var tokens = [...]string{
 	IDENT:   "IDENT",
 	QIDENT:  "QIDENT",
 	STRING:  "STRING",
+	TSTRING: "TSTRING",
 	RAWSTR:  "RAWSTR",
 	FLOAT:   "FLOAT",
 	INTEGER: "INTEGER",
@@ -201,6 +218,7 @@ var tokens = [...]string{
 	TRUE:    "TRUE",
 	FALSE:   "FALSE",
 	BIND:    "BIND",
+	TMPL:    "TMPL",
 
 	SEMI:   ";",
 	LP:     "(",
@@ -229,8 +247,12 @@ var tokens = [...]string{
 	DOT:    ".",
 	ASSIGN: ":=",
 
+	JSON_EXTRACT_JSON: "->",
+	JSON_EXTRACT_SQL:  "->>",
+
 	ALL:               "ALL",
 	AND:               "AND",
+	ANTI:              "ANTI",
 	AS:                "AS",
 	ASC:               "ASC",
 	BEGIN:             "BEGIN",
@@ -238,6 +260,7 @@ var tokens = [...]string{
 	BY:                "BY",
 	CASE:              "CASE",
 	CAST:              "CAST",
+	CLUSTER:           "CLUSTER",
 	COLLATE:           "COLLATE",
 	CONFLICT:          "CONFLICT",
 	CREATE:            "CREATE",
@@ -249,6 +272,7 @@ var tokens = [...]string{
 	DELETE:            "DELETE",
 	DESC:              "DESC",
 	DISTINCT:          "DISTINCT",
+	DISTRIBUTE:        "DISTRIBUTE",
 	DO:                "DO",
 	DROP:              "DROP",
 	ELSE:              "ELSE",
@@ -301,6 +325,7 @@ var tokens = [...]string{
 	OVER:              "OVER",
 	OVERWRITE:         "OVERWRITE",
 	PARTITION:         "PARTITION",
+	PIVOT:             "PIVOT",
 	QUALIFY:           "QUALIFY",
 	RECURSIVE:         "RECURSIVE",
 	REGEXP:            "REGEXP",
@@ -311,13 +336,18 @@ var tokens = [...]string{
 	RLIKE:             "RLIKE",
 	ROWID:             "ROWID",
 	SELECT:            "SELECT",
+	SEMIJOIN:          "SEMI",
 	SET:               "SET",
+	SETMINUS:          "MINUS",
 	SETS:              "SETS",
+	SORT:              "SORT",
 	TABLE:             "TABLE",
+	TABLESAMPLE:       "TABLESAMPLE",
 	THEN:              "THEN",
 	TIMESTAMP:         "TIMESTAMP",
 	TRUNCATE:          "TRUNCATE",
 	UNION:             "UNION",
+	UNPIVOT:           "UNPIVOT",
 	UPDATE:            "UPDATE",
 	USING:             "USING",
 	VALUES:            "VALUES",
@@ -331,10 +361,11 @@ var tokens = [...]string{
 
 // A list of keywords that can be used as unquoted identifiers.
 var bareTokens = [...]Token{
-	ASC, BY, CAST, CONFLICT, CROSS, CURRENT_DATE, CURRENT_TIME,
-	CURRENT_TIMESTAMP, DATE, DESC, DO, END, FIRST, FULL, GLOB, IF, INNER, INTEGER,
+	ANTI, ASC, BY, CAST, CLUSTER, CONFLICT, CROSS, CURRENT_DATE, CURRENT_TIME,
+	CURRENT_TIMESTAMP, DATE, DESC, DISTRIBUTE, DO, END, FIRST, FULL, GLOB, IF, INNER, INTEGER,
 	LAST, LEFT, LIKE, MATCH, NATURAL, NULLS, OFFSET, OUTER, OVER,
-	PARTITION, RECURSIVE, REGEXP, REPLACE, TIMESTAMP, VIEW, WINDOW, WITH,
+	PARTITION, PIVOT, RECURSIVE, REGEXP, REPLACE, SEMIJOIN, SETMINUS, SORT, TABLESAMPLE, TIMESTAMP,
+	UNPIVOT, VIEW, WINDOW, WITH,
 }
 
 func (t Token) String() string {
@@ -385,6 +416,12 @@ func isIdentToken(tok Token) bool {
 	return tok == IDENT || tok == QIDENT || tok == TSTRING || tok == BIND || tok == TMPL
 }
 
+// isFieldToken returns true if tok can follow a dot in a qualified name.
+// Keywords are names there, as in t.minus.
+func isFieldToken(tok Token) bool {
+	return isIdentToken(tok) || (tok > keyword_beg && tok < keyword_end)
+}
+
 // isExprIdentToken returns true if tok can be used as an identifier in an expression.
 // It includes IDENT, QIDENT, and certain keywords.
 func isExprIdentToken(tok Token) bool {
@@ -397,6 +434,18 @@ func isExprIdentToken(tok Token) bool {
 	// Special Cases
 	case GROUPING, DATE, TIMESTAMP, LEFT, RIGHT:
 		return true
+	// Join and set operator keywords that are common column names
+	case SEMIJOIN, ANTI, SETMINUS:
+		return true
+	// Hive clause keywords that are common column names
+	case CLUSTER, DISTRIBUTE, SORT:
+		return true
+	// Table operator keywords that are common column names
+	case PIVOT, UNPIVOT:
+		return true
+	// The column of UNNEST ... WITH OFFSET without an alias
+	case OFFSET:
+		return true
 	// Core functions
 	case REPLACE, LIKE, GLOB, IF:
 		return true
@@ -0,0 +1,522 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbchaos/query"
+	"github.com/sbchaos/query/analysis"
+)
+
+// ExpandStars returns a copy of stmt in which every *, qualified star such as
+// t.* and * EXCEPT (...) is replaced by the columns it selects. The columns
+// of tables are looked up in catalog; the columns of CTEs and subqueries are
+// those of their own, expanded, queries.
+//
+// Columns are listed in the order the engine returns them: the columns of
+// each source in FROM order, with the columns of a lateral view after those
+// of its table. The columns joined by USING or by a NATURAL join are merged
+// into one unqualified column, listed before the other columns of the join.
+// Columns of a query reading more than one source are qualified with the
+// alias or name of their source.
+//
+// An error is returned if a star reads a table missing from catalog, a table
+// function, a recursive CTE without a column list or a subquery column
+// without a name. Stars within EXISTS select no columns and are left
+// unchanged.
+func ExpandStars(stmt query.Statement, catalog analysis.Catalog) (query.Statement, error) {
+	e := &expander{
+		catalog: catalog,
+		done:    make(map[*query.SelectStatement][]*query.Ident),
+	}
+	stmt = query.Clone(stmt).(query.Statement)
+
+	var scope *cteScope
+	var err error
+	query.Inspect(stmt, func(n query.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *query.WithClause:
+			// The WITH clause of an INSERT or DELETE precedes its query.
+			scope, err = e.expandWith(n, scope)
+			return false
+		case *query.Exists:
+			return false
+		case *query.SelectStatement:
+			_, err = e.expandQuery(n, scope)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+type expander struct {
+	catalog analysis.Catalog
+
+	// done holds the result columns of the queries already expanded. Columns
+	// without a name are nil.
+	done map[*query.SelectStatement][]*query.Ident
+}
+
+// cteScope holds the CTEs visible to a query.
+type cteScope struct {
+	parent *cteScope
+	ctes   map[string]*cteColumns
+}
+
+// cteColumns holds the columns of a CTE. Columns is nil until the query of
+// the CTE is expanded, unless the CTE lists its columns.
+type cteColumns struct {
+	name    *query.Ident
+	columns []*query.Ident
+}
+
+// lookup returns the CTE named by ident, or nil if no CTE is in scope.
+func (s *cteScope) lookup(ident *query.MultiPartIdent) *cteColumns {
+	if ident == nil || ident.First != nil || ident.Name == nil {
+		return nil
+	}
+	for ; s != nil; s = s.parent {
+		if cte, ok := s.ctes[strings.ToLower(ident.Name.Name)]; ok {
+			return cte
+		}
+	}
+	return nil
+}
+
+// expandWith expands the queries of the CTEs of with and returns the scope
+// of the query they belong to.
+func (e *expander) expandWith(with *query.WithClause, parent *cteScope) (*cteScope, error) {
+	scope := &cteScope{parent: parent, ctes: make(map[string]*cteColumns)}
+	recursive := with.Recursive.IsValid()
+
+	entries := make([]*cteColumns, len(with.CTEs))
+	for i, cte := range with.CTEs {
+		entries[i] = &cteColumns{name: cte.TableName, columns: cte.Columns}
+		if recursive && cte.TableName != nil {
+			scope.ctes[strings.ToLower(cte.TableName.Name)] = entries[i]
+		}
+	}
+
+	for i, cte := range with.CTEs {
+		if cte.Select != nil {
+			cols, err := e.expandQuery(cte.Select, scope)
+			if err != nil {
+				return nil, err
+			}
+			if len(cte.Columns) == 0 {
+				entries[i].columns = cols
+			}
+		}
+		if cte.TableName != nil {
+			scope.ctes[strings.ToLower(cte.TableName.Name)] = entries[i]
+		}
+	}
+	return scope, nil
+}
+
+// expandQuery expands the stars of sel and of the queries it combines, and
+// returns its result columns.
+func (e *expander) expandQuery(sel *query.SelectStatement, scope *cteScope) ([]*query.Ident, error) {
+	if cols, ok := e.done[sel]; ok {
+		return cols, nil
+	}
+
+	var err error
+	if sel.WithClause != nil {
+		if scope, err = e.expandWith(sel.WithClause, scope); err != nil {
+			return nil, err
+		}
+	}
+
+	// The columns of a set operation are named by its first operand.
+	var cols []*query.Ident
+	if op := sel.Compound; op != nil {
+		if cols, err = e.expandQuery(op.X, scope); err != nil {
+			return nil, err
+		}
+		if _, err = e.expandQuery(op.Y, scope); err != nil {
+			return nil, err
+		}
+	} else if cols, err = e.expandSelect(sel, scope); err != nil {
+		return nil, err
+	}
+	e.done[sel] = cols
+	return cols, nil
+}
+
+// expandSelect expands the stars of sel, which is not a set operation, and
+// returns its result columns.
+func (e *expander) expandSelect(sel *query.SelectStatement, scope *cteScope) ([]*query.Ident, error) {
+	if hasStar(sel) {
+		if sel.Source == nil {
+			return nil, fmt.Errorf("%s: cannot expand * without FROM", sel.Select)
+		}
+		from, err := e.sourceColumns(sel.Source, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		var columns []*query.ResultColumn
+		for _, col := range sel.Columns {
+			expanded, err := from.expand(col)
+			if err != nil {
+				return nil, err
+			}
+			columns = append(columns, expanded...)
+		}
+		sel.Columns = columns
+	}
+
+	// Expand the subqueries not read by a star.
+	var err error
+	query.Inspect(sel, func(n query.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *query.Exists:
+			return false
+		case *query.SelectStatement:
+			if n == sel {
+				return true
+			}
+			_, err = e.expandQuery(n, scope)
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sel.ValueLists) != 0 {
+		return make([]*query.Ident, len(sel.ValueLists[0].Exprs)), nil
+	}
+	cols := make([]*query.Ident, len(sel.Columns))
+	for i, col := range sel.Columns {
+		switch expr := col.Expr.(type) {
+		case nil:
+		case *query.Ident:
+			cols[i] = expr
+		case *query.MultiPartIdent:
+			if isColumnRef(expr) {
+				cols[i] = expr.Name
+			}
+		}
+		if col.Alias != nil {
+			cols[i] = col.Alias
+		}
+	}
+	return cols, nil
+}
+
+// hasStar returns true if sel selects * or a qualified star.
+func hasStar(sel *query.SelectStatement) bool {
+	for _, col := range sel.Columns {
+		if col.Star.IsValid() {
+			return true
+		} else if ref, ok := col.Expr.(*query.QualifiedRef); ok && ref.Star.IsValid() {
+			return true
+		}
+	}
+	return false
+}
+
+// fromColumns holds the columns read by the FROM clause of a query.
+type fromColumns struct {
+	sources []*sourceColumns
+
+	// star holds the columns selected by *, or err if they are unknown.
+	star []*starColumn
+	err  error
+}
+
+// sourceColumns holds the columns of a table, CTE, subquery or lateral view.
+type sourceColumns struct {
+	qualifier *query.Ident          // alias, or the last part of name
+	name      *query.MultiPartIdent // table name; nil if aliased
+	columns   []*query.Ident
+	err       error // reason the columns are unknown
+
+	// unqualified is set for UNNEST, whose alias names its element column
+	// rather than qualifying its columns.
+	unqualified bool
+}
+
+// starColumn is a column selected by *. Source is nil for columns merged by
+// a USING constraint or NATURAL join.
+type starColumn struct {
+	source *sourceColumns
+	name   *query.Ident
+}
+
+// sourceColumns returns the columns read by src. Unknown columns are only an
+// error once a star selects them.
+func (e *expander) sourceColumns(src query.Source, scope *cteScope) (*fromColumns, error) {
+	switch src := src.(type) {
+	case *query.QualifiedTableName:
+		sc := &sourceColumns{qualifier: scanQualifier(src)}
+		if src.Alias == nil {
+			sc.name = src.Name
+		}
+
+		if cte := scope.lookup(src.Name); cte != nil {
+			sc.columns = cte.columns
+			if sc.columns == nil {
+				sc.err = fmt.Errorf("%s: cannot expand * over recursive CTE %s without a column list", query.NodePos(src), cte.name.Name)
+			}
+		} else if table := e.table(src.Name); table != nil {
+			for _, col := range table.Columns {
+				sc.columns = append(sc.columns, &query.Ident{Name: col.Name, Tok: query.IDENT})
+			}
+		} else {
+			sc.err = fmt.Errorf("%s: cannot expand *: unknown table %s", query.NodePos(src), tableName(src.Name))
+		}
+
+		return newFromColumns(sc), nil
+
+	case *query.QualifiedTableFunctionName:
+		return newFromColumns(&sourceColumns{
+			qualifier: src.Alias,
+			err:       fmt.Errorf("%s: cannot expand * over table function %s", query.NodePos(src), src.Name.Name),
+		}), nil
+
+	case *query.ParenSource:
+		sel, ok := src.X.(*query.SelectStatement)
+		if !ok {
+			return e.sourceColumns(src.X, scope)
+		}
+		cols, err := e.expandQuery(sel, scope)
+		if err != nil {
+			return nil, err
+		}
+
+		sc := &sourceColumns{qualifier: src.Alias, columns: cols}
+		for i, col := range cols {
+			if col == nil {
+				pos := query.NodePos(sel)
+				if first := sel.Operands()[0]; i < len(first.Columns) {
+					pos = query.NodePos(first.Columns[i])
+				}
+				sc.err = fmt.Errorf("%s: cannot expand *: column %d of subquery has no name", pos, i+1)
+				break
+			}
+		}
+		return newFromColumns(sc), nil
+
+	case *query.UnnestSource:
+		if src.Alias == nil {
+			return newFromColumns(&sourceColumns{
+				err: fmt.Errorf("%s: cannot expand * over UNNEST without an alias", query.NodePos(src)),
+			}), nil
+		}
+		sc := &sourceColumns{qualifier: src.Alias, unqualified: true}
+		sc.columns = append(sc.columns, &query.Ident{Name: src.Alias.Name, Tok: src.Alias.Tok})
+		if src.OffsetAlias != nil {
+			sc.columns = append(sc.columns, &query.Ident{Name: src.OffsetAlias.Name, Tok: src.OffsetAlias.Tok})
+		} else if src.With.IsValid() {
+			sc.columns = append(sc.columns, &query.Ident{Name: "offset", Tok: query.IDENT})
+		}
+		return newFromColumns(sc), nil
+
+	case *query.SampleSource:
+		from, err := e.sourceColumns(src.X, scope)
+		if err != nil || src.Alias == nil || len(from.sources) != 1 {
+			return from, err
+		}
+		from.sources[0].qualifier, from.sources[0].name = src.Alias, nil
+		return from, nil
+
+	case *query.LateralView:
+		from, err := e.sourceColumns(src.X, scope)
+		if err != nil {
+			return nil, err
+		}
+		lvc := &sourceColumns{qualifier: src.TableAlias, columns: src.ColAlias}
+		from.sources = append(from.sources, lvc)
+		from.star = append(from.star, newFromColumns(lvc).star...)
+		return from, nil
+
+	case *query.JoinClause:
+		items := flattenJoin(src, func(query.Source) {})
+		from, err := e.sourceColumns(items[0].source, scope)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items[1:] {
+			// Semi and anti joins only filter the rows of the sources
+			// before them.
+			if op := item.join.Operator; op != nil && op.IsFilter() {
+				continue
+			}
+			right, err := e.sourceColumns(item.source, scope)
+			if err != nil {
+				return nil, err
+			}
+			if err := from.join(right, item.join); err != nil {
+				return nil, err
+			}
+		}
+		return from, nil
+
+	default:
+		return nil, fmt.Errorf("%s: cannot expand * over %s", query.NodePos(src), src)
+	}
+}
+
+// table returns the catalog entry of the table named by ident, or nil.
+func (e *expander) table(ident *query.MultiPartIdent) *analysis.Table {
+	if e.catalog == nil || ident == nil {
+		return nil
+	}
+	return e.catalog.Table(tableName(ident))
+}
+
+func newFromColumns(sc *sourceColumns) *fromColumns {
+	from := &fromColumns{sources: []*sourceColumns{sc}, err: sc.err}
+	for _, col := range sc.columns {
+		from.star = append(from.star, &starColumn{source: sc, name: col})
+	}
+	return from
+}
+
+// join appends the columns of right, joined by join, to the columns of from.
+func (from *fromColumns) join(right *fromColumns, join *query.JoinClause) error {
+	from.sources = append(from.sources, right.sources...)
+	if from.err == nil {
+		from.err = right.err
+	}
+
+	var using []*query.Ident
+	if c, ok := join.Constraint.(*query.UsingConstraint); ok {
+		using = c.Columns
+	} else if join.Operator != nil && join.Operator.Natural.IsValid() && from.err == nil {
+		for _, col := range from.star {
+			if findColumn(right.star, col.name.Name) != nil {
+				using = append(using, col.name)
+			}
+		}
+	}
+	if len(using) == 0 || from.err != nil {
+		from.star = append(from.star, right.star...)
+		return nil
+	}
+
+	merged := make([]*starColumn, 0, len(from.star)+len(right.star))
+	for _, col := range using {
+		left := findColumn(from.star, col.Name)
+		if left == nil || findColumn(right.star, col.Name) == nil {
+			return fmt.Errorf("%s: cannot expand *: column %s of USING is not read by both sides of the join", col.NamePos, col.Name)
+		}
+		merged = append(merged, &starColumn{name: left.name})
+	}
+	for _, star := range [][]*starColumn{from.star, right.star} {
+		for _, col := range star {
+			if findColumn(merged, col.name.Name) == nil {
+				merged = append(merged, col)
+			}
+		}
+	}
+	from.star = merged
+	return nil
+}
+
+// findColumn returns the column of cols with the given name, or nil. Names
+// are compared case-insensitively.
+func findColumn(cols []*starColumn, name string) *starColumn {
+	for _, col := range cols {
+		if strings.EqualFold(col.name.Name, name) {
+			return col
+		}
+	}
+	return nil
+}
+
+// expand returns the columns selected by col, or col itself if it does not
+// select a star.
+func (from *fromColumns) expand(col *query.ResultColumn) ([]*query.ResultColumn, error) {
+	var cols []*starColumn
+	var pos query.Pos
+	switch ref, _ := col.Expr.(*query.QualifiedRef); {
+	case col.Star.IsValid():
+		if from.err != nil {
+			return nil, from.err
+		}
+		cols, pos = from.star, col.Star
+
+	case ref != nil && ref.Star.IsValid():
+		sc := from.source(ref.Name)
+		if sc == nil {
+			return nil, fmt.Errorf("%s: cannot expand %s: no table %s in FROM", query.NodePos(ref), ref, tableName(ref.Name))
+		} else if sc.err != nil {
+			return nil, sc.err
+		}
+		for _, name := range sc.columns {
+			cols = append(cols, &starColumn{source: sc, name: name})
+		}
+		pos = query.NodePos(ref)
+
+	default:
+		return []*query.ResultColumn{col}, nil
+	}
+
+	except := make(map[string]bool)
+	if col.ExceptCol != nil {
+		var err error
+		query.Inspect(col.ExceptCol, func(n query.Node) bool {
+			ident, ok := n.(*query.MultiPartIdent)
+			if !ok || ident.Name == nil {
+				return err == nil
+			}
+			if err == nil && findColumn(cols, ident.Name.Name) == nil {
+				err = fmt.Errorf("%s: cannot expand %s: column %s is not selected", ident.Name.NamePos, col, ident.Name.Name)
+			}
+			except[strings.ToLower(ident.Name.Name)] = true
+			return false
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var expanded []*query.ResultColumn
+	for _, c := range cols {
+		if except[strings.ToLower(c.name.Name)] {
+			continue
+		}
+		ident := &query.MultiPartIdent{Name: &query.Ident{NamePos: pos, Name: c.name.Name, Tok: c.name.Tok}}
+		if c.source != nil && c.source.qualifier != nil && !c.source.unqualified && len(from.sources) > 1 {
+			ident.First = &query.Ident{NamePos: pos, Name: c.source.qualifier.Name, Tok: c.source.qualifier.Tok}
+		}
+		expanded = append(expanded, &query.ResultColumn{Expr: ident})
+	}
+	return expanded, nil
+}
+
+// source returns the source qualified by ident, which may be an alias or a
+// table name, or nil.
+func (from *fromColumns) source(ident *query.MultiPartIdent) *sourceColumns {
+	name := tableName(ident)
+	for _, sc := range from.sources {
+		if ident.First == nil && sc.qualifier != nil && strings.EqualFold(sc.qualifier.Name, name) {
+			return sc
+		}
+		if sc.name != nil && strings.EqualFold(tableName(sc.name), name) {
+			return sc
+		}
+	}
+	return nil
+}
+
+// isColumnRef returns true if ident names a column rather than a keyword,
+// parameter or typed literal.
+func isColumnRef(ident *query.MultiPartIdent) bool {
+	return ident.Name != nil && (ident.Name.Tok == query.IDENT || ident.Name.Tok == query.QIDENT || ident.Name.Tok == query.TSTRING)
+}
@@ -0,0 +1,106 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query/analysis"
+	"github.com/sbchaos/query/transform"
+)
+
+func TestExpandStars(t *testing.T) {
+	catalog := analysis.NewMapCatalog(
+		&analysis.Table{Name: "db.orders", Columns: []*analysis.Column{{Name: "id"}, {Name: "user_id"}, {Name: "amount"}}},
+		&analysis.Table{Name: "db.users", Columns: []*analysis.Column{{Name: "user_id"}, {Name: "name"}}},
+		&analysis.Table{Name: "t", Columns: []*analysis.Column{{Name: "a"}, {Name: "b"}}},
+	)
+
+	t.Run("Table", func(t *testing.T) {
+		AssertExpandStars(t, catalog, `SELECT * FROM db.orders`,
+			`SELECT id, user_id, amount FROM db.orders`)
+		AssertExpandStars(t, catalog, `SELECT o.*, 1 AS x FROM db.orders AS o WHERE o.amount > 0`,
+			`SELECT id, user_id, amount, 1 AS x FROM db.orders AS o WHERE o.amount > 0`)
+		AssertExpandStars(t, catalog, `SELECT * EXCEPT (user_id, AMOUNT) FROM db.orders`,
+			`SELECT id FROM db.orders`)
+		AssertExpandStars(t, catalog, `INSERT INTO x SELECT * FROM t UNION ALL SELECT * FROM t`,
+			`INSERT INTO x SELECT a, b FROM t UNION ALL SELECT a, b FROM t`)
+		AssertExpandStars(t, catalog, `(SELECT * FROM t LIMIT 1) EXCEPT (SELECT * FROM t)`,
+			`(SELECT a, b FROM t LIMIT 1) EXCEPT (SELECT a, b FROM t)`)
+		AssertExpandStars(t, catalog, `SELECT a, count(*) FROM t WHERE EXISTS (SELECT * FROM unknown) GROUP BY a`,
+			`SELECT a, count(*) FROM t WHERE EXISTS (SELECT * FROM unknown) GROUP BY a`)
+	})
+
+	t.Run("Join", func(t *testing.T) {
+		AssertExpandStars(t, catalog, `SELECT * FROM db.orders o JOIN db.users u ON o.user_id = u.user_id`,
+			`SELECT o.id, o.user_id, o.amount, u.user_id, u.name FROM db.orders AS o JOIN db.users AS u ON o.user_id = u.user_id`)
+		AssertExpandStars(t, catalog, `SELECT u.*, db.orders.* EXCEPT (user_id) FROM db.orders, db.users AS u`,
+			`SELECT u.user_id, u.name, orders.id, orders.amount FROM db.orders, db.users AS u`)
+		AssertExpandStars(t, catalog, `SELECT * FROM db.orders o LEFT JOIN db.users u USING (user_id)`,
+			`SELECT user_id, o.id, o.amount, u.name FROM db.orders AS o LEFT JOIN db.users AS u USING (user_id)`)
+		AssertExpandStars(t, catalog, `SELECT * FROM db.orders o LEFT ANTI JOIN db.users u ON o.user_id = u.user_id`,
+			`SELECT id, user_id, amount FROM db.orders AS o LEFT ANTI JOIN db.users AS u ON o.user_id = u.user_id`)
+		AssertExpandStars(t, catalog, `SELECT * FROM db.users NATURAL JOIN db.orders`,
+			`SELECT user_id, users.name, orders.id, orders.amount FROM db.users NATURAL JOIN db.orders`)
+		AssertExpandStars(t, catalog, `SELECT * FROM t LATERAL VIEW explode(b) e AS c`,
+			`SELECT t.a, t.b, e.c FROM t LATERAL VIEW explode(b) e AS c`)
+		AssertExpandStars(t, catalog, `SELECT * FROM t JOIN db.users u ON t.a = u.user_id LATERAL VIEW explode(b) e AS c`,
+			`SELECT t.a, t.b, u.user_id, u.name, e.c FROM t JOIN db.users AS u ON t.a = u.user_id LATERAL VIEW explode(b) e AS c`)
+		AssertExpandStars(t, catalog, `SELECT * FROM t, UNNEST(b) AS x WITH OFFSET`,
+			`SELECT t.a, t.b, x, offset FROM t, UNNEST(b) AS x WITH OFFSET`)
+		AssertExpandStars(t, catalog, `SELECT * FROM t TABLESAMPLE (BUCKET 1 OUT OF 4) s JOIN db.users u ON s.a = u.user_id`,
+			`SELECT s.a, s.b, u.user_id, u.name FROM t TABLESAMPLE (BUCKET 1 OUT OF 4) AS s JOIN db.users AS u ON s.a = u.user_id`)
+	})
+
+	t.Run("Subquery", func(t *testing.T) {
+		AssertExpandStars(t, catalog, `SELECT * FROM (SELECT *, a + b AS c FROM t) s`,
+			`SELECT a, b, c FROM (SELECT a, b, a + b AS c FROM t) AS s`)
+		AssertExpandStars(t, catalog, `SELECT a FROM (SELECT * FROM t) s WHERE b IN (SELECT * EXCEPT (name) FROM db.users)`,
+			`SELECT a FROM (SELECT a, b FROM t) AS s WHERE b IN (SELECT user_id FROM db.users)`)
+	})
+
+	t.Run("CTE", func(t *testing.T) {
+		AssertExpandStars(t, catalog, `WITH t AS (SELECT * FROM t), u (x, y) AS (SELECT * FROM t) SELECT * FROM u JOIN t ON x = a`,
+			`WITH t AS (SELECT a, b FROM t), u (x, y) AS (SELECT a, b FROM t) SELECT u.x, u.y, t.a, t.b FROM u JOIN t ON x = a`)
+		AssertExpandStars(t, catalog, `INSERT INTO x WITH c AS (SELECT a FROM t) SELECT * FROM c`,
+			`INSERT INTO x WITH c AS (SELECT a FROM t) SELECT a FROM c`)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		AssertExpandStarsError(t, catalog, `SELECT a FROM t JOIN (SELECT * FROM db.missing) m ON true`,
+			`1:37: cannot expand *: unknown table db.missing`)
+		AssertExpandStarsError(t, catalog, `SELECT * FROM (SELECT a + 1 FROM t) s`,
+			`1:23: cannot expand *: column 1 of subquery has no name`)
+		AssertExpandStarsError(t, catalog, `SELECT x.* FROM t`,
+			`1:8: cannot expand x.*: no table x in FROM`)
+		AssertExpandStarsError(t, catalog, `SELECT * EXCEPT (a, z) FROM t`,
+			`1:21: cannot expand * EXCEPT (a, z): column z is not selected`)
+		AssertExpandStarsError(t, catalog, `SELECT * FROM t JOIN db.users USING (name)`,
+			`1:38: cannot expand *: column name of USING is not read by both sides of the join`)
+		AssertExpandStarsError(t, catalog, `WITH RECURSIVE r AS (SELECT 1 AS n UNION ALL SELECT * FROM r) SELECT * FROM r`,
+			`1:60: cannot expand * over recursive CTE r without a column list`)
+		AssertExpandStarsError(t, catalog, `SELECT * FROM t, explode(b)`,
+			`1:18: cannot expand * over table function explode`)
+		AssertExpandStarsError(t, catalog, `SELECT * FROM t, unnest(b)`,
+			`1:18: cannot expand * over UNNEST without an alias`)
+	})
+}
+
+// AssertExpandStars asserts that expanding the stars of s gives want.
+func AssertExpandStars(tb testing.TB, catalog analysis.Catalog, s, want string) {
+	tb.Helper()
+
+	out, err := transform.ExpandStars(ParseStatement(tb, s), catalog)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	assert.Equal(tb, want, out.String())
+}
+
+// AssertExpandStarsError asserts that expanding the stars of s fails with want.
+func AssertExpandStarsError(tb testing.TB, catalog analysis.Catalog, s, want string) {
+	tb.Helper()
+
+	_, err := transform.ExpandStars(ParseStatement(tb, s), catalog)
+	assert.EqualError(tb, err, want)
+}
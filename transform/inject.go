@@ -0,0 +1,279 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// InjectPredicates returns a copy of stmt in which every scan of a table
+// named in predicates is filtered by its predicate, including scans within
+// CTEs, subqueries and join sources. Table names are dotted, e.g.
+// "db.orders", and compared case-insensitively.
+//
+// A predicate is an expression such as "tenant_id = @tenant". Unqualified
+// columns in it are qualified with the alias of each scan, or with the table
+// name if the scan has no alias.
+//
+// The predicate is ANDed into the WHERE clause of the query reading the
+// table, or into the ON constraint of the join if the table is on the
+// nullable side of a LEFT JOIN, so that outer joins keep their rows. A table
+// that cannot be filtered either way, such as one side of a FULL JOIN or the
+// source of a MERGE, is replaced by a subquery that applies the predicate.
+// The target of a DELETE is filtered by its WHERE clause; INSERT and MERGE
+// targets are not scans and are left unchanged.
+func InjectPredicates(stmt query.Statement, predicates map[string]string) (query.Statement, error) {
+	inj := &injector{
+		predicates: make(map[string]query.Expr),
+		done:       make(map[*query.QualifiedTableName]bool),
+	}
+	for name, s := range predicates {
+		expr, err := query.NewParser(strings.NewReader(s)).ParseExpr()
+		if err != nil {
+			return nil, fmt.Errorf("predicate for %s: %w", name, err)
+		}
+		inj.predicates[strings.ToLower(name)] = expr
+	}
+
+	stmt = query.Clone(stmt).(query.Statement)
+	inj.ctes = cteRefs(stmt)
+
+	query.Inspect(stmt, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			if n.Source != nil {
+				inj.injectSource(n.Source, func(src query.Source) { n.Source = src }, func(pred query.Expr) {
+					n.WhereExpr = and(n.WhereExpr, pred)
+				})
+			}
+		case *query.DeleteStatement:
+			if n.Table != nil {
+				if pred := inj.predicate(n.Table); pred != nil {
+					n.WhereExpr = and(n.WhereExpr, pred)
+				}
+			}
+		case *query.MergeStatement:
+			if n.Source != nil {
+				inj.injectSource(n.Source, func(src query.Source) { n.Source = src }, nil)
+			}
+		}
+		return true
+	})
+	return stmt, nil
+}
+
+type injector struct {
+	predicates map[string]query.Expr
+	ctes       map[*query.QualifiedTableName]bool
+
+	// done holds the scans already filtered, so that tables wrapped in a
+	// subquery are not filtered again when the subquery is visited.
+	done map[*query.QualifiedTableName]bool
+}
+
+// joinItem is a source within a chain of joins.
+type joinItem struct {
+	source query.Source
+	set    func(query.Source) // replaces source within its parent
+
+	// join holds the operator and constraint joining source to the items
+	// before it. It is nil for the first item.
+	join *query.JoinClause
+}
+
+// flattenJoin returns the sources of src in the order they are joined.
+//
+// Joins are parsed right-nested, but are evaluated left to right: the
+// operator and constraint of a JoinClause join the first source of Y to the
+// sources before it.
+func flattenJoin(src query.Source, set func(query.Source)) []joinItem {
+	join, ok := src.(*query.JoinClause)
+	if !ok {
+		return []joinItem{{source: src, set: set}}
+	}
+
+	items := flattenJoin(join.X, func(src query.Source) { join.X = src })
+	right := flattenJoin(join.Y, func(src query.Source) { join.Y = src })
+	right[0].join = join
+	return append(items, right...)
+}
+
+// injectSource filters the scans within src. The predicates of tables whose
+// rows are kept by every join are passed to filter. If filter is nil, these
+// tables are wrapped in a subquery instead.
+func (inj *injector) injectSource(src query.Source, set func(query.Source), filter func(query.Expr)) {
+	items := flattenJoin(src, set)
+	for i, item := range items {
+		place := filter
+		if outerJoinedAfter(items, i) {
+			place = nil
+		} else if op := joinOperator(item); op != nil && (op.Left.IsValid() || op.IsFilter()) {
+			place = onFilter(item.join)
+		}
+
+		switch src := item.source.(type) {
+		case *query.QualifiedTableName:
+			pred := inj.predicate(src)
+			if pred == nil {
+				continue
+			} else if place != nil {
+				place(pred)
+			} else {
+				item.set(wrap(src, pred))
+			}
+
+		case *query.ParenSource:
+			if _, ok := src.X.(*query.SelectStatement); !ok {
+				inj.injectSource(src.X, func(x query.Source) { src.X = x }, place)
+			}
+
+		// Lateral views keep the columns of their source, so its rows
+		// can be filtered either side of them.
+		case *query.LateralView:
+			inj.injectSource(src.X, func(x query.Source) { src.X = x }, place)
+
+		// The predicates must filter the rows before they are pivoted.
+		case *query.PivotSource:
+			inj.injectSource(src.X, func(x query.Source) { src.X = x }, nil)
+		case *query.UnpivotSource:
+			inj.injectSource(src.X, func(x query.Source) { src.X = x }, nil)
+
+		// Rows are filtered after they are sampled. Predicates placed
+		// outside a sample with its own alias would need that alias, so
+		// its table is wrapped instead.
+		case *query.SampleSource:
+			if src.Alias != nil {
+				place = nil
+			}
+			inj.injectSource(src.X, func(x query.Source) { src.X = x }, place)
+		}
+	}
+}
+
+// outerJoinedAfter returns true if the item at index i can be null-extended
+// by a FULL JOIN at or after it, or by a RIGHT JOIN after it.
+func outerJoinedAfter(items []joinItem, i int) bool {
+	for j := i; j < len(items); j++ {
+		if j == 0 {
+			continue
+		}
+		if op := items[j].join.Operator; op != nil && (op.Full.IsValid() || (j > i && op.Right.IsValid())) {
+			return true
+		}
+	}
+	return false
+}
+
+// joinOperator returns the operator joining item to the items before it, or
+// nil if there is none.
+func joinOperator(item joinItem) *query.JoinOperator {
+	if item.join == nil {
+		return nil
+	}
+	return item.join.Operator
+}
+
+// onFilter returns a filter that ANDs predicates into the ON constraint of
+// join. Returns nil if join is constrained by USING.
+func onFilter(join *query.JoinClause) func(query.Expr) {
+	switch c := join.Constraint.(type) {
+	case nil:
+		return func(pred query.Expr) {
+			if c, ok := join.Constraint.(*query.OnConstraint); ok {
+				c.X = and(c.X, pred)
+				return
+			}
+			join.Constraint = &query.OnConstraint{X: pred}
+		}
+	case *query.OnConstraint:
+		return func(pred query.Expr) { c.X = and(c.X, pred) }
+	default:
+		return nil
+	}
+}
+
+// predicate returns the predicate for the scan src, qualified with its alias,
+// or nil if the table has no predicate or src has already been filtered.
+func (inj *injector) predicate(src *query.QualifiedTableName) query.Expr {
+	if inj.done[src] || inj.ctes[src] {
+		return nil
+	}
+	tmpl, ok := inj.predicates[strings.ToLower(tableName(src.Name))]
+	if !ok {
+		return nil
+	}
+	inj.done[src] = true
+
+	pred := query.Clone(tmpl).(query.Expr)
+	qualify(pred, scanQualifier(src))
+
+	// Scans within the predicate itself are never filtered.
+	query.Inspect(pred, func(n query.Node) bool {
+		if src, ok := n.(*query.QualifiedTableName); ok {
+			inj.done[src] = true
+		}
+		return true
+	})
+	return pred
+}
+
+// scanQualifier returns the name columns of src are qualified with.
+func scanQualifier(src *query.QualifiedTableName) *query.Ident {
+	if src.Alias != nil {
+		return &query.Ident{Name: src.Alias.Name, Tok: src.Alias.Tok}
+	}
+	return &query.Ident{Name: src.Name.Name.Name, Tok: src.Name.Name.Tok}
+}
+
+// qualify qualifies the unqualified column references in expr with
+// qualifier. Function names and subqueries are left unchanged.
+func qualify(expr query.Expr, qualifier *query.Ident) {
+	funcs := make(map[*query.MultiPartIdent]bool)
+	query.Inspect(expr, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			return false
+		case *query.Call:
+			funcs[n.Name] = true
+		case *query.MultiPartIdent:
+			if !funcs[n] && n.First == nil && n.Name != nil && (n.Name.Tok == query.IDENT || n.Name.Tok == query.QIDENT) {
+				n.First = &query.Ident{Name: qualifier.Name, Tok: qualifier.Tok}
+			}
+		}
+		return true
+	})
+}
+
+// wrap returns a subquery that selects the rows of src matching pred, under
+// the name src was referenced by.
+func wrap(src *query.QualifiedTableName, pred query.Expr) query.Source {
+	pos := query.NodePos(src)
+	return &query.ParenSource{
+		X: &query.SelectStatement{
+			Select:    pos,
+			Columns:   []*query.ResultColumn{{Star: pos}},
+			From:      pos,
+			Source:    src,
+			Where:     pos,
+			WhereExpr: pred,
+		},
+		Alias: scanQualifier(src),
+	}
+}
+
+// and returns x AND y, parenthesising operands that bind less tightly.
+// Returns y if x is nil.
+func and(x, y query.Expr) query.Expr {
+	if x == nil {
+		return y
+	}
+	return &query.BinaryExpr{X: parenOr(x), Op: query.AND, Y: parenOr(y)}
+}
+
+func parenOr(expr query.Expr) query.Expr {
+	if bin, ok := expr.(*query.BinaryExpr); ok && bin.Op == query.OR {
+		return &query.ParenExpr{X: expr}
+	}
+	return expr
+}
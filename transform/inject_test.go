@@ -0,0 +1,105 @@
+package transform_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+	"github.com/sbchaos/query/transform"
+)
+
+func TestInjectPredicates(t *testing.T) {
+	predicates := map[string]string{
+		"orders":    "tenant_id = @tenant",
+		"db.users":  "tenant_id = @tenant OR is_public",
+		"db.events": "env = 'prod'",
+	}
+
+	t.Run("Where", func(t *testing.T) {
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders`,
+			`SELECT * FROM orders WHERE orders.tenant_id = @tenant`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders o WHERE a = 1 OR b = 2`,
+			`SELECT * FROM orders AS o WHERE (a = 1 OR b = 2) AND o.tenant_id = @tenant`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM DB.Users, orders o WHERE o.id = users.id`,
+			`SELECT * FROM DB.Users, orders AS o WHERE o.id = users.id AND (Users.tenant_id = @tenant OR Users.is_public) AND o.tenant_id = @tenant`)
+		AssertInjectPredicates(t, predicates, `DELETE FROM db.events WHERE pt < '2024'`,
+			`DELETE FROM db.events WHERE pt < '2024' AND events.env = 'prod'`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM other`,
+			`SELECT * FROM other`)
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		AssertInjectPredicates(t, predicates, `WITH orders AS (SELECT * FROM orders) SELECT * FROM orders WHERE id IN (SELECT id FROM db.events e)`,
+			`WITH orders AS (SELECT * FROM orders WHERE orders.tenant_id = @tenant) SELECT * FROM orders WHERE id IN (SELECT id FROM db.events AS e WHERE e.env = 'prod')`)
+		AssertInjectPredicates(t, predicates, `WITH a AS (SELECT * FROM orders), orders AS (SELECT * FROM a) SELECT * FROM orders`,
+			`WITH a AS (SELECT * FROM orders WHERE orders.tenant_id = @tenant), orders AS (SELECT * FROM a) SELECT * FROM orders`)
+		AssertInjectPredicates(t, predicates, `INSERT INTO orders SELECT * FROM (SELECT * FROM orders) s`,
+			`INSERT INTO orders SELECT * FROM (SELECT * FROM orders WHERE orders.tenant_id = @tenant) AS s`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders o PIVOT (sum(amount) FOR status IN ('paid')) p`,
+			`SELECT * FROM (SELECT * FROM orders AS o WHERE o.tenant_id = @tenant) AS o PIVOT (sum(amount) FOR status IN ('paid')) AS p`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders AS o TABLESAMPLE SYSTEM (10 PERCENT)`,
+			`SELECT * FROM orders AS o TABLESAMPLE SYSTEM (10 PERCENT) WHERE o.tenant_id = @tenant`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders TABLESAMPLE (10 PERCENT) s`,
+			`SELECT * FROM (SELECT * FROM orders WHERE orders.tenant_id = @tenant) AS orders TABLESAMPLE (10 PERCENT) AS s`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders o LATERAL VIEW explode(o.items) i AS item`,
+			`SELECT * FROM orders AS o LATERAL VIEW explode(o.items) i AS item WHERE o.tenant_id = @tenant`)
+	})
+
+	t.Run("Join", func(t *testing.T) {
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders o JOIN db.events e ON o.id = e.id`,
+			`SELECT * FROM orders AS o JOIN db.events AS e ON o.id = e.id WHERE o.tenant_id = @tenant AND e.env = 'prod'`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders o LEFT JOIN db.events e ON o.id = e.id`,
+			`SELECT * FROM orders AS o LEFT JOIN db.events AS e ON o.id = e.id AND e.env = 'prod' WHERE o.tenant_id = @tenant`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM x LEFT JOIN orders o ON x.id = o.id JOIN db.events e ON o.id = e.id`,
+			`SELECT * FROM x LEFT JOIN orders AS o ON x.id = o.id AND o.tenant_id = @tenant JOIN db.events AS e ON o.id = e.id WHERE e.env = 'prod'`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders o RIGHT JOIN db.events e ON o.id = e.id`,
+			`SELECT * FROM (SELECT * FROM orders AS o WHERE o.tenant_id = @tenant) AS o RIGHT JOIN db.events AS e ON o.id = e.id WHERE e.env = 'prod'`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders o LEFT SEMI JOIN db.events e ON o.id = e.id`,
+			`SELECT * FROM orders AS o LEFT SEMI JOIN db.events AS e ON o.id = e.id AND e.env = 'prod' WHERE o.tenant_id = @tenant`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM orders o FULL JOIN db.events e ON o.id = e.id`,
+			`SELECT * FROM (SELECT * FROM orders AS o WHERE o.tenant_id = @tenant) AS o FULL JOIN (SELECT * FROM db.events AS e WHERE e.env = 'prod') AS e ON o.id = e.id`)
+		AssertInjectPredicates(t, predicates, `SELECT * FROM x LEFT JOIN orders USING (id)`,
+			`SELECT * FROM x LEFT JOIN (SELECT * FROM orders WHERE orders.tenant_id = @tenant) AS orders USING (id)`)
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		AssertInjectPredicates(t, predicates, `MERGE INTO orders t USING db.events s ON t.id = s.id WHEN MATCHED THEN DELETE`,
+			`MERGE INTO orders AS t USING (SELECT * FROM db.events AS s WHERE s.env = 'prod') AS s ON t.id = s.id WHEN MATCHED THEN DELETE`)
+	})
+
+	t.Run("Unmodified", func(t *testing.T) {
+		stmt := ParseStatement(t, `SELECT * FROM orders`)
+		_, err := transform.InjectPredicates(stmt, predicates)
+		assert.NoError(t, err)
+		assert.Equal(t, `SELECT * FROM orders`, stmt.String())
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := transform.InjectPredicates(ParseStatement(t, `SELECT 1`), map[string]string{"t": "a ="})
+		assert.ErrorContains(t, err, "predicate for t: ")
+	})
+}
+
+// AssertInjectPredicates asserts that injecting predicates into s gives want.
+func AssertInjectPredicates(tb testing.TB, predicates map[string]string, s, want string) {
+	tb.Helper()
+
+	out, err := transform.InjectPredicates(ParseStatement(tb, s), predicates)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	assert.Equal(tb, want, out.String())
+}
+
+// ParseStatement parses s into a single statement and fails the test on error.
+func ParseStatement(tb testing.TB, s string) query.Statement {
+	tb.Helper()
+
+	stmt, err := query.NewParser(strings.NewReader(s)).ParseStatement()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return stmt
+}
@@ -0,0 +1,292 @@
+package transform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// Rename returns a copy of stmt in which table and function names are
+// rewritten by mapping. Each key of mapping is a dotted name prefix, such as
+// "dev_project" or "dev_project.schema.table", and is replaced by its value
+// wherever a name starts with it. The longest matching prefix wins and
+// prefixes are compared case-insensitively.
+//
+// Renamed names are the tables read by queries, the targets of INSERT, MERGE
+// and DELETE, the tables of DDL statements, the table prefixes of column
+// references and of qualified stars such as db.t.*, and the names of
+// functions qualified by a project or schema. A prefix is only a table name
+// if it names a table read without an alias by the query or one enclosing
+// it, so fields of struct columns are kept. Unqualified function names are
+// built in and never renamed. CTE names, references to CTEs and aliases are
+// left unchanged.
+func Rename(stmt query.Statement, mapping map[string]string) (query.Statement, error) {
+	r := &renamer{mapping: make(map[string][]string)}
+	for from, to := range mapping {
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("invalid rename %q to %q", from, to)
+		}
+		r.mapping[strings.ToLower(from)] = strings.Split(to, ".")
+	}
+
+	stmt = query.Clone(stmt).(query.Statement)
+
+	// Classify names by where they appear before renaming any of them.
+	tables := make(map[*query.MultiPartIdent]bool)
+	funcs := make(map[*query.MultiPartIdent]bool)
+	quals := make(map[*query.MultiPartIdent]bool)
+	ctes := cteRefs(stmt)
+	query.Inspect(stmt, func(n query.Node) bool {
+		switch n := n.(type) {
+		case *query.QualifiedTableName:
+			if !ctes[n] {
+				tables[n.Name] = true
+			}
+		case *query.InsertStatement:
+			tables[n.Table] = true
+		case *query.CreateTableStatement:
+			tables[n.Name] = true
+		case *query.DropTableStatement:
+			tables[n.Name] = true
+		case *query.TruncateStatement:
+			tables[n.Name] = true
+		case *query.FunctionStatement:
+			funcs[n.Name] = true
+		case *query.Call:
+			funcs[n.Name] = true
+		case *query.QualifiedRef:
+			quals[n.Name] = true
+		}
+		return true
+	})
+
+	sv := &scopeVisitor{ctes: ctes, scopes: make(map[*query.MultiPartIdent]*qualifierScope)}
+	if _, err := query.Walk(sv, stmt); err != nil {
+		return nil, err
+	}
+
+	var err error
+	query.Inspect(stmt, func(n query.Node) bool {
+		ident, ok := n.(*query.MultiPartIdent)
+		if !ok || err != nil {
+			return err == nil
+		}
+
+		parts := identParts(ident)
+		switch {
+		case tables[ident]:
+			err = r.rename(ident, len(parts))
+		case funcs[ident]:
+			if len(parts) > 1 {
+				err = r.rename(ident, len(parts))
+			}
+		case quals[ident]:
+			if sv.scopes[ident].tableParts(parts, len(parts)) == len(parts) {
+				err = r.rename(ident, len(parts))
+			}
+		case len(parts) > 1:
+			// A column reference, possibly qualified by a table name.
+			if n := sv.scopes[ident].tableParts(parts, len(parts)-1); n > 0 {
+				err = r.rename(ident, n)
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// qualifierScope holds the sources that the column references of a query
+// can be qualified by, along with those of the queries enclosing it.
+type qualifierScope struct {
+	parent *qualifierScope
+
+	// aliases holds the aliases of the sources and the names of the CTEs
+	// they read, which are never renamed.
+	aliases map[string]bool
+
+	// tables holds the names of the tables read without an alias.
+	tables []*query.MultiPartIdent
+}
+
+// tableParts returns how many of the leading parts of a reference, at most
+// max, name a table read without an alias. A qualifier names such a table
+// if it matches the end of its name, as t or s.t does for db.s.t. It
+// returns 0 if the reference is qualified by an alias or by no source, as
+// for a field of a struct column.
+func (s *qualifierScope) tableParts(parts []*query.Ident, max int) int {
+	for ; s != nil; s = s.parent {
+		if s.aliases[strings.ToLower(parts[0].Name)] {
+			return 0
+		}
+
+		n := 0
+		for _, table := range s.tables {
+			name := identParts(table)
+			for k := max; k > n; k-- {
+				if k <= len(name) && equalParts(parts[:k], name[len(name)-k:]) {
+					n = k
+					break
+				}
+			}
+		}
+		if n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// equalParts returns true if a and b hold the same names, ignoring case.
+func equalParts(a, b []*query.Ident) bool {
+	for i := range a {
+		if !strings.EqualFold(a[i].Name, b[i].Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// scopeVisitor records the qualifier scope of each name in a statement.
+type scopeVisitor struct {
+	ctes   map[*query.QualifiedTableName]bool
+	scope  *qualifierScope
+	scopes map[*query.MultiPartIdent]*qualifierScope
+}
+
+func (v *scopeVisitor) Visit(n query.Node) (query.Visitor, query.Node, error) {
+	var sources []query.Source
+	switch n := n.(type) {
+	case *query.MultiPartIdent:
+		v.scopes[n] = v.scope
+		return nil, n, nil
+	case *query.SelectStatement:
+		sources = []query.Source{n.Source}
+	case *query.DeleteStatement:
+		sources = []query.Source{n.Table}
+	case *query.MergeStatement:
+		sources = []query.Source{n.Target, n.Source}
+	case *query.InsertStatement:
+		if n.Table != nil {
+			sources = []query.Source{&query.QualifiedTableName{Name: n.Table, Alias: n.Alias}}
+		}
+	default:
+		return v, n, nil
+	}
+
+	scope := &qualifierScope{parent: v.scope, aliases: make(map[string]bool)}
+	for _, src := range sources {
+		scope.addSource(src, v.ctes)
+	}
+	return &scopeVisitor{ctes: v.ctes, scope: scope, scopes: v.scopes}, n, nil
+}
+
+func (v *scopeVisitor) VisitEnd(n query.Node) (query.Node, error) {
+	return n, nil
+}
+
+// addSource adds the aliases and unaliased tables of src to s. Subqueries
+// have scopes of their own.
+func (s *qualifierScope) addSource(src query.Source, ctes map[*query.QualifiedTableName]bool) {
+	addAlias := func(alias *query.Ident) {
+		if alias != nil {
+			s.aliases[strings.ToLower(alias.Name)] = true
+		}
+	}
+
+	switch src := src.(type) {
+	case *query.QualifiedTableName:
+		switch {
+		case src == nil:
+		case src.Alias != nil:
+			addAlias(src.Alias)
+		case ctes[src]:
+			addAlias(src.Name.Name)
+		case src.Name != nil:
+			s.tables = append(s.tables, src.Name)
+		}
+	case *query.JoinClause:
+		s.addSource(src.X, ctes)
+		s.addSource(src.Y, ctes)
+	case *query.ParenSource:
+		addAlias(src.Alias)
+		s.addSource(src.X, ctes)
+	case *query.PivotSource:
+		addAlias(src.Alias)
+		s.addSource(src.X, ctes)
+	case *query.UnpivotSource:
+		addAlias(src.Alias)
+		s.addSource(src.X, ctes)
+	case *query.SampleSource:
+		addAlias(src.Alias)
+		s.addSource(src.X, ctes)
+	case *query.LateralView:
+		addAlias(src.TableAlias)
+		s.addSource(src.X, ctes)
+	case *query.UnnestSource:
+		addAlias(src.Alias)
+	case *query.QualifiedTableFunctionName:
+		addAlias(src.Alias)
+	}
+}
+
+type renamer struct {
+	mapping map[string][]string
+}
+
+// rename rewrites the first n parts of ident, which name a table or function,
+// by the longest matching prefix in the mapping.
+func (r *renamer) rename(ident *query.MultiPartIdent, n int) error {
+	parts := identParts(ident)
+	for i := n; i > 0; i-- {
+		names := make([]string, i)
+		for j := range names {
+			names[j] = parts[j].Name
+		}
+
+		to, ok := r.mapping[strings.ToLower(strings.Join(names, "."))]
+		if !ok {
+			continue
+		}
+
+		renamed := make([]*query.Ident, 0, len(to)+len(parts)-i)
+		for j, name := range to {
+			part := &query.Ident{Name: name, Tok: query.IDENT}
+			if j < i {
+				part.NamePos, part.Tok = parts[j].NamePos, parts[j].Tok
+			}
+			renamed = append(renamed, part)
+		}
+		renamed = append(renamed, parts[i:]...)
+		return setIdentParts(ident, renamed)
+	}
+	return nil
+}
+
+// setIdentParts replaces the parts of ident.
+func setIdentParts(ident *query.MultiPartIdent, parts []*query.Ident) error {
+	if len(parts) > 4 {
+		var names []string
+		for _, part := range parts {
+			names = append(names, part.Name)
+		}
+		return fmt.Errorf("cannot rename %s to %s: too many name parts", ident, strings.Join(names, "."))
+	}
+
+	ident.First, ident.Second, ident.Third = nil, nil, nil
+	ident.Name = parts[len(parts)-1]
+	for i, part := range parts[:len(parts)-1] {
+		switch i {
+		case 0:
+			ident.First = part
+		case 1:
+			ident.Second = part
+		case 2:
+			ident.Third = part
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package transform_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query/transform"
+)
+
+func TestRename(t *testing.T) {
+	mapping := map[string]string{
+		"dev_project":            "prod_project",
+		"dev_project.tmp":        "prod_project.staging",
+		"dev_project.s.old_name": "prod_project.s.new_name",
+		"orders":                 "sales.orders",
+	}
+
+	t.Run("Tables", func(t *testing.T) {
+		AssertRename(t, mapping, `SELECT * FROM dev_project.s.t JOIN DEV_PROJECT.tmp.u ON t.id = u.id`,
+			`SELECT * FROM prod_project.s.t JOIN prod_project.staging.u ON t.id = u.id`)
+		AssertRename(t, mapping, `SELECT * FROM dev_project.s.old_name, other.s.t, orders`,
+			`SELECT * FROM prod_project.s.new_name, other.s.t, sales.orders`)
+		AssertRename(t, mapping, `INSERT OVERWRITE TABLE dev_project.s.t SELECT * FROM (SELECT a FROM dev_project.s.u) AS x`,
+			`INSERT OVERWRITE TABLE prod_project.s.t SELECT * FROM (SELECT a FROM prod_project.s.u) AS x`)
+		AssertRename(t, mapping, `MERGE INTO dev_project.s.t t USING dev_project.s.u u ON t.id = u.id WHEN MATCHED THEN DELETE`,
+			`MERGE INTO prod_project.s.t AS t USING prod_project.s.u AS u ON t.id = u.id WHEN MATCHED THEN DELETE`)
+		AssertRename(t, mapping, `DELETE FROM dev_project.s.t WHERE a = 1`,
+			`DELETE FROM prod_project.s.t WHERE a = 1`)
+	})
+
+	t.Run("DDL", func(t *testing.T) {
+		AssertRename(t, mapping, `CREATE TABLE dev_project.s.t AS SELECT 1`,
+			`CREATE TABLE prod_project.s.t AS SELECT 1`)
+		AssertRename(t, mapping, `DROP TABLE IF EXISTS dev_project.s.t`,
+			`DROP TABLE IF EXISTS prod_project.s.t`)
+		AssertRename(t, mapping, `TRUNCATE TABLE orders`,
+			`TRUNCATE TABLE sales.orders`)
+	})
+
+	t.Run("Columns", func(t *testing.T) {
+		AssertRename(t, mapping, `SELECT dev_project.s.t.a, dev_project.s.t.*, orders.id, o.*, o.b FROM dev_project.s.t, orders, x AS o`,
+			`SELECT prod_project.s.t.a, prod_project.s.t.*, sales.orders.id, o.*, o.b FROM prod_project.s.t, sales.orders, x AS o`)
+		AssertRename(t, mapping, `SELECT s.t.a, t.b FROM dev_project.s.t`,
+			`SELECT s.t.a, t.b FROM prod_project.s.t`)
+		AssertRename(t, mapping, `SELECT * FROM orders WHERE EXISTS (SELECT 1 FROM u WHERE u.id = orders.id)`,
+			`SELECT * FROM sales.orders WHERE EXISTS (SELECT 1 FROM u WHERE u.id = sales.orders.id)`)
+	})
+
+	t.Run("StructColumns", func(t *testing.T) {
+		AssertRename(t, mapping, `SELECT orders.id, orders.item.sku FROM dev_project.s.t`,
+			`SELECT orders.id, orders.item.sku FROM prod_project.s.t`)
+		AssertRename(t, mapping, `SELECT orders.id FROM t WHERE t.id IN (SELECT id FROM orders)`,
+			`SELECT orders.id FROM t WHERE t.id IN (SELECT id FROM sales.orders)`)
+		AssertRename(t, mapping, `SELECT orders.id FROM (SELECT orders FROM t) AS x`,
+			`SELECT orders.id FROM (SELECT orders FROM t) AS x`)
+	})
+
+	t.Run("Functions", func(t *testing.T) {
+		AssertRename(t, mapping, `SELECT dev_project.udf(a), orders(b), count(*) FROM t`,
+			`SELECT prod_project.udf(a), orders(b), count(*) FROM t`)
+	})
+
+	t.Run("CTE", func(t *testing.T) {
+		AssertRename(t, mapping, `WITH orders AS (SELECT * FROM orders) SELECT orders.id FROM orders`,
+			`WITH orders AS (SELECT * FROM sales.orders) SELECT orders.id FROM orders`)
+		AssertRename(t, mapping, `SELECT orders.id FROM x AS orders`,
+			`SELECT orders.id FROM x AS orders`)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, err := transform.Rename(ParseStatement(t, `SELECT * FROM dev_project.s.t`), map[string]string{"dev_project": "a.b.c"})
+		assert.EqualError(t, err, "cannot rename dev_project.s.t to a.b.c.s.t: too many name parts")
+
+		_, err = transform.Rename(ParseStatement(t, `SELECT 1`), map[string]string{"a": ""})
+		assert.EqualError(t, err, `invalid rename "a" to ""`)
+	})
+}
+
+// AssertRename asserts that renaming s by mapping gives want.
+func AssertRename(tb testing.TB, mapping map[string]string, s, want string) {
+	tb.Helper()
+
+	out, err := transform.Rename(ParseStatement(tb, s), mapping)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	assert.Equal(tb, want, out.String())
+}
@@ -0,0 +1,92 @@
+// Package transform rewrites parsed statements. Transforms never modify the
+// statement they are given; they return a rewritten copy.
+package transform
+
+import (
+	"strings"
+
+	"github.com/sbchaos/query"
+)
+
+// tableName returns the dotted name of ident without quoting.
+func tableName(ident *query.MultiPartIdent) string {
+	if ident == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, part := range identParts(ident) {
+		parts = append(parts, part.Name)
+	}
+	return strings.Join(parts, ".")
+}
+
+// identParts returns the parts of ident in order.
+func identParts(ident *query.MultiPartIdent) []*query.Ident {
+	var parts []*query.Ident
+	for _, part := range []*query.Ident{ident.First, ident.Second, ident.Third, ident.Name} {
+		if part != nil {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// cteRefs returns the table references in stmt that refer to a CTE rather
+// than a table. A CTE is visible to the query it belongs to and to the CTEs
+// defined after it, or to every CTE of a WITH RECURSIVE clause.
+func cteRefs(stmt query.Statement) map[*query.QualifiedTableName]bool {
+	refs := make(map[*query.QualifiedTableName]bool)
+	resolveCTERefs(stmt, nil, nil, refs)
+	return refs
+}
+
+// resolveCTERefs records the references to the CTEs in scope within n. The
+// CTEs of skip are already in scope, so skip itself is not visited.
+func resolveCTERefs(n query.Node, skip *query.WithClause, scope map[string]bool, refs map[*query.QualifiedTableName]bool) {
+	query.Inspect(n, func(n query.Node) bool {
+		var with *query.WithClause
+		switch n := n.(type) {
+		case *query.SelectStatement:
+			with = n.WithClause
+		case *query.InsertStatement:
+			with = n.WithClause
+		case *query.DeleteStatement:
+			with = n.WithClause
+		case *query.WithClause:
+			return n != skip
+		case *query.QualifiedTableName:
+			if name := n.Name; name != nil && name.First == nil && name.Name != nil && scope[strings.ToLower(name.Name.Name)] {
+				refs[n] = true
+			}
+		}
+		if with == nil || with == skip {
+			return true
+		}
+
+		inner := make(map[string]bool, len(scope)+len(with.CTEs))
+		for name := range scope {
+			inner[name] = true
+		}
+		recursive := with.Recursive.IsValid()
+		for _, cte := range with.CTEs {
+			if recursive && cte.TableName != nil {
+				inner[strings.ToLower(cte.TableName.Name)] = true
+			}
+		}
+		for _, cte := range with.CTEs {
+			if cte.Select != nil {
+				visible := make(map[string]bool, len(inner))
+				for name := range inner {
+					visible[name] = true
+				}
+				resolveCTERefs(cte.Select, nil, visible, refs)
+			}
+			if cte.TableName != nil {
+				inner[strings.ToLower(cte.TableName.Name)] = true
+			}
+		}
+		resolveCTERefs(n, with, inner, refs)
+		return false
+	})
+}
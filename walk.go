@@ -0,0 +1,726 @@
+package query
+
+import "reflect"
+
+// A Visitor's Visit method is invoked for each node encountered by Walk.
+// If the result visitor w is not nil, Walk visits each of the children
+// of node with the visitor w, followed by a call of w.VisitEnd(node).
+//
+// The node returned from Visit or VisitEnd replaces the visited node
+// within its parent, so it must be assignable to the field that holds it.
+type Visitor interface {
+	Visit(node Node) (w Visitor, n Node, err error)
+	VisitEnd(node Node) (Node, error)
+}
+
+// Walk traverses an AST in depth-first order: It starts by calling v.Visit(node);
+// node must not be nil. If the visitor w returned by v.Visit(node) is not nil,
+// Walk is invoked recursively with visitor w for each of the non-nil children
+// of node, followed by a call of w.VisitEnd(node). Children are visited in the
+// order they appear in the statement text.
+func Walk(v Visitor, node Node) (Node, error) {
+	return walk(v, node)
+}
+
+// Inspect traverses an AST in depth-first order: It starts by calling f(node);
+// node must not be nil. If f returns true, Inspect invokes f recursively for
+// each of the non-nil children of node.
+func Inspect(node Node, f func(Node) bool) {
+	_, _ = Walk(inspector(f), node)
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) (Visitor, Node, error) {
+	if f(node) {
+		return f, node, nil
+	}
+	return nil, node, nil
+}
+
+func (f inspector) VisitEnd(node Node) (Node, error) {
+	return node, nil
+}
+
+func walk(v Visitor, node Node) (_ Node, err error) {
+	w, node, err := v.Visit(node)
+	if err != nil || w == nil || isNilNode(node) {
+		return node, err
+	}
+
+	switch n := node.(type) {
+	case *BinaryExpr:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Y, err = walkNode(w, n.Y); err != nil {
+			return node, err
+		}
+
+	case *Call:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Args); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.OrderingTerms); err != nil {
+			return node, err
+		}
+		if n.LimitExpr, err = walkNode(w, n.LimitExpr); err != nil {
+			return node, err
+		}
+		if n.SeparatorExpr, err = walkNode(w, n.SeparatorExpr); err != nil {
+			return node, err
+		}
+		if n.Within, err = walkNode(w, n.Within); err != nil {
+			return node, err
+		}
+		if n.FilterExpr, err = walkNode(w, n.FilterExpr); err != nil {
+			return node, err
+		}
+		if n.Over, err = walkNode(w, n.Over); err != nil {
+			return node, err
+		}
+
+	case *Params:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Type, err = walkNode(w, n.Type); err != nil {
+			return node, err
+		}
+
+	case *CastExpr:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Type, err = walkNode(w, n.Type); err != nil {
+			return node, err
+		}
+
+	case *Type:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if n.Precision, err = walkNode(w, n.Precision); err != nil {
+			return node, err
+		}
+		if n.Scale, err = walkNode(w, n.Scale); err != nil {
+			return node, err
+		}
+
+	case *CaseExpr:
+		if n.Operand, err = walkNode(w, n.Operand); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Blocks); err != nil {
+			return node, err
+		}
+		if n.ElseExpr, err = walkNode(w, n.ElseExpr); err != nil {
+			return node, err
+		}
+
+	case *CaseBlock:
+		if n.Condition, err = walkNode(w, n.Condition); err != nil {
+			return node, err
+		}
+		if n.Body, err = walkNode(w, n.Body); err != nil {
+			return node, err
+		}
+
+	case *Null:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+
+	case *ExprList:
+		if err = walkNodeList(w, n.Exprs); err != nil {
+			return node, err
+		}
+
+	case *Exists:
+		if n.Select, err = walkNode(w, n.Select); err != nil {
+			return node, err
+		}
+
+	case *MultiPartIdent:
+		if n.First, err = walkNode(w, n.First); err != nil {
+			return node, err
+		}
+		if n.Second, err = walkNode(w, n.Second); err != nil {
+			return node, err
+		}
+		if n.Third, err = walkNode(w, n.Third); err != nil {
+			return node, err
+		}
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+
+	case *ParenExpr:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+
+	case *Range:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Y, err = walkNode(w, n.Y); err != nil {
+			return node, err
+		}
+
+	case *QualifiedRef:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+
+	case *UnaryExpr:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+
+	case *IndexExpr:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Index, err = walkNode(w, n.Index); err != nil {
+			return node, err
+		}
+		if n.Call, err = walkNode(w, n.Call); err != nil {
+			return node, err
+		}
+
+	case SelectExpr:
+		if n.SelectStatement, err = walkNode(w, n.SelectStatement); err != nil {
+			return node, err
+		}
+		node = n
+
+	case *SelectStatement:
+		if n.WithClause, err = walkNode(w, n.WithClause); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.ValueLists); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+		if n.Source, err = walkNode(w, n.Source); err != nil {
+			return node, err
+		}
+		if n.WhereExpr, err = walkNode(w, n.WhereExpr); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.GroupByExprs); err != nil {
+			return node, err
+		}
+		if n.GroupingSets, err = walkNode(w, n.GroupingSets); err != nil {
+			return node, err
+		}
+		if n.HavingExpr, err = walkNode(w, n.HavingExpr); err != nil {
+			return node, err
+		}
+		if n.QualifyExpr, err = walkNode(w, n.QualifyExpr); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Windows); err != nil {
+			return node, err
+		}
+		if n.Compound, err = walkNode(w, n.Compound); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.OrderingTerms); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.ClusterExprs); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.DistributeExprs); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.SortTerms); err != nil {
+			return node, err
+		}
+		if n.LimitExpr, err = walkNode(w, n.LimitExpr); err != nil {
+			return node, err
+		}
+		if n.OffsetExpr, err = walkNode(w, n.OffsetExpr); err != nil {
+			return node, err
+		}
+
+	case *SetOperation:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Y, err = walkNode(w, n.Y); err != nil {
+			return node, err
+		}
+
+	case *WithClause:
+		if err = walkNodeList(w, n.CTEs); err != nil {
+			return node, err
+		}
+
+	case *CTE:
+		if n.TableName, err = walkNode(w, n.TableName); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+		if n.Select, err = walkNode(w, n.Select); err != nil {
+			return node, err
+		}
+
+	case *Within:
+		if n.OrderingTerm, err = walkNode(w, n.OrderingTerm); err != nil {
+			return node, err
+		}
+		if n.GroupLimitExpr, err = walkNode(w, n.GroupLimitExpr); err != nil {
+			return node, err
+		}
+		if n.Index, err = walkNode(w, n.Index); err != nil {
+			return node, err
+		}
+
+	case *ResultColumn:
+		if n.Expr, err = walkNode(w, n.Expr); err != nil {
+			return node, err
+		}
+		if n.ExceptCol, err = walkNode(w, n.ExceptCol); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+		if n.Type, err = walkNode(w, n.Type); err != nil {
+			return node, err
+		}
+
+	case *LateralView:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Udtf, err = walkNode(w, n.Udtf); err != nil {
+			return node, err
+		}
+		if n.TableAlias, err = walkNode(w, n.TableAlias); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.ColAlias); err != nil {
+			return node, err
+		}
+
+	case *QualifiedTableName:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+
+	case *ParenSource:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+
+	case *PivotSource:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Aggregates); err != nil {
+			return node, err
+		}
+		if n.Column, err = walkNode(w, n.Column); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Values); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+
+	case *UnpivotSource:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Value, err = walkNode(w, n.Value); err != nil {
+			return node, err
+		}
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+
+	case *UnnestSource:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+		if n.OffsetAlias, err = walkNode(w, n.OffsetAlias); err != nil {
+			return node, err
+		}
+
+	case *SampleSource:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Method, err = walkNode(w, n.Method); err != nil {
+			return node, err
+		}
+		if n.Size, err = walkNode(w, n.Size); err != nil {
+			return node, err
+		}
+		if n.BucketExpr, err = walkNode(w, n.BucketExpr); err != nil {
+			return node, err
+		}
+		if n.Buckets, err = walkNode(w, n.Buckets); err != nil {
+			return node, err
+		}
+		if n.OnExpr, err = walkNode(w, n.OnExpr); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+
+	case *PivotExpr:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+
+	case *JoinClause:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Operator, err = walkNode(w, n.Operator); err != nil {
+			return node, err
+		}
+		if n.Y, err = walkNode(w, n.Y); err != nil {
+			return node, err
+		}
+		if n.Constraint, err = walkNode(w, n.Constraint); err != nil {
+			return node, err
+		}
+
+	case *OnConstraint:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+
+	case *UsingConstraint:
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+
+	case *QualifiedTableFunctionName:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Args); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+
+	case *OverClause:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if n.Definition, err = walkNode(w, n.Definition); err != nil {
+			return node, err
+		}
+
+	case *OrderingTerm:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+
+	case *Window:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if n.Definition, err = walkNode(w, n.Definition); err != nil {
+			return node, err
+		}
+
+	case *WindowDefinition:
+		if n.Base, err = walkNode(w, n.Base); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Partitions); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.OrderingTerms); err != nil {
+			return node, err
+		}
+		if n.Frame, err = walkNode(w, n.Frame); err != nil {
+			return node, err
+		}
+
+	case *GroupingSets:
+		if err = walkNodeList(w, n.Exprs); err != nil {
+			return node, err
+		}
+
+	case *Rollup:
+		if err = walkNodeList(w, n.Exprs); err != nil {
+			return node, err
+		}
+
+	case *Cube:
+		if err = walkNodeList(w, n.Exprs); err != nil {
+			return node, err
+		}
+
+	case *FrameSpec:
+		if n.Start, err = walkNode(w, n.Start); err != nil {
+			return node, err
+		}
+		if n.End, err = walkNode(w, n.End); err != nil {
+			return node, err
+		}
+
+	case *FrameBound:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+
+	case *DeclarationStatement:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if n.Type, err = walkNode(w, n.Type); err != nil {
+			return node, err
+		}
+		if n.Value, err = walkNode(w, n.Value); err != nil {
+			return node, err
+		}
+
+	case *InsertStatement:
+		if n.WithClause, err = walkNode(w, n.WithClause); err != nil {
+			return node, err
+		}
+		if n.Table, err = walkNode(w, n.Table); err != nil {
+			return node, err
+		}
+		if n.Alias, err = walkNode(w, n.Alias); err != nil {
+			return node, err
+		}
+		if n.Partition, err = walkNode(w, n.Partition); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.ValueLists); err != nil {
+			return node, err
+		}
+		if n.Select, err = walkNode(w, n.Select); err != nil {
+			return node, err
+		}
+		if n.UpsertClause, err = walkNode(w, n.UpsertClause); err != nil {
+			return node, err
+		}
+		if n.ReturningClause, err = walkNode(w, n.ReturningClause); err != nil {
+			return node, err
+		}
+
+	case *PartitionSpec:
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+
+	case *PartitionColumn:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if n.Value, err = walkNode(w, n.Value); err != nil {
+			return node, err
+		}
+
+	case *UpsertClause:
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+		if n.WhereExpr, err = walkNode(w, n.WhereExpr); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Assignments); err != nil {
+			return node, err
+		}
+		if n.UpdateWhereExpr, err = walkNode(w, n.UpdateWhereExpr); err != nil {
+			return node, err
+		}
+
+	case *ReturningClause:
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+
+	case *DeleteStatement:
+		if n.WithClause, err = walkNode(w, n.WithClause); err != nil {
+			return node, err
+		}
+		if n.Table, err = walkNode(w, n.Table); err != nil {
+			return node, err
+		}
+		if n.WhereExpr, err = walkNode(w, n.WhereExpr); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.OrderingTerms); err != nil {
+			return node, err
+		}
+		if n.LimitExpr, err = walkNode(w, n.LimitExpr); err != nil {
+			return node, err
+		}
+		if n.OffsetExpr, err = walkNode(w, n.OffsetExpr); err != nil {
+			return node, err
+		}
+		if n.ReturningClause, err = walkNode(w, n.ReturningClause); err != nil {
+			return node, err
+		}
+
+	case *Assignment:
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+		if n.Expr, err = walkNode(w, n.Expr); err != nil {
+			return node, err
+		}
+
+	case *IndexedColumn:
+		if n.X, err = walkNode(w, n.X); err != nil {
+			return node, err
+		}
+		if n.Collation, err = walkNode(w, n.Collation); err != nil {
+			return node, err
+		}
+
+	case *CreateTableStatement:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Columns); err != nil {
+			return node, err
+		}
+		if n.Select, err = walkNode(w, n.Select); err != nil {
+			return node, err
+		}
+
+	case *ColumnDefinition:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if n.Type, err = walkNode(w, n.Type); err != nil {
+			return node, err
+		}
+
+	case *DropTableStatement:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+
+	case *MergeStatement:
+		if n.Target, err = walkNode(w, n.Target); err != nil {
+			return node, err
+		}
+		if n.Source, err = walkNode(w, n.Source); err != nil {
+			return node, err
+		}
+		if n.OnExpr, err = walkNode(w, n.OnExpr); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Matched); err != nil {
+			return node, err
+		}
+
+	case *MatchedCondition:
+		if n.AndExpr, err = walkNode(w, n.AndExpr); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Assignments); err != nil {
+			return node, err
+		}
+		if n.ColList, err = walkNode(w, n.ColList); err != nil {
+			return node, err
+		}
+		if n.ValueLists, err = walkNode(w, n.ValueLists); err != nil {
+			return node, err
+		}
+
+	case *FunctionStatement:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+		if err = walkNodeList(w, n.Params); err != nil {
+			return node, err
+		}
+		if n.ReturnParam, err = walkNode(w, n.ReturnParam); err != nil {
+			return node, err
+		}
+		if n.FnExpr, err = walkNode(w, n.FnExpr); err != nil {
+			return node, err
+		}
+
+	case *TruncateStatement:
+		if n.Name, err = walkNode(w, n.Name); err != nil {
+			return node, err
+		}
+	}
+
+	return w.VisitEnd(node)
+}
+
+// walkNode walks x, if it is not nil, and returns its replacement.
+func walkNode[T Node](v Visitor, x T) (T, error) {
+	if isNilNode(x) {
+		return x, nil
+	}
+
+	n, err := walk(v, x)
+	if err != nil {
+		return x, err
+	} else if isNilNode(n) {
+		var zero T
+		return zero, nil
+	}
+	return n.(T), nil
+}
+
+// walkNodeList walks each element of a in place.
+func walkNodeList[T Node](v Visitor, a []T) (err error) {
+	for i := range a {
+		if a[i], err = walkNode(v, a[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNilNode returns true if n is nil or holds a nil pointer.
+func isNilNode(n Node) bool {
+	if n == nil {
+		return true
+	}
+	v := reflect.ValueOf(n)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
@@ -0,0 +1,72 @@
+package query_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sbchaos/query"
+)
+
+func TestInspect(t *testing.T) {
+	stmt := ParseStatement(t, `WITH c AS (SELECT x FROM s) SELECT a, f(b) FROM t JOIN c ON t.id = c.id WHERE d > 1 ORDER BY e`)
+
+	var idents []string
+	query.Inspect(stmt, func(n query.Node) bool {
+		if ident, ok := n.(*query.Ident); ok {
+			idents = append(idents, ident.Name)
+		}
+		return true
+	})
+	assert.Equal(t, []string{"c", "x", "s", "a", "f", "b", "t", "c", "t", "id", "c", "id", "d", "e"}, idents)
+
+	// Returning false skips the children of a node.
+	var n int
+	query.Inspect(stmt, func(node query.Node) bool {
+		if _, ok := node.(*query.Ident); ok {
+			n++
+		}
+		_, ok := node.(*query.WithClause)
+		return !ok
+	})
+	assert.Equal(t, 11, n)
+}
+
+func TestNodePos(t *testing.T) {
+	stmt := ParseStatement(t, `WITH c AS (SELECT x FROM s) SELECT a + 1 FROM t`).(*query.SelectStatement)
+	assert.Equal(t, pos(0), query.NodePos(stmt))
+	assert.Equal(t, pos(5), query.NodePos(stmt.WithClause.CTEs[0]))
+	assert.Equal(t, pos(35), query.NodePos(stmt.Columns[0]))
+	assert.Equal(t, query.Pos{}, query.NodePos(&query.Null{}))
+	assert.Equal(t, query.Pos{}, query.NodePos(nil))
+}
+
+func TestWalk(t *testing.T) {
+	stmt := ParseStatement(t, `SELECT a FROM t WHERE b = 1 AND (SELECT max(c) FROM u) > 2`)
+
+	out, err := query.Walk(&renamer{from: "t", to: "t2"}, stmt)
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT a FROM t2 WHERE b = 1 AND (SELECT max(c) FROM u) > 2`, out.String())
+
+	out, err = query.Walk(&renamer{from: "u", to: "u2"}, stmt)
+	assert.NoError(t, err)
+	assert.Equal(t, `SELECT a FROM t2 WHERE b = 1 AND (SELECT max(c) FROM u2) > 2`, out.String())
+}
+
+// renamer replaces references to the table from with to.
+type renamer struct {
+	from, to string
+}
+
+func (r *renamer) Visit(node query.Node) (query.Visitor, query.Node, error) {
+	if tbl, ok := node.(*query.QualifiedTableName); ok && tbl.Name.String() == r.from {
+		return nil, &query.QualifiedTableName{
+			Name: &query.MultiPartIdent{Name: &query.Ident{Name: r.to, Tok: query.IDENT}},
+		}, nil
+	}
+	return r, node, nil
+}
+
+func (r *renamer) VisitEnd(node query.Node) (query.Node, error) {
+	return node, nil
+}